@@ -141,8 +141,9 @@ func AuthMiddleware(action, resourceType string) gin.HandlerFunc {
 		user := enforcement.UserBuilder(userKey).Build()
 		resource := enforcement.ResourceBuilder(resourceType).Build()
 
-		// Check permission
-		permitted, err := permisClient.Check(user, enforcement.Action(action), resource)
+		// Check permission with the request context, so a cancelled request
+		// stops the check and its deadline and tracing carry over
+		permitted, err := permisClient.CheckWithContext(c.Request.Context(), user, enforcement.Action(action), resource)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Permission check failed",
@@ -182,7 +183,7 @@ func AuthMiddlewareWithTenant(action, resourceType string) gin.HandlerFunc {
 			WithTenant(tenantKey).
 			Build()
 
-		permitted, err := permisClient.Check(user, enforcement.Action(action), resource)
+		permitted, err := permisClient.CheckWithContext(c.Request.Context(), user, enforcement.Action(action), resource)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Permission check failed",
@@ -223,7 +224,7 @@ func AuthMiddlewareWithResource(action, resourceType string) gin.HandlerFunc {
 			WithKey(resourceKey).
 			Build()
 
-		permitted, err := permisClient.Check(user, enforcement.Action(action), resource)
+		permitted, err := permisClient.CheckWithContext(c.Request.Context(), user, enforcement.Action(action), resource)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Permission check failed",
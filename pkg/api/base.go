@@ -1,21 +1,49 @@
 // Package api provides API client implementations for the Permissio.io SDK.
+//
+// Every list method (List, ListAll, Count, ...) accepts nil params, which
+// behaves exactly like empty params: no filters and the default pagination,
+// that is the first page with config.Config.DefaultPageSize items (or the
+// server's page size when unset).
 package api
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/enforcement"
 	"go.uber.org/zap"
 )
 
+// Metric names reported to config.Metrics, labeled by HTTP method.
+const (
+	// MetricRetryTotal counts request retries.
+	MetricRetryTotal = "permis_retry_total"
+
+	// MetricRetryBackoffSeconds observes each backoff delay before a retry.
+	MetricRetryBackoffSeconds = "permis_retry_backoff_seconds"
+
+	// MetricRequestAttempts observes the number of attempts per successful request.
+	MetricRequestAttempts = "permis_request_attempts"
+)
+
+// IdempotencyKeyHeader is the header that makes non-idempotent requests safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// backoffTimer returns a channel that receives once a retry backoff has
+// elapsed. It is a variable so tests can control the backoff.
+var backoffTimer = time.After
+
 // BaseClient provides common HTTP functionality for API clients.
 type BaseClient struct {
 	config *config.Config
@@ -33,117 +61,261 @@ func (c *BaseClient) Config() *config.Config {
 	return c.config
 }
 
+// debugEnabled returns true if debug logging is enabled for the request,
+// either globally in the config or for this context via enforcement.WithDebug.
+func (c *BaseClient) debugEnabled(ctx context.Context) bool {
+	return c.config.Logger != nil && (c.config.Debug || enforcement.DebugFromContext(ctx))
+}
+
 // BuildURL builds a URL for the given path.
+// The path is appended to the API URL verbatim, without a version segment.
 func (c *BaseClient) BuildURL(path string) string {
 	return fmt.Sprintf("%s%s", c.config.ApiURL, path)
 }
 
 // BuildFactsURL builds a URL for facts endpoints.
+// Without a scope it falls back to an unscoped URL, which most endpoints
+// reject; Init (or an explicit scope) must succeed first. A warning is logged
+// in debug mode, and requests fail with ErrMissingScope in strict scope mode.
 func (c *BaseClient) BuildFactsURL(path string) string {
-	if c.config.HasScope() {
-		return fmt.Sprintf("%s/v1/facts/%s/%s%s",
-			c.config.ApiURL,
-			c.config.ProjectID,
-			c.config.EnvironmentID,
-			path)
-	}
-	return fmt.Sprintf("%s/v1%s", c.config.ApiURL, path)
+	return c.buildScopedURL("facts", path, true)
 }
 
 // BuildSchemaURL builds a URL for schema endpoints.
+// The same scope requirements as BuildFactsURL apply.
 func (c *BaseClient) BuildSchemaURL(path string) string {
-	if c.config.HasScope() {
-		return fmt.Sprintf("%s/v1/schema/%s/%s%s",
+	return c.buildScopedURL("schema", path, true)
+}
+
+// PreviewURL returns the URL a GET request for path is sent to, read replica
+// included, without making a request. kind is "facts" or "schema" for the
+// URLs of BuildFactsURL and BuildSchemaURL, or "" for BuildURL. Unlike those,
+// it logs no warning when the scope is missing.
+func (c *BaseClient) PreviewURL(kind, path string) string {
+	endpoint := c.BuildURL(path)
+	if kind != "" {
+		endpoint = c.buildScopedURL(kind, path, false)
+	}
+	return c.routeURL(http.MethodGet, endpoint)
+}
+
+// buildScopedURL builds the URL of path in the kind ("facts" or "schema") of
+// endpoints of the current scope. Without a scope it returns the unscoped
+// fallback, logging a debug-mode warning if warn is set.
+func (c *BaseClient) buildScopedURL(kind, path string, warn bool) string {
+	if projectID, environmentID := c.config.Scope(); projectID != "" && environmentID != "" {
+		return fmt.Sprintf("%s/%s/%s/%s/%s%s",
 			c.config.ApiURL,
-			c.config.ProjectID,
-			c.config.EnvironmentID,
+			c.config.Version(),
+			kind,
+			projectID,
+			environmentID,
 			path)
 	}
-	return fmt.Sprintf("%s/v1%s", c.config.ApiURL, path)
+	if warn {
+		c.warnMissingScope(path)
+	}
+	return fmt.Sprintf("%s/%s%s", c.config.ApiURL, c.config.Version(), path)
+}
+
+// warnMissingScope logs a debug-mode warning when an unscoped URL is built.
+func (c *BaseClient) warnMissingScope(path string) {
+	if c.config.Debug && c.config.Logger != nil {
+		c.config.Logger.Warn("Building URL without project/environment scope; "+
+			"call Init or configure the scope explicitly",
+			zap.String("path", path))
+	}
 }
 
 // Request performs an HTTP request with retry logic.
-func (c *BaseClient) Request(ctx context.Context, method, url string, body interface{}, result interface{}) error {
+// Idempotent methods (GET, PUT, DELETE) are retried on failure. POST and PATCH
+// are only retried when RetryWrites is enabled or an Idempotency-Key header is
+// configured or set on ctx with enforcement.WithHeaders, since retrying them
+// could duplicate writes. A 401 is retried once, outside of the retry count,
+// after a successful TokenRefresher call. A 404 on a retried
+// DELETE is treated as success, since the object is gone either way.
+// The number of retries is RetryAttempts, unless ctx carries a per-call
+// count set with enforcement.WithRetries, which takes precedence.
+func (c *BaseClient) Request(ctx context.Context, method, endpoint string, body interface{}, result interface{}) error {
+	_, err := c.request(ctx, method, endpoint, body, result)
+	return err
+}
+
+// responseInfo is the status and headers of a successful response.
+type responseInfo struct {
+	StatusCode int
+	Header     http.Header
+}
+
+// request performs an HTTP request with retry logic and returns the status
+// and headers of the successful response.
+func (c *BaseClient) request(ctx context.Context, method, endpoint string, body interface{}, result interface{}) (responseInfo, error) {
+	if c.config.StrictScope && !c.config.HasScope() && strings.HasPrefix(endpoint, c.config.ApiURL) {
+		return responseInfo{}, ErrMissingScope
+	}
+	return c.send(ctx, method, endpoint, body, result)
+}
+
+// send performs an HTTP request like request, without the StrictScope check.
+func (c *BaseClient) send(ctx context.Context, method, endpoint string, body interface{}, result interface{}) (responseInfo, error) {
+	endpoint = c.routeURL(method, endpoint)
+
 	var lastErr error
+	retryable := c.isRetryable(ctx, method)
+	refreshed := false
+	retries := c.config.RetryAttempts
+	if n, ok := enforcement.RetriesFromContext(ctx); ok {
+		retries = n
+	}
 
-	for attempt := 0; attempt <= c.config.RetryAttempts; attempt++ {
+	for attempt := 0; attempt <= retries; attempt++ {
 		if attempt > 0 {
 			// Exponential backoff
 			backoff := time.Duration(attempt*attempt) * 100 * time.Millisecond
+			if metrics := c.config.Metrics; metrics != nil {
+				labels := map[string]string{"method": method}
+				metrics.IncCounter(MetricRetryTotal, labels)
+				metrics.ObserveHistogram(MetricRetryBackoffSeconds, backoff.Seconds(), labels)
+			}
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoff):
+				// Keep the error that triggered the retry alongside the cancellation
+				return responseInfo{}, errors.Join(ctx.Err(), lastErr)
+			case <-backoffTimer(backoff):
 			}
 		}
 
-		err := c.doRequest(ctx, method, url, body, result)
+		info, err := c.doRequest(ctx, method, endpoint, body, result)
+		if err != nil && !refreshed && c.config.TokenRefresher != nil && isUnauthorized(err) {
+			// Only one refresh per request, so a rejected new token can't loop
+			refreshed = true
+			if c.refreshToken(ctx) {
+				info, err = c.doRequest(ctx, method, endpoint, body, result)
+			}
+		}
 		if err == nil {
-			return nil
+			if metrics := c.config.Metrics; metrics != nil {
+				metrics.ObserveHistogram(MetricRequestAttempts, float64(attempt+1), map[string]string{"method": method})
+			}
+			return info, nil
+		}
+
+		// A 404 on a retried DELETE means an earlier attempt most likely
+		// succeeded before its response was lost
+		if attempt > 0 && method == http.MethodDelete && isNotFound(err) {
+			if c.debugEnabled(ctx) {
+				c.config.Logger.Debug("Retried DELETE returned 404, treating as deleted",
+					zap.Int("attempt", attempt+1))
+			}
+			return responseInfo{StatusCode: http.StatusNotFound}, nil
 		}
 
 		lastErr = err
 
+		if !retryable {
+			return responseInfo{}, err
+		}
+
 		// Don't retry on certain errors
 		if apiErr, ok := err.(*PermisError); ok {
 			if apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 {
-				return err // Don't retry client errors
+				return responseInfo{}, err // Don't retry client errors
 			}
 		}
 
-		if c.config.Debug && c.config.Logger != nil {
+		if c.debugEnabled(ctx) {
 			c.config.Logger.Debug("Request failed, retrying",
 				zap.Int("attempt", attempt+1),
 				zap.Error(err))
 		}
 	}
 
-	return lastErr
+	return responseInfo{}, lastErr
+}
+
+// refreshToken obtains a new token from the configured TokenRefresher and
+// stores it, returning false if the refresher failed.
+func (c *BaseClient) refreshToken(ctx context.Context) bool {
+	token, err := c.config.TokenRefresher(ctx)
+	if err != nil || token == "" {
+		if c.config.Logger != nil {
+			c.config.Logger.Warn("Token refresh failed", zap.Error(err))
+		}
+		return false
+	}
+	c.config.SetToken(token)
+	if c.debugEnabled(ctx) {
+		c.config.Logger.Debug("Token refreshed after 401, retrying request")
+	}
+	return true
+}
+
+// routeURL sends GET requests for API URLs to the read replica, if one is configured.
+func (c *BaseClient) routeURL(method, endpoint string) string {
+	if c.config.ReadURL == "" || method != http.MethodGet || !strings.HasPrefix(endpoint, c.config.ApiURL) {
+		return endpoint
+	}
+	return c.config.ReadURL + strings.TrimPrefix(endpoint, c.config.ApiURL)
+}
+
+// isRetryable returns true if requests with the given method may be retried.
+func (c *BaseClient) isRetryable(ctx context.Context, method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPatch:
+		return c.config.RetryWrites ||
+			c.config.CustomHeaders[IdempotencyKeyHeader] != "" ||
+			enforcement.HeadersFromContext(ctx)[IdempotencyKeyHeader] != ""
+	default:
+		return true
+	}
 }
 
-// doRequest performs a single HTTP request.
-func (c *BaseClient) doRequest(ctx context.Context, method, url string, body interface{}, result interface{}) error {
+// doRequest performs a single HTTP request and returns the response status and headers.
+func (c *BaseClient) doRequest(ctx context.Context, method, endpoint string, body interface{}, result interface{}) (responseInfo, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+			return responseInfo{}, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bodyReader)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return responseInfo{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set(c.config.AuthHeader())
 
-	// Add custom headers
+	// Add custom headers, then per-request headers, which take precedence
 	for key, value := range c.config.CustomHeaders {
 		req.Header.Set(key, value)
 	}
+	for key, value := range enforcement.HeadersFromContext(ctx) {
+		req.Header.Set(key, value)
+	}
 
-	if c.config.Debug && c.config.Logger != nil {
+	if c.debugEnabled(ctx) {
 		c.config.Logger.Debug("Making request",
 			zap.String("method", method),
-			zap.String("url", url))
+			zap.String("url", endpoint))
 	}
 
 	resp, err := c.config.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return responseInfo{}, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return responseInfo{}, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if c.config.Debug && c.config.Logger != nil {
+	if c.debugEnabled(ctx) {
 		c.config.Logger.Debug("Received response",
 			zap.Int("status", resp.StatusCode),
 			zap.String("body", string(respBody)))
@@ -151,21 +323,34 @@ func (c *BaseClient) doRequest(ctx context.Context, method, url string, body int
 
 	// Check for errors
 	if resp.StatusCode >= 400 {
-		return c.parseError(resp.StatusCode, respBody)
+		return responseInfo{}, c.parseError(resp.StatusCode, resp.Header.Get("Content-Type"), respBody)
 	}
 
 	// Parse result
 	if result != nil && len(respBody) > 0 {
 		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("failed to unmarshal response: %w", err)
+			return responseInfo{}, fmt.Errorf("failed to unmarshal response: %w", err)
 		}
 	}
 
-	return nil
+	return responseInfo{StatusCode: resp.StatusCode, Header: resp.Header}, nil
 }
 
 // parseError parses an error response.
-func (c *BaseClient) parseError(statusCode int, body []byte) error {
+// Non-JSON bodies, such as a gateway's HTML error page, get a short message;
+// the raw body is kept in Details["body"].
+func (c *BaseClient) parseError(statusCode int, contentType string, body []byte) error {
+	if !isJSONBody(contentType, body) {
+		return &PermisError{
+			Message:    "unexpected non-JSON response",
+			StatusCode: statusCode,
+			Details: map[string]interface{}{
+				"body":        string(body),
+				"contentType": contentType,
+			},
+		}
+	}
+
 	var errResp struct {
 		Message string `json:"message"`
 		Error   string `json:"error"`
@@ -194,34 +379,61 @@ func (c *BaseClient) parseError(statusCode int, body []byte) error {
 	}
 }
 
+// isJSONBody returns false for responses that are clearly not JSON: an HTML or
+// XML content type, or a body starting with "<". Other content types are not
+// trusted, since some servers label JSON errors as text/plain.
+func isJSONBody(contentType string, body []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+		(mediaType == "text/html" || strings.HasSuffix(mediaType, "xml")) {
+		return false
+	}
+	return !bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
+}
+
 // Get performs a GET request.
-func (c *BaseClient) Get(ctx context.Context, url string, result interface{}) error {
-	return c.Request(ctx, http.MethodGet, url, nil, result)
+func (c *BaseClient) Get(ctx context.Context, endpoint string, result interface{}) error {
+	return c.Request(ctx, http.MethodGet, endpoint, nil, result)
+}
+
+// GetUnscoped performs a GET request for an endpoint that doesn't depend on
+// the project and environment, such as the API key scope, with the same
+// retries, headers and token refresh as Get. Unlike Get, it is allowed under
+// StrictScope before the scope is known, so it can be used to resolve it.
+func (c *BaseClient) GetUnscoped(ctx context.Context, endpoint string, result interface{}) error {
+	_, err := c.send(ctx, http.MethodGet, endpoint, nil, result)
+	return err
 }
 
 // Post performs a POST request.
-func (c *BaseClient) Post(ctx context.Context, url string, body interface{}, result interface{}) error {
-	return c.Request(ctx, http.MethodPost, url, body, result)
+func (c *BaseClient) Post(ctx context.Context, endpoint string, body interface{}, result interface{}) error {
+	return c.Request(ctx, http.MethodPost, endpoint, body, result)
 }
 
 // Put performs a PUT request.
-func (c *BaseClient) Put(ctx context.Context, url string, body interface{}, result interface{}) error {
-	return c.Request(ctx, http.MethodPut, url, body, result)
+func (c *BaseClient) Put(ctx context.Context, endpoint string, body interface{}, result interface{}) error {
+	return c.Request(ctx, http.MethodPut, endpoint, body, result)
+}
+
+// upsert performs a PUT request and reports whether the server created the
+// object (201 Created) rather than updating it.
+func (c *BaseClient) upsert(ctx context.Context, endpoint string, body interface{}, result interface{}) (bool, error) {
+	info, err := c.request(ctx, http.MethodPut, endpoint, body, result)
+	return info.StatusCode == http.StatusCreated, err
 }
 
 // Patch performs a PATCH request.
-func (c *BaseClient) Patch(ctx context.Context, url string, body interface{}, result interface{}) error {
-	return c.Request(ctx, http.MethodPatch, url, body, result)
+func (c *BaseClient) Patch(ctx context.Context, endpoint string, body interface{}, result interface{}) error {
+	return c.Request(ctx, http.MethodPatch, endpoint, body, result)
 }
 
 // Delete performs a DELETE request.
-func (c *BaseClient) Delete(ctx context.Context, url string, result interface{}) error {
-	return c.Request(ctx, http.MethodDelete, url, nil, result)
+func (c *BaseClient) Delete(ctx context.Context, endpoint string, result interface{}) error {
+	return c.Request(ctx, http.MethodDelete, endpoint, nil, result)
 }
 
 // DeleteWithBody performs a DELETE request with a body.
-func (c *BaseClient) DeleteWithBody(ctx context.Context, url string, body interface{}, result interface{}) error {
-	return c.Request(ctx, http.MethodDelete, url, body, result)
+func (c *BaseClient) DeleteWithBody(ctx context.Context, endpoint string, body interface{}, result interface{}) error {
+	return c.Request(ctx, http.MethodDelete, endpoint, body, result)
 }
 
 // BuildQueryParams builds query parameters from a params struct.
@@ -245,6 +457,23 @@ func BuildQueryParams(baseURL string, params map[string]string) string {
 	return u.String()
 }
 
+// listURL appends the page, page size and filters of a List call to endpoint.
+// Empty filters are left out, and a zero perPage falls back to pageSize, so a
+// List call with nil params is the same as one with empty params.
+func (c *BaseClient) listURL(endpoint string, page, perPage int, filters map[string]string) string {
+	return BuildQueryParams(endpoint, ListParamsToMap(page, c.pageSize(perPage), filters))
+}
+
+// pageSize returns perPage, or the configured DefaultPageSize if perPage is
+// not positive. It is zero, leaving the page size to the server (or to the
+// paginator's DefaultPageSize), when neither is set.
+func (c *BaseClient) pageSize(perPage int) int {
+	if perPage > 0 {
+		return perPage
+	}
+	return c.config.DefaultPageSize
+}
+
 // ListParamsToMap converts list params to a map.
 func ListParamsToMap(page, perPage int, extra map[string]string) map[string]string {
 	params := make(map[string]string)
@@ -0,0 +1,554 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/enforcement"
+	"github.com/permissio/permissio-go/pkg/models"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestBuildURLsIncludeAPIVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		scoped  bool
+		facts   string
+		schema  string
+	}{
+		{"default scoped", "", true, "https://api.test/v1/facts/proj/env/users", "https://api.test/v1/schema/proj/env/roles"},
+		{"v2 scoped", "v2", true, "https://api.test/v2/facts/proj/env/users", "https://api.test/v2/schema/proj/env/roles"},
+		{"v2 unscoped", "v2", false, "https://api.test/v2/users", "https://api.test/v2/roles"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ApiURL: "https://api.test", APIVersion: tt.version}
+			if tt.scoped {
+				cfg.UpdateScope("proj", "env")
+			}
+			c := NewBaseClient(cfg)
+
+			if got := c.BuildFactsURL("/users"); got != tt.facts {
+				t.Errorf("BuildFactsURL() = %q, want %q", got, tt.facts)
+			}
+			if got := c.BuildSchemaURL("/roles"); got != tt.schema {
+				t.Errorf("BuildSchemaURL() = %q, want %q", got, tt.schema)
+			}
+		})
+	}
+}
+
+func TestContextScopedDebugLogging(t *testing.T) {
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	core, logs := observer.New(zapcore.DebugLevel)
+	cfg.Logger = zap.New(core)
+	c := NewBaseClient(cfg)
+
+	if err := c.Get(context.Background(), c.BuildFactsURL("/users"), nil); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Fatalf("expected no debug logs without debug enabled, got %d", logs.Len())
+	}
+
+	if err := c.Get(enforcement.WithDebug(context.Background()), c.BuildFactsURL("/users"), nil); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if logs.FilterMessage("Making request").Len() != 1 {
+		t.Errorf("expected request to be logged with context debug enabled")
+	}
+}
+
+func TestRetryPolicyByMethod(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		configure func(*config.Config)
+		want      int
+	}{
+		{"GET is retried", http.MethodGet, nil, 2},
+		{"POST is not retried by default", http.MethodPost, nil, 1},
+		{"PATCH is not retried by default", http.MethodPatch, nil, 1},
+		{"POST is retried with RetryWrites", http.MethodPost, func(c *config.Config) { c.RetryWrites = true }, 2},
+		{"POST is retried with an idempotency key", http.MethodPost, func(c *config.Config) {
+			c.CustomHeaders[IdempotencyKeyHeader] = "key-1"
+		}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			cfg.RetryAttempts = 1
+			if tt.configure != nil {
+				tt.configure(cfg)
+			}
+
+			err := NewBaseClient(cfg).Request(context.Background(), tt.method, cfg.ApiURL+"/v1/test", nil, nil)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if attempts != tt.want {
+				t.Errorf("attempts = %d, want %d", attempts, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerCallRetriesOverrideConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{
+		{"configured count", context.Background(), 2},
+		{"no retries for the call", enforcement.WithRetries(context.Background(), 0), 1},
+		{"more retries for the call", enforcement.WithRetries(context.Background(), 2), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			cfg.RetryAttempts = 1
+
+			if err := NewBaseClient(cfg).Get(tt.ctx, cfg.ApiURL+"/v1/test", nil); err == nil {
+				t.Fatal("expected an error")
+			}
+			if attempts != tt.want {
+				t.Errorf("attempts = %d, want %d", attempts, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenRefreshOn401(t *testing.T) {
+	tests := []struct {
+		name      string
+		refresher func(ctx context.Context) (string, error)
+		validKey  string
+		wantErr   bool
+		attempts  int
+		refreshes int
+	}{
+		{"refreshed token is used", nil, "fresh", false, 2, 1},
+		{"failed refresh", func(ctx context.Context) (string, error) { return "", errors.New("vault down") }, "fresh", true, 1, 1},
+		{"still unauthorized", nil, "other", true, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts, refreshes int
+			cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if r.Header.Get("Authorization") != "Bearer "+tt.validKey {
+					w.WriteHeader(http.StatusUnauthorized)
+					_, _ = w.Write([]byte(`{"message":"token expired"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			cfg.Token = "stale"
+			cfg.TokenRefresher = func(ctx context.Context) (string, error) {
+				refreshes++
+				if tt.refresher != nil {
+					return tt.refresher(ctx)
+				}
+				return "fresh", nil
+			}
+
+			err := NewBaseClient(cfg).Get(context.Background(), cfg.ApiURL+"/v1/test", nil)
+			if tt.wantErr {
+				var apiErr *PermisError
+				if !errors.As(err, &apiErr) || !apiErr.IsUnauthorized() {
+					t.Errorf("Get() error = %v, want the 401 error", err)
+				}
+			} else if err != nil {
+				t.Errorf("Get() error: %v", err)
+			}
+			if attempts != tt.attempts || refreshes != tt.refreshes {
+				t.Errorf("attempts = %d, refreshes = %d, want %d and %d", attempts, refreshes, tt.attempts, tt.refreshes)
+			}
+		})
+	}
+}
+
+func TestStrictScopeFailsFast(t *testing.T) {
+	var requests int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	cfg.UpdateScope("", "")
+	cfg.StrictScope = true
+
+	_, err := NewUsersAPI(cfg).List(context.Background(), nil)
+	if !errors.Is(err, ErrMissingScope) {
+		t.Fatalf("expected ErrMissingScope, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no request to be made, got %d", requests)
+	}
+}
+
+func TestMissingScopeWarningInDebugMode(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	cfg := &config.Config{ApiURL: "https://api.test", Debug: true, Logger: zap.New(core)}
+
+	NewBaseClient(cfg).BuildFactsURL("/users")
+	if logs.FilterLevelExact(zapcore.WarnLevel).Len() != 1 {
+		t.Error("expected a warning when building an unscoped URL")
+	}
+}
+
+func TestPreviewURL(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	cfg := &config.Config{ApiURL: "https://api.test", ReadURL: "https://replica.test", Debug: true, Logger: zap.New(core)}
+	c := NewBaseClient(cfg)
+
+	if got, want := c.PreviewURL("facts", "/users"), "https://replica.test/v1/users"; got != want {
+		t.Errorf("PreviewURL() without scope = %q, want %q", got, want)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected no missing-scope warning when previewing, got %d logs", logs.Len())
+	}
+
+	cfg.UpdateScope("proj", "env")
+	if got, want := c.PreviewURL("schema", "/roles"), "https://replica.test/v1/schema/proj/env/roles"; got != want {
+		t.Errorf("PreviewURL(schema) = %q, want %q", got, want)
+	}
+	if got, want := c.PreviewURL("", "/v1/api-key/scope"), "https://replica.test/v1/api-key/scope"; got != want {
+		t.Errorf("PreviewURL(\"\") = %q, want %q", got, want)
+	}
+}
+
+func TestKeysArePathEscaped(t *testing.T) {
+	var gotPath string
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		_, _ = w.Write([]byte("{}"))
+	}))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{
+			name: "user with plus and at",
+			call: func() error { _, err := NewUsersAPI(cfg).Get(ctx, "john+tag@ex.com"); return err },
+			want: "/v1/facts/proj/env/users/john+tag@ex.com",
+		},
+		{
+			name: "role with space",
+			call: func() error { _, err := NewRolesAPI(cfg).Get(ctx, "content editor"); return err },
+			want: "/v1/schema/proj/env/roles/content%20editor",
+		},
+		{
+			name: "resource with slash",
+			call: func() error { _, err := NewResourcesAPI(cfg).Get(ctx, "docs/legal"); return err },
+			want: "/v1/schema/proj/env/resources/docs%2Flegal",
+		},
+		{
+			name: "instance with slash and space",
+			call: func() error {
+				_, err := NewResourcesAPI(cfg).GetInstance(ctx, "document", "reports/q1 2026.pdf")
+				return err
+			},
+			want: "/v1/facts/proj/env/resources/document/instances/reports%2Fq1%202026.pdf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != nil {
+				t.Fatalf("request error: %v", err)
+			}
+			if gotPath != tt.want {
+				t.Errorf("path = %q, want %q", gotPath, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomAuthHeader(t *testing.T) {
+	var apiKey, authorization string
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("X-API-Key")
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	cfg.AuthHeaderName = "X-API-Key"
+	cfg.AuthHeaderTemplate = "%s"
+
+	if _, err := NewUsersAPI(cfg).Get(context.Background(), "alice"); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if apiKey != "permis_key_test" {
+		t.Errorf("X-API-Key = %q, want the API key", apiKey)
+	}
+	if authorization != "" {
+		t.Errorf("Authorization = %q, want it unset", authorization)
+	}
+}
+
+func TestErrorsCarryOperation(t *testing.T) {
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"not found","code":"NOT_FOUND"}`, http.StatusNotFound)
+	}))
+
+	_, err := NewUsersAPI(cfg).Get(context.Background(), "alice")
+	apiErr, ok := err.(*PermisError)
+	if !ok {
+		t.Fatalf("Get() error = %v, want *PermisError", err)
+	}
+	if apiErr.Operation != "users.get" {
+		t.Errorf("Operation = %q, want %q", apiErr.Operation, "users.get")
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "NOT_FOUND" {
+		t.Errorf("status/code = %d/%q, want them preserved", apiErr.StatusCode, apiErr.Code)
+	}
+
+	err = NewRoleAssignmentsAPI(cfg).Unassign(context.Background(), "alice", "editor", "acme")
+	if apiErr, ok := err.(*PermisError); !ok || apiErr.Operation != "role_assignments.unassign" {
+		t.Errorf("Unassign() error = %v, want role_assignments.unassign operation", err)
+	}
+}
+
+func TestCreateConflictMatchesErrConflict(t *testing.T) {
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			http.Error(w, `{"message":"already exists","code":"CONFLICT"}`, http.StatusConflict)
+			return
+		}
+		http.Error(w, `{"message":"boom"}`, http.StatusInternalServerError)
+	}))
+	ctx := context.Background()
+
+	creates := map[string]func() error{
+		"users": func() error {
+			_, err := NewUsersAPI(cfg).Create(ctx, models.NewUserCreate("alice"))
+			return err
+		},
+		"roles": func() error {
+			_, err := NewRolesAPI(cfg).Create(ctx, models.NewRoleCreate("editor"))
+			return err
+		},
+		"tenants": func() error {
+			_, err := NewTenantsAPI(cfg).Create(ctx, models.NewTenantCreate("acme"))
+			return err
+		},
+		"resources": func() error {
+			_, err := NewResourcesAPI(cfg).Create(ctx, models.NewResourceCreate("document"))
+			return err
+		},
+		"instances": func() error {
+			_, err := NewResourcesAPI(cfg).CreateInstance(ctx, "document", models.NewResourceInstanceCreate("document", "doc-1"))
+			return err
+		},
+	}
+	for name, create := range creates {
+		err := create()
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("%s: Create() error = %v, want ErrConflict", name, err)
+		}
+		if apiErr, ok := err.(*PermisError); !ok || !apiErr.IsConflict() || apiErr.Code != "CONFLICT" {
+			t.Errorf("%s: Create() error = %v, want a 409 *PermisError", name, err)
+		}
+	}
+
+	_, err := NewUsersAPI(cfg).Get(ctx, "alice")
+	if errors.Is(err, ErrConflict) {
+		t.Errorf("Get() error = %v, want it not to match ErrConflict", err)
+	}
+}
+
+func TestReadURLRoutesGets(t *testing.T) {
+	var primary, replica []string
+	record := func(hits *[]string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*hits = append(*hits, r.Method)
+			_, _ = w.Write([]byte(`{"key":"alice"}`))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	primaryServer, replicaServer := record(&primary), record(&replica)
+
+	cfg := config.NewConfigBuilder("permis_key_test").
+		WithApiUrl(primaryServer.URL).
+		WithReadURL(replicaServer.URL).
+		WithProjectID("proj").
+		WithEnvironmentID("env").
+		WithRetryAttempts(0).
+		Build()
+	users := NewUsersAPI(cfg)
+	ctx := context.Background()
+
+	if _, err := users.Create(ctx, models.NewUserCreate("alice")); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if _, err := users.Get(ctx, "alice"); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+
+	if len(primary) != 1 || primary[0] != http.MethodPost {
+		t.Errorf("primary requests = %v, want [POST]", primary)
+	}
+	if len(replica) != 1 || replica[0] != http.MethodGet {
+		t.Errorf("replica requests = %v, want [GET]", replica)
+	}
+}
+
+// setBackoffTimer replaces the retry backoff timer, returning a function that
+// restores it.
+func setBackoffTimer(timer func(time.Duration) <-chan time.Time) (restore func()) {
+	previous := backoffTimer
+	backoffTimer = timer
+	return func() { backoffTimer = previous }
+}
+
+// recordingMetrics is a config.Metrics that records everything it receives.
+type recordingMetrics struct {
+	mu         sync.Mutex
+	counters   map[string]int
+	histograms map[string][]float64
+}
+
+func (m *recordingMetrics) IncCounter(name string, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name+"/"+labels["method"]]++
+}
+
+func (m *recordingMetrics) ObserveHistogram(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.histograms[name+"/"+labels["method"]] = append(m.histograms[name+"/"+labels["method"]], value)
+}
+
+func TestRetryMetrics(t *testing.T) {
+	var calls int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, `{"message":"unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	metrics := &recordingMetrics{counters: map[string]int{}, histograms: map[string][]float64{}}
+	cfg.RetryAttempts = 2
+	cfg.Metrics = metrics
+	defer setBackoffTimer(func(time.Duration) <-chan time.Time {
+		// Retry right away
+		fired := make(chan time.Time, 1)
+		fired <- time.Time{}
+		return fired
+	})()
+
+	if _, err := NewUsersAPI(cfg).Get(context.Background(), "alice"); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+
+	if got := metrics.counters[MetricRetryTotal+"/GET"]; got != 1 {
+		t.Errorf("%s = %d, want 1", MetricRetryTotal, got)
+	}
+	if got := metrics.histograms[MetricRequestAttempts+"/GET"]; len(got) != 1 || got[0] != 2 {
+		t.Errorf("%s = %v, want [2]", MetricRequestAttempts, got)
+	}
+	if got := metrics.histograms[MetricRetryBackoffSeconds+"/GET"]; len(got) != 1 || got[0] <= 0 {
+		t.Errorf("%s = %v, want one positive backoff", MetricRetryBackoffSeconds, got)
+	}
+}
+
+func TestCancelledRetryKeepsLastError(t *testing.T) {
+	// The context is cancelled during the first backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer setBackoffTimer(func(time.Duration) <-chan time.Time {
+		cancel()
+		return nil
+	})()
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"unavailable"}`, http.StatusServiceUnavailable)
+	}))
+	cfg.RetryAttempts = 2
+
+	_, err := NewUsersAPI(cfg).Get(ctx, "alice")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	var apiErr *PermisError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("error = %v, want the 503 that triggered the retry", err)
+	}
+}
+
+func TestContextHeadersApplyToSingleRequest(t *testing.T) {
+	var received []http.Header
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+	}))
+	cfg.CustomHeaders["X-Feature"] = "global"
+	cfg.CustomHeaders["X-Client"] = "sdk"
+	client := NewBaseClient(cfg)
+
+	ctx := enforcement.WithHeaders(context.Background(), map[string]string{"X-Feature": "beta", "X-Tenant-Hint": "acme"})
+	if err := client.Get(ctx, cfg.ApiURL+"/v1/test", nil); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if err := client.Get(context.Background(), cfg.ApiURL+"/v1/test", nil); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+
+	first, second := received[0], received[1]
+	if first.Get("X-Feature") != "beta" || first.Get("X-Tenant-Hint") != "acme" || first.Get("X-Client") != "sdk" {
+		t.Errorf("first request headers = %v", first)
+	}
+	if second.Get("X-Feature") != "global" || second.Get("X-Tenant-Hint") != "" {
+		t.Errorf("context headers leaked into the second request: %v", second)
+	}
+	if len(cfg.CustomHeaders) != 2 {
+		t.Errorf("config headers modified: %v", cfg.CustomHeaders)
+	}
+}
+
+func TestHTMLErrorPageGetsConciseMessage(t *testing.T) {
+	const page = "<html><head><title>502 Bad Gateway</title></head><body><h1>502 Bad Gateway</h1></body></html>"
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(page))
+	}))
+
+	err := NewBaseClient(cfg).Get(context.Background(), cfg.ApiURL+"/v1/test", nil)
+	var apiErr *PermisError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected a *PermisError, got %v", err)
+	}
+	if apiErr.Error() != "unexpected non-JSON response (status: 502)" {
+		t.Errorf("Error() = %q", apiErr.Error())
+	}
+	if apiErr.Details["body"] != page {
+		t.Errorf("Details[body] = %v, want the raw page", apiErr.Details["body"])
+	}
+}
@@ -1,6 +1,20 @@
 package api
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// ErrMissingScope is returned in strict scope mode when a request is made
+// before the project and environment are known (see Client.Init).
+var ErrMissingScope = errors.New("project and environment scope is not set: " +
+	"call Init or configure WithProjectID and WithEnvironmentID")
+
+// ErrConflict matches (via errors.Is) API errors for a create of something that
+// already exists. Use Sync to upsert instead.
+var ErrConflict = errors.New("resource already exists")
 
 // PermisError represents an error from the Permissio.io API.
 type PermisError struct {
@@ -15,14 +29,28 @@ type PermisError struct {
 
 	// Details contains additional error details.
 	Details map[string]interface{}
+
+	// Operation is the logical SDK operation that failed (e.g. "users.get",
+	// "role_assignments.assign"), for bucketing failures in handlers and metrics.
+	Operation string
 }
 
 // Error implements the error interface.
 func (e *PermisError) Error() string {
+	msg := e.Message
+	if e.Operation != "" {
+		msg = e.Operation + ": " + msg
+	}
 	if e.Code != "" {
-		return fmt.Sprintf("[%s] %s (status: %d)", e.Code, e.Message, e.StatusCode)
+		return fmt.Sprintf("[%s] %s (status: %d)", e.Code, msg, e.StatusCode)
 	}
-	return fmt.Sprintf("%s (status: %d)", e.Message, e.StatusCode)
+	return fmt.Sprintf("%s (status: %d)", msg, e.StatusCode)
+}
+
+// Is reports whether e matches target, so that errors.Is(err, ErrConflict)
+// holds for 409 responses.
+func (e *PermisError) Is(target error) bool {
+	return target == ErrConflict && e.IsConflict()
 }
 
 // IsNotFound returns true if this is a 404 error.
@@ -45,6 +73,11 @@ func (e *PermisError) IsBadRequest() bool {
 	return e.StatusCode == 400
 }
 
+// IsConflict returns true if this is a 409 error.
+func (e *PermisError) IsConflict() bool {
+	return e.StatusCode == 409
+}
+
 // IsServerError returns true if this is a 5xx error.
 func (e *PermisError) IsServerError() bool {
 	return e.StatusCode >= 500
@@ -67,3 +100,40 @@ func AccessDeniedError(message string) *PermisError {
 		StatusCode: 403,
 	}
 }
+
+// CheckError returns nil if the check response is allowed, and otherwise an
+// ACCESS_DENIED *PermisError with the response's 403 status and the reason for
+// the deny, to report denies the same way as API errors.
+func CheckError(response *models.CheckResponse) error {
+	if response.Allowed {
+		return nil
+	}
+	message := "Access denied"
+	if response.Reason != "" {
+		message += ": " + response.Reason
+	}
+	err := AccessDeniedError(message)
+	err.StatusCode = response.HTTPStatus()
+	return err
+}
+
+// isNotFound returns true if err is a 404 PermisError.
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*PermisError)
+	return ok && apiErr.IsNotFound()
+}
+
+// isUnauthorized returns true if err is a 401 PermisError.
+func isUnauthorized(err error) bool {
+	apiErr, ok := err.(*PermisError)
+	return ok && apiErr.IsUnauthorized()
+}
+
+// wrapErr annotates a PermisError with the operation that produced it.
+// Other errors, including nil, are returned unchanged.
+func wrapErr(op string, err error) error {
+	if apiErr, ok := err.(*PermisError); ok && apiErr.Operation == "" {
+		apiErr.Operation = op
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+func TestCheckError(t *testing.T) {
+	if err := CheckError(&models.CheckResponse{Allowed: true}); err != nil {
+		t.Errorf("allowed: error = %v, want nil", err)
+	}
+
+	denied := &models.CheckResponse{Reason: "No role grants permission document:delete"}
+	var apiErr *PermisError
+	if err := CheckError(denied); !errors.As(err, &apiErr) {
+		t.Fatalf("denied: expected a *PermisError, got %v", err)
+	}
+	if !apiErr.IsForbidden() || apiErr.StatusCode != http.StatusForbidden || apiErr.Code != "ACCESS_DENIED" {
+		t.Errorf("denied error = %+v", apiErr)
+	}
+	if want := "[ACCESS_DENIED] Access denied: No role grants permission document:delete (status: 403)"; apiErr.Error() != want {
+		t.Errorf("Error() = %q, want %q", apiErr.Error(), want)
+	}
+}
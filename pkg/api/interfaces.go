@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"io"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// UsersClient is the user management API. It is implemented by UsersAPI and
+// can be replaced with a mock, such as permittest.UsersClient, in tests.
+type UsersClient interface {
+	List(ctx context.Context, params *models.UserListParams) (*models.UserList, error)
+	ListAll(ctx context.Context, params *models.UserListParams) ([]models.UserRead, error)
+	Count(ctx context.Context, params *models.UserListParams) (int, error)
+	Get(ctx context.Context, userKey string) (*models.UserRead, error)
+	Create(ctx context.Context, user *models.UserCreate) (*models.UserRead, error)
+	Update(ctx context.Context, userKey string, data *models.UserUpdate) (*models.UserRead, error)
+	CreateRaw(ctx context.Context, body interface{}) (*models.UserRead, error)
+	UpdateRaw(ctx context.Context, userKey string, body interface{}) (*models.UserRead, error)
+	Delete(ctx context.Context, userKey string) error
+	BulkDelete(ctx context.Context, keys []string) (*models.BulkUserResponse, error)
+	SyncUser(ctx context.Context, user models.UserCreate) (*models.UserRead, error)
+	Upsert(ctx context.Context, user models.UserCreate) (*models.UserRead, bool, error)
+	AssignRole(ctx context.Context, userKey, role, tenant string) (*models.RoleAssignmentRead, error)
+	UnassignRole(ctx context.Context, userKey, role, tenant string) error
+	GetRoles(ctx context.Context, userKey string, tenant string) ([]string, error)
+	AddTenant(ctx context.Context, userKey, tenantKey string) error
+	AddTenantIfAbsent(ctx context.Context, userKey, tenantKey string) (bool, error)
+	RemoveTenant(ctx context.Context, userKey, tenantKey string) error
+	GetTenants(ctx context.Context, userKey string) ([]string, error)
+}
+
+// TenantsClient is the tenant management API. It is implemented by TenantsAPI
+// and can be replaced with a mock, such as permittest.TenantsClient, in tests.
+type TenantsClient interface {
+	List(ctx context.Context, params *models.TenantListParams) (*models.TenantList, error)
+	ListAll(ctx context.Context, params *models.TenantListParams) ([]models.TenantRead, error)
+	Get(ctx context.Context, tenantKey string) (*models.TenantRead, error)
+	Create(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, error)
+	Update(ctx context.Context, tenantKey string, data *models.TenantUpdate) (*models.TenantRead, error)
+	CreateRaw(ctx context.Context, body interface{}) (*models.TenantRead, error)
+	UpdateRaw(ctx context.Context, tenantKey string, body interface{}) (*models.TenantRead, error)
+	Delete(ctx context.Context, tenantKey string) error
+	Sync(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, error)
+	Upsert(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, bool, error)
+	AddUser(ctx context.Context, tenantKey, userKey string) error
+	AddUserIfAbsent(ctx context.Context, tenantKey, userKey string) (bool, error)
+	RemoveUser(ctx context.Context, tenantKey, userKey string) error
+	GetUsers(ctx context.Context, tenantKey string) ([]string, error)
+}
+
+// RolesClient is the role management API. It is implemented by RolesAPI and
+// can be replaced with a mock, such as permittest.RolesClient, in tests.
+type RolesClient interface {
+	List(ctx context.Context, params *models.RoleListParams) (*models.RoleList, error)
+	ListAll(ctx context.Context, params *models.RoleListParams) ([]models.RoleRead, error)
+	Count(ctx context.Context, params *models.RoleListParams) (int, error)
+	Get(ctx context.Context, roleKey string) (*models.RoleRead, error)
+	Create(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, error)
+	Update(ctx context.Context, roleKey string, data *models.RoleUpdate) (*models.RoleRead, error)
+	CreateRaw(ctx context.Context, body interface{}) (*models.RoleRead, error)
+	UpdateRaw(ctx context.Context, roleKey string, body interface{}) (*models.RoleRead, error)
+	Delete(ctx context.Context, roleKey string) error
+	Sync(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, error)
+	Upsert(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, bool, error)
+	GetPermissions(ctx context.Context, roleKey string) ([]string, error)
+	AddPermission(ctx context.Context, roleKey, permission string) error
+	RemovePermission(ctx context.Context, roleKey, permission string) error
+	GetExtends(ctx context.Context, roleKey string) ([]string, error)
+	GetExtendedBy(ctx context.Context, roleKey string, options *GetExtendedByOptions) ([]string, error)
+	GetExtendedByChains(ctx context.Context, roleKey string) (map[string][]string, error)
+	AddExtends(ctx context.Context, roleKey, parentRoleKey string) error
+	RemoveExtends(ctx context.Context, roleKey, parentRoleKey string) error
+	PermissionSource(ctx context.Context, roleKey, permission string) (*models.PermissionSource, error)
+	EffectivePermissionCount(ctx context.Context) (map[string]int, error)
+	Hierarchy(ctx context.Context) (*models.RoleGraph, error)
+	WildcardGrants(ctx context.Context) (map[string]models.RoleWildcards, error)
+}
+
+// ResourcesClient is the resource type and instance management API. It is
+// implemented by ResourcesAPI and can be replaced with a mock, such as
+// permittest.ResourcesClient, in tests.
+type ResourcesClient interface {
+	List(ctx context.Context, params *models.ResourceListParams) (*models.ResourceList, error)
+	ListAll(ctx context.Context, params *models.ResourceListParams) ([]models.ResourceRead, error)
+	Get(ctx context.Context, resourceKey string) (*models.ResourceRead, error)
+	Create(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, error)
+	Update(ctx context.Context, resourceKey string, data *models.ResourceUpdate) (*models.ResourceRead, error)
+	CreateRaw(ctx context.Context, body interface{}) (*models.ResourceRead, error)
+	UpdateRaw(ctx context.Context, resourceKey string, body interface{}) (*models.ResourceRead, error)
+	Delete(ctx context.Context, resourceKey string) error
+	Sync(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, error)
+	Upsert(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, bool, error)
+	GetActions(ctx context.Context, resourceKey string) ([]string, error)
+	AddAction(ctx context.Context, resourceKey, action string) error
+	GetActionsDetailed(ctx context.Context, resourceKey string) ([]models.ResourceAction, error)
+	AddActionDetailed(ctx context.Context, resourceKey string, action models.ResourceAction) error
+	RemoveAction(ctx context.Context, resourceKey, action string) error
+	CreateInstance(ctx context.Context, resourceKey string, instance *models.ResourceInstanceCreate) (*models.ResourceInstanceRead, error)
+	CreateInstanceWithOptions(ctx context.Context, resourceKey string, instance *models.ResourceInstanceCreate, options *CreateInstanceOptions) (*models.ResourceInstanceRead, error)
+	GetInstance(ctx context.Context, resourceKey, instanceKey string) (*models.ResourceInstanceRead, error)
+	InstanceExists(ctx context.Context, resourceKey, instanceKey string) (bool, error)
+	DeleteInstance(ctx context.Context, resourceKey, instanceKey string) error
+}
+
+// RoleAssignmentsClient is the role assignment API. It is implemented by
+// RoleAssignmentsAPI and can be replaced with a mock, such as
+// permittest.RoleAssignmentsClient, in tests.
+type RoleAssignmentsClient interface {
+	List(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	ListWithPagination(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, models.PaginatedResponse, error)
+	ListAll(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	Count(ctx context.Context, params *models.RoleAssignmentListParams) (int, error)
+	ListByUser(ctx context.Context, userKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	ListByTenant(ctx context.Context, tenantKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	ListByTenantDetailed(ctx context.Context, tenantKey string, params *models.RoleAssignmentListParams) ([]models.RoleAssignmentWithRole, error)
+	ListByResource(ctx context.Context, resourceType, instanceKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	Assign(ctx context.Context, assignment *models.RoleAssignmentCreate) (*models.RoleAssignmentRead, error)
+	Unassign(ctx context.Context, user, role, tenant string) error
+	UnassignWithResource(ctx context.Context, user, role, tenant, resource, resourceInstance string) error
+	PurgeExpired(ctx context.Context, params *models.RoleAssignmentListParams) (int, error)
+	BulkAssign(ctx context.Context, assignments []models.RoleAssignmentCreate) (*models.BulkRoleAssignmentResponse, error)
+	BulkUnassign(ctx context.Context, assignments []models.RoleAssignmentCreate) (*models.BulkRoleAssignmentResponse, error)
+	BulkAssignStream(ctx context.Context, r io.Reader, options *BulkAssignStreamOptions) (*models.BulkRoleAssignmentResponse, error)
+	HasRole(ctx context.Context, userKey, roleKey string, options *HasRoleOptions) (bool, error)
+	GetUserRoles(ctx context.Context, userKey string, options *GetUserRolesOptions) ([]string, error)
+	GetRoleUsers(ctx context.Context, roleKey string, options *GetRoleUsersOptions) ([]string, error)
+	ListDetailed(ctx context.Context, params *models.RoleAssignmentListParams) (*models.RoleAssignmentList, error)
+	GetByID(ctx context.Context, id string) (*models.RoleAssignmentRead, error)
+}
+
+var (
+	_ UsersClient           = (*UsersAPI)(nil)
+	_ TenantsClient         = (*TenantsAPI)(nil)
+	_ RolesClient           = (*RolesAPI)(nil)
+	_ ResourcesClient       = (*ResourcesAPI)(nil)
+	_ RoleAssignmentsClient = (*RoleAssignmentsAPI)(nil)
+)
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// DefaultPageSize is the page size used when auto-paginating without an explicit PerPage.
+const DefaultPageSize = 100
+
+// PageFetcher fetches a single page of items along with its pagination metadata.
+type PageFetcher[T any] func(ctx context.Context, page, perPage int) ([]T, models.PaginatedResponse, error)
+
+// Paginator iterates items across all pages returned by a PageFetcher.
+type Paginator[T any] struct {
+	fetch   PageFetcher[T]
+	perPage int
+}
+
+// NewPaginator creates a Paginator. A perPage of zero or less uses DefaultPageSize.
+func NewPaginator[T any](fetch PageFetcher[T], perPage int) *Paginator[T] {
+	if perPage <= 0 {
+		perPage = DefaultPageSize
+	}
+	return &Paginator[T]{fetch: fetch, perPage: perPage}
+}
+
+// ForEach calls fn for every item across all pages, in order.
+// It stops at the first error returned by the fetcher or fn, and when ctx is
+// cancelled between pages.
+func (p *Paginator[T]) ForEach(ctx context.Context, fn func(T) error) error {
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		items, meta, err := p.fetch(ctx, page, p.perPage)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if isLastPage(page, p.perPage, len(items), meta) {
+			return nil
+		}
+	}
+}
+
+// All returns every item across all pages.
+func (p *Paginator[T]) All(ctx context.Context) ([]T, error) {
+	var all []T
+	err := p.ForEach(ctx, func(item T) error {
+		all = append(all, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// isLastPage reports whether page is the final page. TotalPages is used when
+// the server reports it; otherwise a short page marks the end.
+func isLastPage(page, perPage, count int, meta models.PaginatedResponse) bool {
+	if count == 0 {
+		return true
+	}
+	if meta.TotalPages > 0 {
+		return page >= meta.TotalPages
+	}
+	return count < perPage
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// fakeFetcher serves items in pages of perPage.
+func fakeFetcher(items []int, reportTotal bool, calls *int) PageFetcher[int] {
+	return func(ctx context.Context, page, perPage int) ([]int, models.PaginatedResponse, error) {
+		*calls++
+		start := (page - 1) * perPage
+		if start > len(items) {
+			start = len(items)
+		}
+		end := start + perPage
+		if end > len(items) {
+			end = len(items)
+		}
+		meta := models.PaginatedResponse{Page: page, PerPage: perPage}
+		if reportTotal {
+			meta.Total = len(items)
+			meta.TotalPages = (len(items) + perPage - 1) / perPage
+		}
+		return items[start:end], meta, nil
+	}
+}
+
+func TestPaginatorAll(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7}
+
+	for _, reportTotal := range []bool{true, false} {
+		var calls int
+		all, err := NewPaginator(fakeFetcher(items, reportTotal, &calls), 3).All(context.Background())
+		if err != nil {
+			t.Fatalf("All() error: %v", err)
+		}
+		if len(all) != len(items) {
+			t.Errorf("reportTotal=%v: got %d items, want %d", reportTotal, len(all), len(items))
+		}
+		if calls != 3 {
+			t.Errorf("reportTotal=%v: got %d fetches, want 3", reportTotal, calls)
+		}
+	}
+}
+
+func TestPaginatorPropagatesErrors(t *testing.T) {
+	fetchErr := errors.New("boom")
+	p := NewPaginator(func(ctx context.Context, page, perPage int) ([]int, models.PaginatedResponse, error) {
+		if page == 2 {
+			return nil, models.PaginatedResponse{}, fetchErr
+		}
+		return []int{1, 2}, models.PaginatedResponse{TotalPages: 3}, nil
+	}, 2)
+
+	if _, err := p.All(context.Background()); !errors.Is(err, fetchErr) {
+		t.Errorf("expected fetch error, got %v", err)
+	}
+
+	stopErr := errors.New("stop")
+	var seen int
+	err := p.ForEach(context.Background(), func(int) error {
+		seen++
+		return stopErr
+	})
+	if !errors.Is(err, stopErr) || seen != 1 {
+		t.Errorf("expected ForEach to stop at the first callback error, got %v after %d items", err, seen)
+	}
+}
+
+func TestPaginatorHonorsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	p := NewPaginator(func(ctx context.Context, page, perPage int) ([]int, models.PaginatedResponse, error) {
+		calls++
+		cancel()
+		return []int{1}, models.PaginatedResponse{TotalPages: 10}, nil
+	}, 1)
+
+	if _, err := p.All(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected fetching to stop after cancellation, got %d calls", calls)
+	}
+}
+
+func TestListMethodsAcceptNilParams(t *testing.T) {
+	lists := map[string]func(ctx context.Context, cfg *config.Config) error{
+		"users": func(ctx context.Context, cfg *config.Config) error {
+			_, err := NewUsersAPI(cfg).List(ctx, nil)
+			return err
+		},
+		"tenants": func(ctx context.Context, cfg *config.Config) error {
+			_, err := NewTenantsAPI(cfg).List(ctx, nil)
+			return err
+		},
+		"roles": func(ctx context.Context, cfg *config.Config) error {
+			_, err := NewRolesAPI(cfg).List(ctx, nil)
+			return err
+		},
+		"resources": func(ctx context.Context, cfg *config.Config) error {
+			_, err := NewResourcesAPI(cfg).List(ctx, nil)
+			return err
+		},
+		"role_assignments": func(ctx context.Context, cfg *config.Config) error {
+			_, err := NewRoleAssignmentsAPI(cfg).List(ctx, nil)
+			return err
+		},
+		"role_assignments/detailed": func(ctx context.Context, cfg *config.Config) error {
+			_, err := NewRoleAssignmentsAPI(cfg).ListDetailed(ctx, nil)
+			return err
+		},
+	}
+
+	for name, list := range lists {
+		for _, pageSize := range []int{0, 25} {
+			t.Run(fmt.Sprintf("%s/pageSize=%d", name, pageSize), func(t *testing.T) {
+				var query string
+				cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					query = r.URL.RawQuery
+					if strings.HasPrefix(name, "role_assignments") {
+						_, _ = w.Write([]byte(`[]`))
+						return
+					}
+					_, _ = w.Write([]byte(`{"data":[]}`))
+				}))
+				cfg.DefaultPageSize = pageSize
+
+				if err := list(context.Background(), cfg); err != nil {
+					t.Fatalf("List(nil) error: %v", err)
+				}
+				want := ""
+				if pageSize > 0 {
+					want = fmt.Sprintf("perPage=%d", pageSize)
+				}
+				if query != want {
+					t.Errorf("query = %q, want %q", query, want)
+				}
+			})
+		}
+	}
+}
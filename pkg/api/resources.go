@@ -2,7 +2,10 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"sort"
 
 	"github.com/permissio/permissio-go/pkg/config"
 	"github.com/permissio/permissio-go/pkg/models"
@@ -20,124 +23,279 @@ func NewResourcesAPI(cfg *config.Config) *ResourcesAPI {
 	}
 }
 
-// List returns a paginated list of resources.
+// List returns a paginated list of resources. params may be nil.
 func (a *ResourcesAPI) List(ctx context.Context, params *models.ResourceListParams) (*models.ResourceList, error) {
-	url := a.BuildSchemaURL("/resources")
-
-	if params != nil {
-		queryParams := ListParamsToMap(params.Page, params.PerPage, map[string]string{
-			"search": params.Search,
-		})
-		url = BuildQueryParams(url, queryParams)
+	if params == nil {
+		params = &models.ResourceListParams{}
 	}
+	endpoint := a.listURL(a.BuildSchemaURL("/resources"), params.Page, params.PerPage, map[string]string{
+		"search": params.Search,
+	})
 
 	var result models.ResourceList
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("resources.list", err)
 	}
 	return &result, nil
 }
 
+// ListAll returns every resource matching params, following all pages.
+// params may be nil; its Page is ignored.
+func (a *ResourcesAPI) ListAll(ctx context.Context, params *models.ResourceListParams) ([]models.ResourceRead, error) {
+	query := models.ResourceListParams{}
+	if params != nil {
+		query = *params
+	}
+	return NewPaginator(func(ctx context.Context, page, perPage int) ([]models.ResourceRead, models.PaginatedResponse, error) {
+		query.Page, query.PerPage = page, perPage
+		result, err := a.List(ctx, &query)
+		if err != nil {
+			return nil, models.PaginatedResponse{}, err
+		}
+		return result.Data, result.PaginatedResponse, nil
+	}, a.pageSize(query.PerPage)).All(ctx)
+}
+
 // Get retrieves a resource by key.
 func (a *ResourcesAPI) Get(ctx context.Context, resourceKey string) (*models.ResourceRead, error) {
-	url := a.BuildSchemaURL(fmt.Sprintf("/resources/%s", resourceKey))
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s", url.PathEscape(resourceKey)))
 
 	var result models.ResourceRead
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("resources.get", err)
 	}
 	return &result, nil
 }
 
 // Create creates a new resource.
+// The error matches ErrConflict if the resource already exists.
 func (a *ResourcesAPI) Create(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, error) {
-	url := a.BuildSchemaURL("/resources")
+	endpoint := a.BuildSchemaURL("/resources")
 
 	var result models.ResourceRead
-	if err := a.Post(ctx, url, resource, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, resource, &result); err != nil {
+		return nil, wrapErr("resources.create", err)
 	}
 	return &result, nil
 }
 
 // Update updates an existing resource.
 func (a *ResourcesAPI) Update(ctx context.Context, resourceKey string, data *models.ResourceUpdate) (*models.ResourceRead, error) {
-	url := a.BuildSchemaURL(fmt.Sprintf("/resources/%s", resourceKey))
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s", url.PathEscape(resourceKey)))
+
+	var result models.ResourceRead
+	if err := a.Patch(ctx, endpoint, data, &result); err != nil {
+		return nil, wrapErr("resources.update", err)
+	}
+	return &result, nil
+}
+
+// CreateRaw creates a resource type from an arbitrary body (json.RawMessage,
+// a map, ...) sent as is, for schema fields the SDK doesn't model yet.
+func (a *ResourcesAPI) CreateRaw(ctx context.Context, body interface{}) (*models.ResourceRead, error) {
+	endpoint := a.BuildSchemaURL("/resources")
+
+	var result models.ResourceRead
+	if err := a.Post(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("resources.create_raw", err)
+	}
+	return &result, nil
+}
+
+// UpdateRaw is like Update with a body the SDK doesn't model; see CreateRaw.
+func (a *ResourcesAPI) UpdateRaw(ctx context.Context, resourceKey string, body interface{}) (*models.ResourceRead, error) {
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s", url.PathEscape(resourceKey)))
 
 	var result models.ResourceRead
-	if err := a.Patch(ctx, url, data, &result); err != nil {
-		return nil, err
+	if err := a.Patch(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("resources.update_raw", err)
 	}
 	return &result, nil
 }
 
 // Delete deletes a resource.
 func (a *ResourcesAPI) Delete(ctx context.Context, resourceKey string) error {
-	url := a.BuildSchemaURL(fmt.Sprintf("/resources/%s", resourceKey))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s", url.PathEscape(resourceKey)))
+	return wrapErr("resources.delete", a.BaseClient.Delete(ctx, endpoint, nil))
 }
 
 // Sync creates or updates a resource (upsert).
 func (a *ResourcesAPI) Sync(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, error) {
-	url := a.BuildSchemaURL("/resources")
+	result, _, err := a.Upsert(ctx, resource)
+	return result, err
+}
+
+// Upsert is like Sync but also reports whether the resource was created
+// rather than updated.
+func (a *ResourcesAPI) Upsert(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, bool, error) {
+	endpoint := a.BuildSchemaURL("/resources")
 
 	var result models.ResourceRead
-	if err := a.Put(ctx, url, resource, &result); err != nil {
-		return nil, err
+	created, err := a.upsert(ctx, endpoint, resource, &result)
+	if err != nil {
+		return nil, false, wrapErr("resources.sync", err)
 	}
-	return &result, nil
+	return &result, created, nil
 }
 
 // GetActions returns the actions for a resource.
 func (a *ResourcesAPI) GetActions(ctx context.Context, resourceKey string) ([]string, error) {
-	url := a.BuildSchemaURL(fmt.Sprintf("/resources/%s/actions", resourceKey))
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s/actions", url.PathEscape(resourceKey)))
 
 	var result struct {
 		Actions []string `json:"actions"`
 	}
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("resources.get_actions", err)
 	}
 	return result.Actions, nil
 }
 
 // AddAction adds an action to a resource.
 func (a *ResourcesAPI) AddAction(ctx context.Context, resourceKey, action string) error {
-	url := a.BuildSchemaURL(fmt.Sprintf("/resources/%s/actions", resourceKey))
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s/actions", url.PathEscape(resourceKey)))
 	body := map[string]string{"action": action}
-	return a.Post(ctx, url, body, nil)
+	return wrapErr("resources.add_action", a.Post(ctx, endpoint, body, nil))
+}
+
+// GetActionsDetailed returns the actions for a resource with their display
+// names and descriptions, sorted by key.
+func (a *ResourcesAPI) GetActionsDetailed(ctx context.Context, resourceKey string) ([]models.ResourceAction, error) {
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s", url.PathEscape(resourceKey)))
+
+	var result struct {
+		Actions json.RawMessage `json:"actions"`
+	}
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("resources.get_actions_detailed", err)
+	}
+	return parseResourceActions(result.Actions)
+}
+
+// AddActionDetailed adds an action with a display name and description to a resource.
+func (a *ResourcesAPI) AddActionDetailed(ctx context.Context, resourceKey string, action models.ResourceAction) error {
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s/actions", url.PathEscape(resourceKey)))
+	body := map[string]string{
+		"action":      action.Key,
+		"name":        action.Name,
+		"description": action.Description,
+	}
+	return wrapErr("resources.add_action_detailed", a.Post(ctx, endpoint, body, nil))
+}
+
+// parseResourceActions decodes a resource's actions, which the backend returns
+// either as a map of key to {name, description} or as a plain list of keys.
+func parseResourceActions(raw json.RawMessage) ([]models.ResourceAction, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var byKey map[string]struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(raw, &byKey); err == nil {
+		actions := make([]models.ResourceAction, 0, len(byKey))
+		for key, details := range byKey {
+			actions = append(actions, models.ResourceAction{Key: key, Name: details.Name, Description: details.Description})
+		}
+		sort.Slice(actions, func(i, j int) bool { return actions[i].Key < actions[j].Key })
+		return actions, nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode resource actions: %w", err)
+	}
+	actions := make([]models.ResourceAction, 0, len(keys))
+	for _, key := range keys {
+		actions = append(actions, models.ResourceAction{Key: key})
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Key < actions[j].Key })
+	return actions, nil
 }
 
 // RemoveAction removes an action from a resource.
 func (a *ResourcesAPI) RemoveAction(ctx context.Context, resourceKey, action string) error {
-	url := a.BuildSchemaURL(fmt.Sprintf("/resources/%s/actions/%s", resourceKey, action))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/resources/%s/actions/%s", url.PathEscape(resourceKey), url.PathEscape(action)))
+	return wrapErr("resources.remove_action", a.BaseClient.Delete(ctx, endpoint, nil))
 }
 
 // CreateInstance creates a resource instance.
+// When the instance tenant is omitted, the configured default tenant is used,
+// the same one instance-scoped checks fall back to. Without a configured
+// default, the server assigns its own default tenant, which is returned in
+// the Tenant field of the result.
+// The error matches ErrConflict if the instance already exists.
 func (a *ResourcesAPI) CreateInstance(ctx context.Context, resourceKey string, instance *models.ResourceInstanceCreate) (*models.ResourceInstanceRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/resources/%s/instances", resourceKey))
+	return a.CreateInstanceWithOptions(ctx, resourceKey, instance, nil)
+}
+
+// CreateInstanceOptions contains optional parameters for CreateInstanceWithOptions.
+type CreateInstanceOptions struct {
+	// ResolveTenant fetches the created instance when the create response
+	// omits the tenant, so the server-assigned tenant is always populated.
+	// If that fetch fails, the created instance is returned, without its
+	// tenant, along with the error.
+	ResolveTenant bool
+}
+
+// CreateInstanceWithOptions creates a resource instance with the given options.
+func (a *ResourcesAPI) CreateInstanceWithOptions(ctx context.Context, resourceKey string, instance *models.ResourceInstanceCreate, options *CreateInstanceOptions) (*models.ResourceInstanceRead, error) {
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/resources/%s/instances", url.PathEscape(resourceKey)))
+
+	if instance.Tenant == "" && a.config.DefaultTenant != "" {
+		withTenant := *instance
+		withTenant.Tenant = a.config.DefaultTenant
+		instance = &withTenant
+	}
 
 	var result models.ResourceInstanceRead
-	if err := a.Post(ctx, url, instance, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, instance, &result); err != nil {
+		return nil, wrapErr("resources.create_instance", err)
 	}
+
+	if options != nil && options.ResolveTenant && result.Tenant == "" {
+		key := result.Key
+		if key == "" {
+			key = instance.Key
+		}
+		fetched, err := a.GetInstance(ctx, resourceKey, key)
+		if err != nil {
+			// The instance exists now: return it so callers don't retry the create
+			return &result, fmt.Errorf("instance %s was created but its tenant could not be fetched: %w", key, err)
+		}
+		result.Tenant = fetched.Tenant
+	}
+
 	return &result, nil
 }
 
 // GetInstance retrieves a resource instance.
 func (a *ResourcesAPI) GetInstance(ctx context.Context, resourceKey, instanceKey string) (*models.ResourceInstanceRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/resources/%s/instances/%s", resourceKey, instanceKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/resources/%s/instances/%s", url.PathEscape(resourceKey), url.PathEscape(instanceKey)))
 
 	var result models.ResourceInstanceRead
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("resources.get_instance", err)
 	}
 	return &result, nil
 }
 
+// InstanceExists reports whether a resource instance exists.
+// A 404 yields false with no error; other errors are returned as-is.
+func (a *ResourcesAPI) InstanceExists(ctx context.Context, resourceKey, instanceKey string) (bool, error) {
+	if _, err := a.GetInstance(ctx, resourceKey, instanceKey); err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 // DeleteInstance deletes a resource instance.
 func (a *ResourcesAPI) DeleteInstance(ctx context.Context, resourceKey, instanceKey string) error {
-	url := a.BuildFactsURL(fmt.Sprintf("/resources/%s/instances/%s", resourceKey, instanceKey))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/resources/%s/instances/%s", url.PathEscape(resourceKey), url.PathEscape(instanceKey)))
+	return wrapErr("resources.delete_instance", a.BaseClient.Delete(ctx, endpoint, nil))
 }
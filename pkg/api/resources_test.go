@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// newTestConfig returns a scoped config pointed at an httptest server running handler.
+func newTestConfig(t *testing.T, handler http.Handler) *config.Config {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return config.NewConfigBuilder("permis_key_test").
+		WithApiUrl(server.URL).
+		WithProjectID("proj").
+		WithEnvironmentID("env").
+		WithRetryAttempts(0).
+		Build()
+}
+
+func TestCreateInstanceReturnsTenant(t *testing.T) {
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(models.ResourceInstanceRead{Key: "doc-1", ResourceType: "document", Tenant: "default"})
+	}))
+
+	instance, err := NewResourcesAPI(cfg).CreateInstance(context.Background(), "document",
+		&models.ResourceInstanceCreate{Key: "doc-1", ResourceType: "document"})
+	if err != nil {
+		t.Fatalf("CreateInstance() error: %v", err)
+	}
+	if instance.Tenant != "default" {
+		t.Errorf("Tenant = %q, want %q", instance.Tenant, "default")
+	}
+}
+
+func TestCreateInstanceResolvesOmittedTenant(t *testing.T) {
+	var gets int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			_ = json.NewEncoder(w).Encode(models.ResourceInstanceRead{Key: "doc-1", ResourceType: "document"})
+		case http.MethodGet:
+			gets++
+			if r.URL.Path != "/v1/facts/proj/env/resources/document/instances/doc-1" {
+				t.Errorf("unexpected path %q", r.URL.Path)
+			}
+			_ = json.NewEncoder(w).Encode(models.ResourceInstanceRead{Key: "doc-1", ResourceType: "document", Tenant: "default"})
+		}
+	}))
+
+	instance, err := NewResourcesAPI(cfg).CreateInstanceWithOptions(context.Background(), "document",
+		&models.ResourceInstanceCreate{Key: "doc-1", ResourceType: "document"},
+		&CreateInstanceOptions{ResolveTenant: true})
+	if err != nil {
+		t.Fatalf("CreateInstanceWithOptions() error: %v", err)
+	}
+	if instance.Tenant != "default" {
+		t.Errorf("Tenant = %q, want %q", instance.Tenant, "default")
+	}
+	if gets != 1 {
+		t.Errorf("expected 1 follow-up GET, got %d", gets)
+	}
+}
+
+func TestCreateInstanceReturnsCreatedInstanceWhenTenantFetchFails(t *testing.T) {
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_ = json.NewEncoder(w).Encode(models.ResourceInstanceRead{Key: "doc-1", ResourceType: "document"})
+			return
+		}
+		http.Error(w, `{"message":"boom"}`, http.StatusInternalServerError)
+	}))
+
+	instance, err := NewResourcesAPI(cfg).CreateInstanceWithOptions(context.Background(), "document",
+		&models.ResourceInstanceCreate{Key: "doc-1", ResourceType: "document"},
+		&CreateInstanceOptions{ResolveTenant: true})
+	var apiErr *PermisError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("CreateInstanceWithOptions() error = %v, want the wrapped GET error", err)
+	}
+	if instance == nil || instance.Key != "doc-1" {
+		t.Errorf("instance = %+v, want the created instance", instance)
+	}
+}
+
+func TestInstanceExists(t *testing.T) {
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/facts/proj/env/resources/document/instances/doc-1":
+			_ = json.NewEncoder(w).Encode(models.ResourceInstanceRead{Key: "doc-1", ResourceType: "document"})
+		case "/v1/facts/proj/env/resources/document/instances/broken":
+			http.Error(w, `{"message":"boom"}`, http.StatusInternalServerError)
+		default:
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+		}
+	}))
+	resources := NewResourcesAPI(cfg)
+	ctx := context.Background()
+
+	if exists, err := resources.InstanceExists(ctx, "document", "doc-1"); err != nil || !exists {
+		t.Errorf("existing instance: exists=%v err=%v", exists, err)
+	}
+	if exists, err := resources.InstanceExists(ctx, "document", "missing"); err != nil || exists {
+		t.Errorf("missing instance: exists=%v err=%v", exists, err)
+	}
+	if _, err := resources.InstanceExists(ctx, "document", "broken"); err == nil {
+		t.Error("expected server error to be returned")
+	}
+}
+
+func TestGetActionsDetailed(t *testing.T) {
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"key":"document","actions":{
+			"write":{"name":"Write","description":"Edit the document"},
+			"read":{"name":"Read"}}}`))
+	}))
+
+	actions, err := NewResourcesAPI(cfg).GetActionsDetailed(context.Background(), "document")
+	if err != nil {
+		t.Fatalf("GetActionsDetailed() error: %v", err)
+	}
+
+	want := []models.ResourceAction{
+		{Key: "read", Name: "Read"},
+		{Key: "write", Name: "Write", Description: "Edit the document"},
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("got %d actions, want %d", len(actions), len(want))
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("actions[%d] = %+v, want %+v", i, actions[i], want[i])
+		}
+	}
+}
+
+func TestAddActionDetailedSendsName(t *testing.T) {
+	var body map[string]string
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte("{}"))
+	}))
+
+	err := NewResourcesAPI(cfg).AddActionDetailed(context.Background(), "document",
+		models.ResourceAction{Key: "archive", Name: "Archive", Description: "Move to archive"})
+	if err != nil {
+		t.Fatalf("AddActionDetailed() error: %v", err)
+	}
+	if body["action"] != "archive" || body["name"] != "Archive" || body["description"] != "Move to archive" {
+		t.Errorf("request body = %v", body)
+	}
+}
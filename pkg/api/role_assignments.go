@@ -2,7 +2,14 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/permissio/permissio-go/pkg/config"
 	"github.com/permissio/permissio-go/pkg/models"
@@ -20,26 +27,106 @@ func NewRoleAssignmentsAPI(cfg *config.Config) *RoleAssignmentsAPI {
 	}
 }
 
-// List returns a list of role assignments.
+// Pagination headers read by ListWithPagination. The role assignments
+// endpoint returns a bare array, so totals can only be reported in headers.
+const (
+	// TotalCountHeader carries the total number of matching items.
+	TotalCountHeader = "X-Total-Count"
+
+	// TotalPagesHeader carries the total number of pages.
+	TotalPagesHeader = "X-Total-Pages"
+)
+
+// List returns a list of role assignments. params may be nil.
 func (a *RoleAssignmentsAPI) List(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error) {
-	url := a.BuildFactsURL("/role_assignments")
+	result, _, err := a.ListWithPagination(ctx, params)
+	return result, err
+}
 
-	if params != nil {
-		queryParams := ListParamsToMap(params.Page, params.PerPage, map[string]string{
-			"user":              params.User,
-			"role":              params.Role,
-			"tenant":            params.Tenant,
-			"resource":          params.Resource,
-			"resource_instance": params.ResourceInstance,
-		})
-		url = BuildQueryParams(url, queryParams)
+// ListWithPagination returns a page of role assignments with the pagination
+// metadata reported in the X-Total-Count and X-Total-Pages response headers.
+// Total and TotalPages are zero when the server doesn't send them; TotalPages
+// is derived from X-Total-Count and the page size when only the count is sent.
+func (a *RoleAssignmentsAPI) ListWithPagination(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, models.PaginatedResponse, error) {
+	if params == nil {
+		params = &models.RoleAssignmentListParams{}
 	}
+	endpoint := a.listURL(a.BuildFactsURL("/role_assignments"), params.Page, params.PerPage, a.listFilters(params))
 
 	var result models.RoleAssignmentList
-	if err := a.Get(ctx, url, &result); err != nil {
-		return nil, err
+	info, err := a.request(ctx, http.MethodGet, endpoint, nil, &result)
+	if err != nil {
+		return nil, models.PaginatedResponse{}, wrapErr("role_assignments.list", err)
+	}
+	a.appUserKeys(result)
+
+	meta := models.PaginatedResponse{Page: params.Page, PerPage: a.pageSize(params.PerPage)}
+	meta.Total, _ = strconv.Atoi(info.Header.Get(TotalCountHeader))
+	meta.TotalPages, _ = strconv.Atoi(info.Header.Get(TotalPagesHeader))
+	if meta.TotalPages == 0 && meta.Total > 0 && meta.PerPage > 0 {
+		meta.TotalPages = (meta.Total + meta.PerPage - 1) / meta.PerPage
+	}
+	return result, meta, nil
+}
+
+// listFilters returns the query filters of a role assignments list.
+func (a *RoleAssignmentsAPI) listFilters(params *models.RoleAssignmentListParams) map[string]string {
+	return map[string]string{
+		"user":              a.config.APIUserKey(params.User),
+		"role":              params.Role,
+		"tenant":            params.Tenant,
+		"resource":          params.Resource,
+		"resource_instance": params.ResourceInstance,
 	}
-	return result, nil
+}
+
+// ListAll returns every role assignment matching params, following all pages.
+// params may be nil; its Page is ignored. Pages are followed until the
+// reported page count, or a short page when the server reports none.
+func (a *RoleAssignmentsAPI) ListAll(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error) {
+	query := models.RoleAssignmentListParams{}
+	if params != nil {
+		query = *params
+	}
+	return NewPaginator(func(ctx context.Context, page, perPage int) ([]models.RoleAssignmentRead, models.PaginatedResponse, error) {
+		query.Page, query.PerPage = page, perPage
+		return a.ListWithPagination(ctx, &query)
+	}, a.pageSize(query.PerPage)).All(ctx)
+}
+
+// Count returns the number of role assignments matching params. params may be nil.
+//
+// The count is read from the X-Total-Count header of a single-item page. When
+// the server doesn't send it, this pages through every matching assignment,
+// which can be expensive for large result sets.
+func (a *RoleAssignmentsAPI) Count(ctx context.Context, params *models.RoleAssignmentListParams) (int, error) {
+	query := models.RoleAssignmentListParams{}
+	if params != nil {
+		query = *params
+	}
+
+	probe := query
+	probe.Page, probe.PerPage = 1, 1
+	result, meta, err := a.ListWithPagination(ctx, &probe)
+	if err != nil {
+		return 0, err
+	}
+	if meta.Total > 0 || len(result) == 0 {
+		return meta.Total, nil
+	}
+
+	count := 0
+	err = NewPaginator(func(ctx context.Context, page, perPage int) ([]models.RoleAssignmentRead, models.PaginatedResponse, error) {
+		query.Page, query.PerPage = page, perPage
+		return a.ListWithPagination(ctx, &query)
+	}, a.pageSize(query.PerPage)).ForEach(ctx, func(models.RoleAssignmentRead) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
 }
 
 // ListByUser returns role assignments for a specific user.
@@ -60,6 +147,45 @@ func (a *RoleAssignmentsAPI) ListByTenant(ctx context.Context, tenantKey string,
 	return a.List(ctx, params)
 }
 
+// ListByTenantDetailed returns every role assignment of a tenant, following
+// all pages, with each role's display name included. params may be nil; its
+// Page is ignored and its PerPage sets the size of the pages fetched. Roles
+// are fetched once; assignments referencing roles that no longer exist fall
+// back to the role key.
+func (a *RoleAssignmentsAPI) ListByTenantDetailed(ctx context.Context, tenantKey string, params *models.RoleAssignmentListParams) ([]models.RoleAssignmentWithRole, error) {
+	query := models.RoleAssignmentListParams{}
+	if params != nil {
+		query = *params
+	}
+	query.Tenant = tenantKey
+	assignments, err := a.ListAll(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := (&RolesAPI{BaseClient: a.BaseClient}).ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	rolesMap := make(map[string]*models.RoleRead, len(roles))
+	for i := range roles {
+		rolesMap[roles[i].Key] = &roles[i]
+	}
+
+	result := make([]models.RoleAssignmentWithRole, 0, len(assignments))
+	for _, assignment := range assignments {
+		detailed := models.RoleAssignmentWithRole{RoleAssignmentRead: assignment, RoleName: assignment.Role}
+		if role, ok := rolesMap[assignment.Role]; ok {
+			detailed.RoleFound = true
+			if role.Name != "" {
+				detailed.RoleName = role.Name
+			}
+		}
+		result = append(result, detailed)
+	}
+	return result, nil
+}
+
 // ListByResource returns role assignments for a specific resource.
 func (a *RoleAssignmentsAPI) ListByResource(ctx context.Context, resourceType, instanceKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error) {
 	if params == nil {
@@ -71,74 +197,231 @@ func (a *RoleAssignmentsAPI) ListByResource(ctx context.Context, resourceType, i
 }
 
 // Assign creates a new role assignment.
+// An empty tenant is replaced by the configured default tenant.
 func (a *RoleAssignmentsAPI) Assign(ctx context.Context, assignment *models.RoleAssignmentCreate) (*models.RoleAssignmentRead, error) {
-	url := a.BuildFactsURL("/role_assignments")
+	endpoint := a.BuildFactsURL("/role_assignments")
+
+	body := *assignment
+	body.User = a.config.APIUserKey(body.User)
+	body.Tenant = a.config.TenantOrDefault(body.Tenant)
 
 	var result models.RoleAssignmentRead
-	if err := a.Post(ctx, url, assignment, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("role_assignments.assign", err)
 	}
+	result.User = a.config.AppUserKey(result.User)
 	return &result, nil
 }
 
 // Unassign removes a role assignment.
 func (a *RoleAssignmentsAPI) Unassign(ctx context.Context, user, role, tenant string) error {
-	url := a.BuildFactsURL("/role_assignments")
+	endpoint := a.BuildFactsURL("/role_assignments")
 
 	body := map[string]string{
-		"user":   user,
+		"user":   a.config.APIUserKey(user),
 		"role":   role,
-		"tenant": tenant,
+		"tenant": a.config.TenantOrDefault(tenant),
 	}
 
-	return a.DeleteWithBody(ctx, url, body, nil)
+	return wrapErr("role_assignments.unassign", a.DeleteWithBody(ctx, endpoint, body, nil))
 }
 
 // UnassignWithResource removes a role assignment with resource context.
 func (a *RoleAssignmentsAPI) UnassignWithResource(ctx context.Context, user, role, tenant, resource, resourceInstance string) error {
-	url := a.BuildFactsURL("/role_assignments")
+	endpoint := a.BuildFactsURL("/role_assignments")
 
 	body := map[string]string{
-		"user":              user,
+		"user":              a.config.APIUserKey(user),
 		"role":              role,
-		"tenant":            tenant,
+		"tenant":            a.config.TenantOrDefault(tenant),
 		"resource":          resource,
 		"resource_instance": resourceInstance,
 	}
 
-	return a.DeleteWithBody(ctx, url, body, nil)
+	return wrapErr("role_assignments.unassign_with_resource", a.DeleteWithBody(ctx, endpoint, body, nil))
+}
+
+// PurgeExpired unassigns the role assignments matching params whose ExpiresAt
+// has passed, and returns how many were removed. Assignments without an expiry
+// are kept. Scope params by user or tenant to bound the work; params may be nil.
+//
+// It is meant to run periodically, since the backend may not remove expired
+// assignments itself. A failed unassign doesn't stop the purge; the errors are
+// returned joined, alongside the count of assignments that were removed. If
+// ctx is cancelled, the purge stops and ctx's error is returned with the count
+// removed so far.
+func (a *RoleAssignmentsAPI) PurgeExpired(ctx context.Context, params *models.RoleAssignmentListParams) (int, error) {
+	assignments, err := a.ListAll(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	purged := 0
+	var errs []error
+	for _, assignment := range assignments {
+		if err := ctx.Err(); err != nil {
+			return purged, errors.Join(append(errs, err)...)
+		}
+		if !assignment.ExpiredAt(now) {
+			continue
+		}
+		err := a.UnassignWithResource(ctx, assignment.User, assignment.Role, assignment.Tenant,
+			assignment.Resource, assignment.ResourceInstance)
+		if err != nil && !isNotFound(err) {
+			errs = append(errs, err)
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
 }
 
 // BulkAssign creates multiple role assignments at once.
 func (a *RoleAssignmentsAPI) BulkAssign(ctx context.Context, assignments []models.RoleAssignmentCreate) (*models.BulkRoleAssignmentResponse, error) {
-	url := a.BuildFactsURL("/role_assignments/bulk")
+	endpoint := a.BuildFactsURL("/role_assignments/bulk")
 
 	body := models.BulkRoleAssignmentRequest{
-		Assignments: assignments,
+		Assignments: a.forAPI(assignments),
 	}
 
 	var result models.BulkRoleAssignmentResponse
-	if err := a.Post(ctx, url, body, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("role_assignments.bulk_assign", err)
 	}
+	a.appBulkErrors(&result)
 	return &result, nil
 }
 
 // BulkUnassign removes multiple role assignments at once.
 func (a *RoleAssignmentsAPI) BulkUnassign(ctx context.Context, assignments []models.RoleAssignmentCreate) (*models.BulkRoleAssignmentResponse, error) {
-	url := a.BuildFactsURL("/role_assignments/bulk")
+	endpoint := a.BuildFactsURL("/role_assignments/bulk")
 
 	body := models.BulkRoleAssignmentRequest{
-		Assignments: assignments,
+		Assignments: a.forAPI(assignments),
 	}
 
 	var result models.BulkRoleAssignmentResponse
-	if err := a.DeleteWithBody(ctx, url, body, &result); err != nil {
-		return nil, err
+	if err := a.DeleteWithBody(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("role_assignments.bulk_unassign", err)
 	}
+	a.appBulkErrors(&result)
 	return &result, nil
 }
 
+// forAPI returns a copy of assignments with empty tenants replaced by the
+// configured default tenant and user keys transformed for the API.
+func (a *RoleAssignmentsAPI) forAPI(assignments []models.RoleAssignmentCreate) []models.RoleAssignmentCreate {
+	if a.config.DefaultTenant == "" && a.config.UserKeyTransform == nil {
+		return assignments
+	}
+	result := make([]models.RoleAssignmentCreate, len(assignments))
+	for i, assignment := range assignments {
+		assignment.User = a.config.APIUserKey(assignment.User)
+		assignment.Tenant = a.config.TenantOrDefault(assignment.Tenant)
+		result[i] = assignment
+	}
+	return result
+}
+
+// appUserKeys maps the user keys of assignments read from the API back to
+// application keys, in place.
+func (a *RoleAssignmentsAPI) appUserKeys(assignments models.RoleAssignmentList) {
+	for i := range assignments {
+		assignments[i].User = a.config.AppUserKey(assignments[i].User)
+	}
+}
+
+// appBulkErrors maps the user keys of the failed assignments in a bulk
+// response back to application keys.
+func (a *RoleAssignmentsAPI) appBulkErrors(result *models.BulkRoleAssignmentResponse) {
+	for i := range result.Errors {
+		result.Errors[i].Assignment.User = a.config.AppUserKey(result.Errors[i].Assignment.User)
+	}
+}
+
+// DefaultBulkBatchSize is the default number of assignments per bulk request
+// in BulkAssignStream.
+const DefaultBulkBatchSize = 500
+
+// BulkAssignStreamOptions contains optional parameters for BulkAssignStream.
+type BulkAssignStreamOptions struct {
+	// BatchSize is the number of assignments per bulk request.
+	// Defaults to DefaultBulkBatchSize.
+	BatchSize int
+}
+
+// BulkBatchError is returned by BulkAssignStream when a batch can't be read
+// or submitted. Offset is the index in the stream of the batch's first
+// assignment; assignments before it have been submitted.
+type BulkBatchError struct {
+	Offset int
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *BulkBatchError) Error() string {
+	return fmt.Sprintf("bulk batch at offset %d: %v", e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *BulkBatchError) Unwrap() error {
+	return e.Err
+}
+
+// BulkAssignStream reads newline-delimited JSON role assignments from r and
+// submits them with BulkAssign in batches, so large imports never have to be
+// held in memory. Wrap r (e.g. with gzip.NewReader) to import compressed files.
+//
+// The returned response aggregates the counts and errors of all submitted
+// batches. Processing stops at the first batch that can't be decoded or
+// submitted, or when ctx is cancelled between batches; the error is then a
+// *BulkBatchError, returned together with the aggregate so far.
+func (a *RoleAssignmentsAPI) BulkAssignStream(ctx context.Context, r io.Reader, options *BulkAssignStreamOptions) (*models.BulkRoleAssignmentResponse, error) {
+	batchSize := DefaultBulkBatchSize
+	if options != nil && options.BatchSize > 0 {
+		batchSize = options.BatchSize
+	}
+
+	total := &models.BulkRoleAssignmentResponse{}
+	decoder := json.NewDecoder(r)
+	batch := make([]models.RoleAssignmentCreate, 0, batchSize)
+
+	for offset := 0; ; offset += len(batch) {
+		batch = batch[:0]
+		for len(batch) < batchSize {
+			var assignment models.RoleAssignmentCreate
+			err := decoder.Decode(&assignment)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return total, &BulkBatchError{Offset: offset, Err: fmt.Errorf("decode assignment %d: %w", offset+len(batch), err)}
+			}
+			batch = append(batch, assignment)
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return total, &BulkBatchError{Offset: offset, Err: err}
+		}
+
+		result, err := a.BulkAssign(ctx, batch)
+		if err != nil {
+			return total, &BulkBatchError{Offset: offset, Err: err}
+		}
+		total.Created += result.Created
+		total.Failed += result.Failed
+		total.Errors = append(total.Errors, result.Errors...)
+
+		if len(batch) < batchSize {
+			return total, nil
+		}
+	}
+}
+
 // HasRole checks if a user has a specific role.
 func (a *RoleAssignmentsAPI) HasRole(ctx context.Context, userKey, roleKey string, options *HasRoleOptions) (bool, error) {
 	params := &models.RoleAssignmentListParams{
@@ -242,34 +525,29 @@ type GetRoleUsersOptions struct {
 }
 
 // ListDetailed returns detailed role assignments with expanded information.
+// params may be nil.
 func (a *RoleAssignmentsAPI) ListDetailed(ctx context.Context, params *models.RoleAssignmentListParams) (*models.RoleAssignmentList, error) {
-	url := a.BuildFactsURL("/role_assignments/detailed")
-
-	if params != nil {
-		queryParams := ListParamsToMap(params.Page, params.PerPage, map[string]string{
-			"user":              params.User,
-			"role":              params.Role,
-			"tenant":            params.Tenant,
-			"resource":          params.Resource,
-			"resource_instance": params.ResourceInstance,
-		})
-		url = BuildQueryParams(url, queryParams)
+	if params == nil {
+		params = &models.RoleAssignmentListParams{}
 	}
+	endpoint := a.listURL(a.BuildFactsURL("/role_assignments/detailed"), params.Page, params.PerPage, a.listFilters(params))
 
 	var result models.RoleAssignmentList
-	if err := a.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("role_assignments.list_detailed", err)
 	}
+	a.appUserKeys(result)
 	return &result, nil
 }
 
 // GetByID retrieves a role assignment by ID.
 func (a *RoleAssignmentsAPI) GetByID(ctx context.Context, id string) (*models.RoleAssignmentRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/role_assignments/%s", id))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/role_assignments/%s", url.PathEscape(id)))
 
 	var result models.RoleAssignmentRead
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("role_assignments.get_by_id", err)
 	}
+	result.User = a.config.AppUserKey(result.User)
 	return &result, nil
 }
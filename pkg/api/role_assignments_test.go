@@ -0,0 +1,298 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+func TestRoleAssignmentsCountPaginates(t *testing.T) {
+	const total = 250
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("perPage"))
+
+		var result models.RoleAssignmentList
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			result = append(result, models.RoleAssignmentRead{ID: strconv.Itoa(i)})
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+
+	count, err := NewRoleAssignmentsAPI(cfg).Count(context.Background(), &models.RoleAssignmentListParams{Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("Count() error: %v", err)
+	}
+	if count != total {
+		t.Errorf("Count() = %d, want %d", count, total)
+	}
+}
+
+func TestRoleAssignmentsPaginationHeaders(t *testing.T) {
+	const total = 200
+	var requests int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("perPage"))
+
+		// Full pages only: the short-page heuristic alone would request page 3
+		var result models.RoleAssignmentList
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			result = append(result, models.RoleAssignmentRead{ID: strconv.Itoa(i)})
+		}
+		w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+	assignments := NewRoleAssignmentsAPI(cfg)
+	ctx := context.Background()
+
+	_, meta, err := assignments.ListWithPagination(ctx, &models.RoleAssignmentListParams{ListParams: models.ListParams{Page: 1, PerPage: 100}})
+	if err != nil {
+		t.Fatalf("ListWithPagination() error: %v", err)
+	}
+	if meta.Total != total || meta.TotalPages != 2 || meta.Page != 1 || meta.PerPage != 100 {
+		t.Errorf("meta = %+v, want total %d over 2 pages", meta, total)
+	}
+
+	requests = 0
+	all, err := assignments.ListAll(ctx, nil)
+	if err != nil || len(all) != total {
+		t.Fatalf("ListAll() = %d items, %v, want %d", len(all), err, total)
+	}
+	if requests != 2 {
+		t.Errorf("ListAll() made %d requests, want 2", requests)
+	}
+
+	requests = 0
+	count, err := assignments.Count(ctx, nil)
+	if err != nil || count != total || requests != 1 {
+		t.Errorf("Count() = %d, %v in %d requests, want %d in 1", count, err, requests, total)
+	}
+}
+
+func TestListByTenantDetailedJoinsRoleNamesAcrossPages(t *testing.T) {
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/facts/proj/env/role_assignments":
+			// One assignment per page
+			page := models.RoleAssignmentList{{User: "alice", Role: "editor", Tenant: "acme"}}
+			if r.URL.Query().Get("page") == "2" {
+				page = models.RoleAssignmentList{{User: "bob", Role: "deleted-role", Tenant: "acme"}}
+			}
+			w.Header().Set(TotalCountHeader, "2")
+			_ = json.NewEncoder(w).Encode(page)
+		case "/v1/schema/proj/env/roles":
+			_ = json.NewEncoder(w).Encode(models.RoleList{
+				Data:              []models.RoleRead{{Key: "editor", Name: "Content Editor"}},
+				PaginatedResponse: models.PaginatedResponse{Page: 1, TotalPages: 1, Total: 1},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	params := &models.RoleAssignmentListParams{ListParams: models.ListParams{PerPage: 1}}
+	result, err := NewRoleAssignmentsAPI(cfg).ListByTenantDetailed(context.Background(), "acme", params)
+	if err != nil {
+		t.Fatalf("ListByTenantDetailed() error: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 assignments, got %d", len(result))
+	}
+	if result[0].RoleName != "Content Editor" || !result[0].RoleFound {
+		t.Errorf("editor assignment = %+v, want role name joined", result[0])
+	}
+	if result[1].RoleName != "deleted-role" || result[1].RoleFound {
+		t.Errorf("missing role assignment = %+v, want fallback to key", result[1])
+	}
+}
+
+func TestBulkAssignStreamBatches(t *testing.T) {
+	var batches []int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body models.BulkRoleAssignmentRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		batches = append(batches, len(body.Assignments))
+		if len(batches) == 3 {
+			http.Error(w, `{"message":"boom"}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.BulkRoleAssignmentResponse{Created: len(body.Assignments) - 1, Failed: 1})
+	}))
+
+	var input strings.Builder
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(&input, `{"user":"user-%d","role":"viewer","tenant":"acme"}`+"\n", i)
+	}
+
+	result, err := NewRoleAssignmentsAPI(cfg).BulkAssignStream(context.Background(),
+		strings.NewReader(input.String()), &BulkAssignStreamOptions{BatchSize: 2})
+
+	var batchErr *BulkBatchError
+	if !errors.As(err, &batchErr) || batchErr.Offset != 4 {
+		t.Fatalf("BulkAssignStream() error = %v, want a batch error at offset 4", err)
+	}
+	if result.Created != 2 || result.Failed != 2 {
+		t.Errorf("result = %+v, want 2 created and 2 failed before the failing batch", result)
+	}
+	if want := []int{2, 2, 1}; len(batches) != len(want) || batches[0] != 2 || batches[1] != 2 || batches[2] != 1 {
+		t.Errorf("batch sizes = %v, want %v", batches, want)
+	}
+}
+
+func TestBulkAssignStreamRejectsInvalidJSON(t *testing.T) {
+	var requests int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_ = json.NewEncoder(w).Encode(models.BulkRoleAssignmentResponse{Created: 1})
+	}))
+
+	input := `{"user":"alice","role":"viewer"}` + "\n" + `{"user":` + "\n"
+	result, err := NewRoleAssignmentsAPI(cfg).BulkAssignStream(context.Background(),
+		strings.NewReader(input), &BulkAssignStreamOptions{BatchSize: 1})
+
+	var batchErr *BulkBatchError
+	if !errors.As(err, &batchErr) || batchErr.Offset != 1 {
+		t.Fatalf("BulkAssignStream() error = %v, want a batch error at offset 1", err)
+	}
+	if requests != 1 || result.Created != 1 {
+		t.Errorf("requests = %d, created = %d, want the valid batch submitted", requests, result.Created)
+	}
+}
+
+func TestBulkUnassignTreatsRetried404AsDeleted(t *testing.T) {
+	var attempts int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			// The delete is applied, but the connection drops before the response
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatalf("hijack failed: %v", err)
+			}
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"role assignment not found"}`)
+	}))
+	cfg.RetryAttempts = 1
+
+	_, err := NewRoleAssignmentsAPI(cfg).BulkUnassign(context.Background(), []models.RoleAssignmentCreate{
+		{User: "alice", Role: "editor", Tenant: "acme"},
+	})
+	if err != nil {
+		t.Fatalf("BulkUnassign() error: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+
+	// A 404 on the first attempt is still an error
+	cfg.RetryAttempts = 0
+	attempts = 1
+	_, err = NewRoleAssignmentsAPI(cfg).BulkUnassign(context.Background(), nil)
+	if !isNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	assignments := models.RoleAssignmentList{
+		{User: "alice", Role: "editor", Tenant: "acme", ExpiresAt: &past},
+		{User: "bob", Role: "editor", Tenant: "acme", ExpiresAt: &future},
+		{User: "carol", Role: "viewer", Tenant: "acme"},
+		{User: "dave", Role: "admin", Tenant: "acme", Resource: "document", ResourceInstance: "doc-1", ExpiresAt: &past},
+	}
+
+	var tenant string
+	var unassigned []string
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			tenant = r.URL.Query().Get("tenant")
+			_ = json.NewEncoder(w).Encode(assignments)
+		case http.MethodDelete:
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode unassign body: %v", err)
+			}
+			unassigned = append(unassigned, body["user"]+":"+body["resource_instance"])
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	purged, err := NewRoleAssignmentsAPI(cfg).PurgeExpired(context.Background(), &models.RoleAssignmentListParams{Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("PurgeExpired() error: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("purged = %d, want 2", purged)
+	}
+	if tenant != "acme" {
+		t.Errorf("listed tenant = %q, want acme", tenant)
+	}
+	if strings.Join(unassigned, ",") != "alice:,dave:doc-1" {
+		t.Errorf("unassigned = %v", unassigned)
+	}
+}
+
+func TestPurgeExpiredStopsWhenCancelled(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	assignments := models.RoleAssignmentList{
+		{User: "alice", Role: "editor", ExpiresAt: &past},
+		{User: "bob", Role: "editor", ExpiresAt: &past},
+		{User: "carol", Role: "editor", ExpiresAt: &past},
+	}
+
+	var unassigns int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(assignments)
+			return
+		}
+		unassigns++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// Cancel once the first unassign has completed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	transport := cfg.HTTPClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	cfg.HTTPClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := transport.RoundTrip(r)
+		if r.Method == http.MethodDelete {
+			cancel()
+		}
+		return resp, err
+	})}
+
+	purged, err := NewRoleAssignmentsAPI(cfg).PurgeExpired(ctx, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("PurgeExpired() error = %v, want context.Canceled", err)
+	}
+	if purged != 1 || unassigns != 1 {
+		t.Errorf("purged = %d after %d unassigns, want 1 and 1", purged, unassigns)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
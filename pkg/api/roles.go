@@ -3,8 +3,11 @@ package api
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"sort"
 
 	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/enforcement"
 	"github.com/permissio/permissio-go/pkg/models"
 )
 
@@ -20,122 +23,491 @@ func NewRolesAPI(cfg *config.Config) *RolesAPI {
 	}
 }
 
-// List returns a paginated list of roles.
+// List returns a paginated list of roles. params may be nil.
 func (a *RolesAPI) List(ctx context.Context, params *models.RoleListParams) (*models.RoleList, error) {
-	url := a.BuildSchemaURL("/roles")
-
-	if params != nil {
-		queryParams := ListParamsToMap(params.Page, params.PerPage, map[string]string{
-			"search": params.Search,
-		})
-		url = BuildQueryParams(url, queryParams)
+	if params == nil {
+		params = &models.RoleListParams{}
 	}
+	endpoint := a.listURL(a.BuildSchemaURL("/roles"), params.Page, params.PerPage, map[string]string{
+		"search": params.Search,
+	})
 
 	var result models.RoleList
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("roles.list", err)
 	}
 	return &result, nil
 }
 
+// ListAll returns every role matching params, following all pages.
+// params may be nil; its Page is ignored.
+func (a *RolesAPI) ListAll(ctx context.Context, params *models.RoleListParams) ([]models.RoleRead, error) {
+	query := models.RoleListParams{}
+	if params != nil {
+		query = *params
+	}
+	return NewPaginator(func(ctx context.Context, page, perPage int) ([]models.RoleRead, models.PaginatedResponse, error) {
+		query.Page, query.PerPage = page, perPage
+		result, err := a.List(ctx, &query)
+		if err != nil {
+			return nil, models.PaginatedResponse{}, err
+		}
+		return result.Data, result.PaginatedResponse, nil
+	}, a.pageSize(query.PerPage)).All(ctx)
+}
+
+// Count returns the number of roles matching params, as reported by the
+// pagination metadata of a single-item page. params may be nil.
+func (a *RolesAPI) Count(ctx context.Context, params *models.RoleListParams) (int, error) {
+	query := models.RoleListParams{}
+	if params != nil {
+		query = *params
+	}
+	query.Page, query.PerPage = 1, 1
+
+	result, err := a.List(ctx, &query)
+	if err != nil {
+		return 0, err
+	}
+	return result.Total, nil
+}
+
 // Get retrieves a role by key.
 func (a *RolesAPI) Get(ctx context.Context, roleKey string) (*models.RoleRead, error) {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s", roleKey))
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s", url.PathEscape(roleKey)))
 
 	var result models.RoleRead
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("roles.get", err)
 	}
 	return &result, nil
 }
 
 // Create creates a new role.
+// The error matches ErrConflict if the role already exists.
 func (a *RolesAPI) Create(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, error) {
-	url := a.BuildSchemaURL("/roles")
+	if err := a.validatePermissions(role.Permissions); err != nil {
+		return nil, err
+	}
+
+	endpoint := a.BuildSchemaURL("/roles")
 
 	var result models.RoleRead
-	if err := a.Post(ctx, url, role, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, role, &result); err != nil {
+		return nil, wrapErr("roles.create", err)
 	}
 	return &result, nil
 }
 
 // Update updates an existing role.
 func (a *RolesAPI) Update(ctx context.Context, roleKey string, data *models.RoleUpdate) (*models.RoleRead, error) {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s", roleKey))
+	if err := a.validatePermissions(data.Permissions); err != nil {
+		return nil, err
+	}
+
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s", url.PathEscape(roleKey)))
 
 	var result models.RoleRead
-	if err := a.Patch(ctx, url, data, &result); err != nil {
-		return nil, err
+	if err := a.Patch(ctx, endpoint, data, &result); err != nil {
+		return nil, wrapErr("roles.update", err)
+	}
+	return &result, nil
+}
+
+// CreateRaw creates a role from an arbitrary body (json.RawMessage, a map,
+// ...) sent as is. Permissions in it are not validated, even with
+// ValidatePermissions enabled.
+func (a *RolesAPI) CreateRaw(ctx context.Context, body interface{}) (*models.RoleRead, error) {
+	endpoint := a.BuildSchemaURL("/roles")
+
+	var result models.RoleRead
+	if err := a.Post(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("roles.create_raw", err)
+	}
+	return &result, nil
+}
+
+// UpdateRaw is like Update with a body the SDK doesn't model; see CreateRaw.
+func (a *RolesAPI) UpdateRaw(ctx context.Context, roleKey string, body interface{}) (*models.RoleRead, error) {
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s", url.PathEscape(roleKey)))
+
+	var result models.RoleRead
+	if err := a.Patch(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("roles.update_raw", err)
 	}
 	return &result, nil
 }
 
 // Delete deletes a role.
 func (a *RolesAPI) Delete(ctx context.Context, roleKey string) error {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s", roleKey))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s", url.PathEscape(roleKey)))
+	return wrapErr("roles.delete", a.BaseClient.Delete(ctx, endpoint, nil))
 }
 
 // Sync creates or updates a role (upsert).
 func (a *RolesAPI) Sync(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, error) {
-	url := a.BuildSchemaURL("/roles")
+	result, _, err := a.Upsert(ctx, role)
+	return result, err
+}
+
+// Upsert is like Sync but also reports whether the role was created
+// rather than updated.
+func (a *RolesAPI) Upsert(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, bool, error) {
+	if err := a.validatePermissions(role.Permissions); err != nil {
+		return nil, false, err
+	}
+
+	endpoint := a.BuildSchemaURL("/roles")
 
 	var result models.RoleRead
-	if err := a.Put(ctx, url, role, &result); err != nil {
-		return nil, err
+	created, err := a.upsert(ctx, endpoint, role, &result)
+	if err != nil {
+		return nil, false, wrapErr("roles.sync", err)
 	}
-	return &result, nil
+	return &result, created, nil
 }
 
 // GetPermissions returns the permissions for a role.
 func (a *RolesAPI) GetPermissions(ctx context.Context, roleKey string) ([]string, error) {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/permissions", roleKey))
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/permissions", url.PathEscape(roleKey)))
 
 	var result struct {
 		Permissions []string `json:"permissions"`
 	}
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("roles.get_permissions", err)
 	}
 	return result.Permissions, nil
 }
 
 // AddPermission adds a permission to a role.
 func (a *RolesAPI) AddPermission(ctx context.Context, roleKey, permission string) error {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/permissions", roleKey))
+	if err := a.validatePermissions([]string{permission}); err != nil {
+		return err
+	}
+
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/permissions", url.PathEscape(roleKey)))
 	body := map[string]string{"permission": permission}
-	return a.Post(ctx, url, body, nil)
+	return wrapErr("roles.add_permission", a.Post(ctx, endpoint, body, nil))
 }
 
 // RemovePermission removes a permission from a role.
 func (a *RolesAPI) RemovePermission(ctx context.Context, roleKey, permission string) error {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/permissions/%s", roleKey, permission))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/permissions/%s", url.PathEscape(roleKey), url.PathEscape(permission)))
+	return wrapErr("roles.remove_permission", a.BaseClient.Delete(ctx, endpoint, nil))
 }
 
 // GetExtends returns the roles that this role extends.
 func (a *RolesAPI) GetExtends(ctx context.Context, roleKey string) ([]string, error) {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/extends", roleKey))
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/extends", url.PathEscape(roleKey)))
 
 	var result struct {
 		Extends []string `json:"extends"`
 	}
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("roles.get_extends", err)
 	}
 	return result.Extends, nil
 }
 
 // AddExtends adds a parent role to extend from.
 func (a *RolesAPI) AddExtends(ctx context.Context, roleKey, parentRoleKey string) error {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/extends", roleKey))
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/extends", url.PathEscape(roleKey)))
 	body := map[string]string{"role": parentRoleKey}
-	return a.Post(ctx, url, body, nil)
+	return wrapErr("roles.add_extends", a.Post(ctx, endpoint, body, nil))
 }
 
 // RemoveExtends removes a parent role from the extends list.
 func (a *RolesAPI) RemoveExtends(ctx context.Context, roleKey, parentRoleKey string) error {
-	url := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/extends/%s", roleKey, parentRoleKey))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildSchemaURL(fmt.Sprintf("/roles/%s/extends/%s", url.PathEscape(roleKey), url.PathEscape(parentRoleKey)))
+	return wrapErr("roles.remove_extends", a.BaseClient.Delete(ctx, endpoint, nil))
+}
+
+// GetExtendedByOptions contains optional parameters for GetExtendedBy.
+type GetExtendedByOptions struct {
+	// Transitive also returns roles that extend the role through other roles.
+	Transitive bool
+}
+
+// GetExtendedBy returns the keys of the roles that extend roleKey, sorted.
+// Only direct dependents are returned unless options.Transitive is set. Use it
+// to assess which roles an edit to roleKey affects. The role graph is fetched
+// with ListAll and evaluated client-side.
+func (a *RolesAPI) GetExtendedBy(ctx context.Context, roleKey string, options *GetExtendedByOptions) ([]string, error) {
+	chains, err := a.GetExtendedByChains(ctx, roleKey)
+	if err != nil {
+		return nil, err
+	}
+
+	transitive := options != nil && options.Transitive
+	dependents := make([]string, 0, len(chains))
+	for dependent, chain := range chains {
+		if transitive || len(chain) == 2 {
+			dependents = append(dependents, dependent)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents, nil
+}
+
+// GetExtendedByChains returns every role that extends roleKey, directly or
+// transitively, mapped to its shortest extends chain from the dependent role
+// down to roleKey. For example, if admin extends editor and editor extends
+// viewer, the result for viewer includes "admin": ["admin", "editor", "viewer"].
+func (a *RolesAPI) GetExtendedByChains(ctx context.Context, roleKey string) (map[string][]string, error) {
+	roles, err := a.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := models.NewRoleGraph(roles)
+	if _, ok := graph.Node(roleKey); !ok {
+		return nil, NewPermisError(fmt.Sprintf("role %s not found", roleKey), "NOT_FOUND", 404)
+	}
+	return graph.ExtendedByChains(roleKey), nil
+}
+
+// PermissionSource reports whether a role grants a permission directly, which
+// ancestor role it is inherited from (with the full inheritance path), or that
+// it is absent. Wildcard permissions ("document:*", "*:*") count as granting.
+func (a *RolesAPI) PermissionSource(ctx context.Context, roleKey, permission string) (*models.PermissionSource, error) {
+	required, err := models.ParsePermission(permission)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := a.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rolesMap := make(map[string]*models.RoleRead, len(roles))
+	for i := range roles {
+		rolesMap[roles[i].Key] = &roles[i]
+	}
+	if _, ok := rolesMap[roleKey]; !ok {
+		return nil, NewPermisError(fmt.Sprintf("role %s not found", roleKey), "NOT_FOUND", 404)
+	}
+
+	source := &models.PermissionSource{Role: roleKey, Permission: permission}
+
+	// Breadth-first search so the nearest granting role is reported
+	paths := map[string][]string{roleKey: {roleKey}}
+	queue := []string{roleKey}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		role, ok := rolesMap[current]
+		if !ok {
+			continue
+		}
+
+		for _, perm := range role.Permissions {
+			if models.PermissionMatches(perm, required.ResourceType, required.Action) {
+				source.Granted = true
+				source.Direct = current == roleKey
+				source.GrantedBy = current
+				source.GrantingPermission = perm
+				source.Path = paths[current]
+				return source, nil
+			}
+		}
+
+		for _, parent := range role.Extends {
+			if _, seen := paths[parent]; seen {
+				continue
+			}
+			path := make([]string, len(paths[current]), len(paths[current])+1)
+			copy(path, paths[current])
+			paths[parent] = append(path, parent)
+			queue = append(queue, parent)
+		}
+	}
+
+	return source, nil
+}
+
+// EffectivePermissionCount returns, for every role, the number of distinct
+// permissions it grants including those inherited through Extends, to spot
+// over-privileged roles. Roles are fetched once and inheritance is resolved
+// as in client-side checks: roles in an inheritance cycle share their
+// permissions, parents missing from the roles list are ignored, and ancestors
+// beyond config.MaxInheritanceDepth don't count.
+func (a *RolesAPI) EffectivePermissionCount(ctx context.Context) (map[string]int, error) {
+	graph, permissions, err := a.effectivePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		counts[node.Key] = len(permissions[node.Key])
+	}
+	return counts, nil
+}
+
+// WildcardGrants returns, for every role granting at least one wildcard
+// permission ("document:*", "*:*"), those permissions split into the ones
+// defined on the role and the ones it inherits, both sorted. Use it with
+// EffectivePermissionCount in security reviews to find over-broad roles.
+// Inheritance is resolved as in EffectivePermissionCount. Malformed
+// permissions are ignored.
+func (a *RolesAPI) WildcardGrants(ctx context.Context) (map[string]models.RoleWildcards, error) {
+	graph, permissions, err := a.effectivePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	grants := make(map[string]models.RoleWildcards)
+	for _, node := range graph.Nodes {
+		direct := make(map[string]struct{}, len(node.Permissions))
+		for _, perm := range node.Permissions {
+			direct[perm] = struct{}{}
+		}
+
+		var wildcards models.RoleWildcards
+		for _, perm := range permissions[node.Key] {
+			if parsed, err := models.ParsePermission(perm); err != nil || !parsed.IsWildcard() {
+				continue
+			}
+			if _, ok := direct[perm]; ok {
+				wildcards.Direct = append(wildcards.Direct, perm)
+			} else {
+				wildcards.Inherited = append(wildcards.Inherited, perm)
+			}
+		}
+		if len(wildcards.Direct) == 0 && len(wildcards.Inherited) == 0 {
+			continue
+		}
+		sort.Strings(wildcards.Direct)
+		sort.Strings(wildcards.Inherited)
+		grants[node.Key] = wildcards
+	}
+	return grants, nil
+}
+
+// Hierarchy returns the role inheritance graph, built from one auto-paginated
+// role list, for admin UIs that draw the inheritance tree. A graph with
+// cycles is not an error: they are reported in the graph's Cycles.
+func (a *RolesAPI) Hierarchy(ctx context.Context) (*models.RoleGraph, error) {
+	roles, err := a.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewRoleGraph(roles), nil
+}
+
+// validatePermissions validates permission formats when permission validation is enabled.
+func (a *RolesAPI) validatePermissions(permissions []string) error {
+	if !a.config.ValidatePermissions {
+		return nil
+	}
+	return models.ValidatePermissions(permissions)
+}
+
+// effectivePermissions fetches every role and returns their graph along with,
+// for every role, the distinct permissions it grants including inherited ones,
+// resolved the way client-side checks do. Roles in the same inheritance cycle
+// share one slice.
+func (a *RolesAPI) effectivePermissions(ctx context.Context) (*models.RoleGraph, map[string][]string, error) {
+	roles, err := a.ListAll(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	graph := models.NewRoleGraph(roles)
+	if permissions, ok := permissionClosures(graph, a.config.MaxInheritanceDepth); ok {
+		return graph, permissions, nil
+	}
+
+	// MaxInheritanceDepth may leave some ancestors out, which depends on the
+	// role the walk starts from: resolve every role on its own
+	rolesMap := make(map[string]*models.RoleRead, len(roles))
+	for i := range roles {
+		if _, ok := rolesMap[roles[i].Key]; !ok {
+			rolesMap[roles[i].Key] = &roles[i]
+		}
+	}
+	evaluator := enforcement.NewEvaluator(rolesMap, nil)
+	evaluator.MaxDepth = a.config.MaxInheritanceDepth
+	permissions := make(map[string][]string, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		permissions[node.Key] = evaluator.RolePermissions(node.Key)
+	}
+	return graph, permissions, nil
+}
+
+// permissionClosures returns, for every role of graph, its permissions and
+// those of every role it inherits from, sorted. Each inheritance cycle is
+// resolved once as a single role with the permissions of all its members, and
+// every other role once from the already resolved sets of its parents. ok is
+// false when maxDepth is positive and some role may have ancestors further
+// away than maxDepth levels, which these shared sets can't leave out.
+func permissionClosures(graph *models.RoleGraph, maxDepth int) (closures map[string][]string, ok bool) {
+	// Collapse every cycle into its first role
+	component := make(map[string]string, len(graph.Nodes))
+	members := make(map[string][]models.RoleGraphNode, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		component[node.Key] = node.Key
+	}
+	for _, cycle := range graph.Cycles {
+		for _, key := range cycle {
+			component[key] = cycle[0]
+		}
+	}
+	for _, node := range graph.Nodes {
+		members[component[node.Key]] = append(members[component[node.Key]], node)
+	}
+	parents := make(map[string][]string, len(graph.Nodes))
+	for _, edge := range graph.Edges {
+		if role, parent := component[edge.Role], component[edge.Parent]; role != parent {
+			parents[role] = append(parents[role], parent)
+		}
+	}
+
+	// Once cycles are collapsed the graph is acyclic, so the walk through
+	// parents ends. height bounds how many levels of Extends separate the
+	// roles of a component from their furthest ancestor.
+	sets := make(map[string]map[string]struct{}, len(members))
+	height := make(map[string]int, len(members))
+	var resolve func(key string)
+	resolve = func(key string) {
+		if _, done := sets[key]; done {
+			return
+		}
+		set := make(map[string]struct{})
+		height[key] = len(members[key]) - 1
+		for _, node := range members[key] {
+			for _, perm := range node.Permissions {
+				set[perm] = struct{}{}
+			}
+		}
+		for _, parent := range parents[key] {
+			resolve(parent)
+			for perm := range sets[parent] {
+				set[perm] = struct{}{}
+			}
+			height[key] = max(height[key], len(members[key])+height[parent])
+		}
+		sets[key] = set
+	}
+
+	closures = make(map[string][]string, len(graph.Nodes))
+	for key := range members {
+		resolve(key)
+		if maxDepth > 0 && height[key] > maxDepth {
+			return nil, false
+		}
+
+		permissions := make([]string, 0, len(sets[key]))
+		for perm := range sets[key] {
+			permissions = append(permissions, perm)
+		}
+		sort.Strings(permissions)
+		for _, node := range members[key] {
+			closures[node.Key] = permissions
+		}
+	}
+	return closures, true
 }
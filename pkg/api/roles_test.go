@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+func TestRoleCreateValidatesPermissions(t *testing.T) {
+	var requests int
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"key":"editor"}`))
+	}))
+	roles := NewRolesAPI(cfg)
+	role := models.NewRoleCreate("editor").SetPermissions([]string{"document.read"})
+
+	if _, err := roles.Create(context.Background(), role); err != nil {
+		t.Fatalf("Create() without validation: unexpected error: %v", err)
+	}
+
+	cfg.ValidatePermissions = true
+	if _, err := roles.Create(context.Background(), role); err == nil {
+		t.Fatal("Create() with validation: expected an error for 'document.read'")
+	}
+	if requests != 1 {
+		t.Errorf("expected invalid role not to be sent, got %d requests", requests)
+	}
+}
+
+func TestRoleUpsertReportsCreated(t *testing.T) {
+	existing := map[string]bool{}
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var role models.RoleCreate
+		_ = json.NewDecoder(r.Body).Decode(&role)
+		if !existing[role.Key] {
+			existing[role.Key] = true
+			w.WriteHeader(http.StatusCreated)
+		}
+		_ = json.NewEncoder(w).Encode(models.RoleRead{Key: role.Key})
+	}))
+	roles := NewRolesAPI(cfg)
+
+	for i, wantCreated := range []bool{true, false} {
+		role, created, err := roles.Upsert(context.Background(), models.NewRoleCreate("editor"))
+		if err != nil {
+			t.Fatalf("Upsert() #%d error: %v", i+1, err)
+		}
+		if role.Key != "editor" || created != wantCreated {
+			t.Errorf("Upsert() #%d = (%q, %v), want (editor, %v)", i+1, role.Key, created, wantCreated)
+		}
+	}
+}
+
+// rolesHandler serves a single page of roles.
+func rolesHandler(t *testing.T, roles []models.RoleRead) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/schema/proj/env/roles" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(models.RoleList{
+			Data:              roles,
+			PaginatedResponse: models.PaginatedResponse{Page: 1, TotalPages: 1, Total: len(roles)},
+		})
+	})
+}
+
+func TestPermissionSource(t *testing.T) {
+	cfg := newTestConfig(t, rolesHandler(t, []models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer"}},
+		{Key: "admin", Permissions: []string{"report:*"}, Extends: []string{"editor"}},
+	}))
+	roles := NewRolesAPI(cfg)
+	ctx := context.Background()
+
+	direct, err := roles.PermissionSource(ctx, "admin", "report:export")
+	if err != nil {
+		t.Fatalf("PermissionSource() error: %v", err)
+	}
+	if !direct.Granted || !direct.Direct || direct.GrantingPermission != "report:*" {
+		t.Errorf("unexpected direct source %+v", direct)
+	}
+
+	inherited, err := roles.PermissionSource(ctx, "admin", "document:read")
+	if err != nil {
+		t.Fatalf("PermissionSource() error: %v", err)
+	}
+	if !inherited.Granted || inherited.Direct || inherited.GrantedBy != "viewer" {
+		t.Errorf("unexpected inherited source %+v", inherited)
+	}
+	if strings.Join(inherited.Path, ">") != "admin>editor>viewer" {
+		t.Errorf("Path = %v", inherited.Path)
+	}
+
+	absent, err := roles.PermissionSource(ctx, "viewer", "document:write")
+	if err != nil {
+		t.Fatalf("PermissionSource() error: %v", err)
+	}
+	if absent.Granted {
+		t.Errorf("expected permission to be absent, got %+v", absent)
+	}
+}
+
+func TestGetExtendedBy(t *testing.T) {
+	cfg := newTestConfig(t, rolesHandler(t, []models.RoleRead{
+		{Key: "viewer"},
+		{Key: "commenter", Extends: []string{"viewer"}},
+		{Key: "editor", Extends: []string{"viewer"}},
+		{Key: "admin", Extends: []string{"editor", "commenter"}},
+		{Key: "auditor"},
+	}))
+	roles := NewRolesAPI(cfg)
+	ctx := context.Background()
+
+	direct, err := roles.GetExtendedBy(ctx, "viewer", nil)
+	if err != nil {
+		t.Fatalf("GetExtendedBy() error: %v", err)
+	}
+	if strings.Join(direct, ",") != "commenter,editor" {
+		t.Errorf("direct dependents = %v", direct)
+	}
+
+	all, err := roles.GetExtendedBy(ctx, "viewer", &GetExtendedByOptions{Transitive: true})
+	if err != nil {
+		t.Fatalf("GetExtendedBy() error: %v", err)
+	}
+	if strings.Join(all, ",") != "admin,commenter,editor" {
+		t.Errorf("transitive dependents = %v", all)
+	}
+
+	chains, err := roles.GetExtendedByChains(ctx, "viewer")
+	if err != nil {
+		t.Fatalf("GetExtendedByChains() error: %v", err)
+	}
+	if len(chains["admin"]) != 3 || chains["admin"][0] != "admin" || chains["admin"][2] != "viewer" {
+		t.Errorf("admin chain = %v", chains["admin"])
+	}
+
+	if _, err := roles.GetExtendedBy(ctx, "missing", nil); !isNotFound(err) {
+		t.Errorf("expected not found for an unknown role, got %v", err)
+	}
+}
+
+func TestRolesEffectivePermissionCount(t *testing.T) {
+	roles := []models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "editor", Permissions: []string{"document:write", "document:read"}, Extends: []string{"viewer"}},
+		{Key: "admin", Permissions: []string{"document:delete"}, Extends: []string{"editor", "deleted-role"}},
+		{Key: "cycle-a", Permissions: []string{"report:read"}, Extends: []string{"cycle-b"}},
+		{Key: "cycle-b", Permissions: []string{"report:write"}, Extends: []string{"cycle-a", "viewer"}},
+	}
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(models.RoleList{
+			Data:              roles,
+			PaginatedResponse: models.PaginatedResponse{Page: 1, Total: len(roles), TotalPages: 1},
+		})
+	}))
+
+	counts, err := NewRolesAPI(cfg).EffectivePermissionCount(context.Background())
+	if err != nil {
+		t.Fatalf("EffectivePermissionCount() error: %v", err)
+	}
+	want := map[string]int{"viewer": 1, "editor": 2, "admin": 3, "cycle-a": 3, "cycle-b": 3}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("EffectivePermissionCount() = %v, want %v", counts, want)
+	}
+}
+
+func TestRolesEffectivePermissionCountHonorsMaxInheritanceDepth(t *testing.T) {
+	roles := []models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer"}},
+		{Key: "admin", Permissions: []string{"document:delete"}, Extends: []string{"editor"}},
+	}
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(models.RoleList{
+			Data:              roles,
+			PaginatedResponse: models.PaginatedResponse{Page: 1, Total: len(roles), TotalPages: 1},
+		})
+	}))
+	cfg.MaxInheritanceDepth = 1
+
+	counts, err := NewRolesAPI(cfg).EffectivePermissionCount(context.Background())
+	if err != nil {
+		t.Fatalf("EffectivePermissionCount() error: %v", err)
+	}
+	// admin reaches editor but not viewer, as in a check
+	want := map[string]int{"viewer": 1, "editor": 2, "admin": 2}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("EffectivePermissionCount() = %v, want %v", counts, want)
+	}
+}
+
+func TestPermissionClosures(t *testing.T) {
+	graph := models.NewRoleGraph([]models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer"}},
+		{Key: "cycle-a", Permissions: []string{"report:read"}, Extends: []string{"cycle-b"}},
+		{Key: "cycle-b", Permissions: []string{"report:write"}, Extends: []string{"cycle-a", "editor"}},
+	})
+
+	closures, ok := permissionClosures(graph, 0)
+	if !ok {
+		t.Fatal("expected closures without a depth limit")
+	}
+	want := []string{"document:read", "document:write", "report:read", "report:write"}
+	if !reflect.DeepEqual(closures["cycle-a"], want) {
+		t.Errorf("cycle-a closure = %v, want %v", closures["cycle-a"], want)
+	}
+	if &closures["cycle-a"][0] != &closures["cycle-b"][0] {
+		t.Error("expected the roles of a cycle to share their closure")
+	}
+
+	// viewer is three levels above cycle-a, through cycle-b and editor
+	if _, ok := permissionClosures(graph, 2); ok {
+		t.Error("expected no closures when the depth limit may cut inheritance short")
+	}
+	if _, ok := permissionClosures(graph, 3); !ok {
+		t.Error("expected closures when the depth limit covers the hierarchy")
+	}
+}
+
+func TestRolesWildcardGrants(t *testing.T) {
+	roles := []models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "doc-admin", Permissions: []string{"document:*", "report:read"}, Extends: []string{"viewer"}},
+		{Key: "owner", Permissions: []string{"*:*", "document:*"}, Extends: []string{"doc-admin"}},
+		{Key: "doc-lead", Permissions: []string{"report:write"}, Extends: []string{"doc-admin"}},
+		{Key: "auditor", Permissions: []string{"*:read", "bad.permission"}},
+		{Key: "cycle-a", Permissions: []string{"report:*"}, Extends: []string{"cycle-b"}},
+		{Key: "cycle-b", Extends: []string{"cycle-a"}},
+	}
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(models.RoleList{
+			Data:              roles,
+			PaginatedResponse: models.PaginatedResponse{Page: 1, Total: len(roles), TotalPages: 1},
+		})
+	}))
+
+	grants, err := NewRolesAPI(cfg).WildcardGrants(context.Background())
+	if err != nil {
+		t.Fatalf("WildcardGrants() error: %v", err)
+	}
+	want := map[string]models.RoleWildcards{
+		"doc-admin": {Direct: []string{"document:*"}},
+		"doc-lead":  {Inherited: []string{"document:*"}},
+		"owner":     {Direct: []string{"*:*", "document:*"}},
+		"auditor":   {Direct: []string{"*:read"}},
+		"cycle-a":   {Direct: []string{"report:*"}},
+		"cycle-b":   {Inherited: []string{"report:*"}},
+	}
+	if !reflect.DeepEqual(grants, want) {
+		t.Errorf("WildcardGrants() = %v, want %v", grants, want)
+	}
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 
 	"github.com/permissio/permissio-go/pkg/config"
 	"github.com/permissio/permissio-go/pkg/models"
@@ -20,96 +22,160 @@ func NewTenantsAPI(cfg *config.Config) *TenantsAPI {
 	}
 }
 
-// List returns a paginated list of tenants.
+// List returns a paginated list of tenants. params may be nil.
 func (a *TenantsAPI) List(ctx context.Context, params *models.TenantListParams) (*models.TenantList, error) {
-	url := a.BuildFactsURL("/tenants")
-
-	if params != nil {
-		queryParams := ListParamsToMap(params.Page, params.PerPage, map[string]string{
-			"search": params.Search,
-		})
-		url = BuildQueryParams(url, queryParams)
+	if params == nil {
+		params = &models.TenantListParams{}
 	}
+	endpoint := a.listURL(a.BuildFactsURL("/tenants"), params.Page, params.PerPage, map[string]string{
+		"search": params.Search,
+	})
 
 	var result models.TenantList
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("tenants.list", err)
 	}
 	return &result, nil
 }
 
+// ListAll returns every tenant matching params, following all pages.
+// params may be nil; its Page is ignored.
+func (a *TenantsAPI) ListAll(ctx context.Context, params *models.TenantListParams) ([]models.TenantRead, error) {
+	query := models.TenantListParams{}
+	if params != nil {
+		query = *params
+	}
+	return NewPaginator(func(ctx context.Context, page, perPage int) ([]models.TenantRead, models.PaginatedResponse, error) {
+		query.Page, query.PerPage = page, perPage
+		result, err := a.List(ctx, &query)
+		if err != nil {
+			return nil, models.PaginatedResponse{}, err
+		}
+		return result.Data, result.PaginatedResponse, nil
+	}, a.pageSize(query.PerPage)).All(ctx)
+}
+
 // Get retrieves a tenant by key.
 func (a *TenantsAPI) Get(ctx context.Context, tenantKey string) (*models.TenantRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/tenants/%s", tenantKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/tenants/%s", url.PathEscape(tenantKey)))
 
 	var result models.TenantRead
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("tenants.get", err)
 	}
 	return &result, nil
 }
 
 // Create creates a new tenant.
+// The error matches ErrConflict if the tenant already exists.
 func (a *TenantsAPI) Create(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, error) {
-	url := a.BuildFactsURL("/tenants")
+	endpoint := a.BuildFactsURL("/tenants")
 
 	var result models.TenantRead
-	if err := a.Post(ctx, url, tenant, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, tenant, &result); err != nil {
+		return nil, wrapErr("tenants.create", err)
 	}
 	return &result, nil
 }
 
 // Update updates an existing tenant.
 func (a *TenantsAPI) Update(ctx context.Context, tenantKey string, data *models.TenantUpdate) (*models.TenantRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/tenants/%s", tenantKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/tenants/%s", url.PathEscape(tenantKey)))
 
 	var result models.TenantRead
-	if err := a.Patch(ctx, url, data, &result); err != nil {
-		return nil, err
+	if err := a.Patch(ctx, endpoint, data, &result); err != nil {
+		return nil, wrapErr("tenants.update", err)
+	}
+	return &result, nil
+}
+
+// CreateRaw creates a tenant from an arbitrary body (json.RawMessage, a map,
+// ...) sent as is, for tenant fields the SDK doesn't model yet.
+func (a *TenantsAPI) CreateRaw(ctx context.Context, body interface{}) (*models.TenantRead, error) {
+	endpoint := a.BuildFactsURL("/tenants")
+
+	var result models.TenantRead
+	if err := a.Post(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("tenants.create_raw", err)
+	}
+	return &result, nil
+}
+
+// UpdateRaw is like Update with a body the SDK doesn't model; see CreateRaw.
+func (a *TenantsAPI) UpdateRaw(ctx context.Context, tenantKey string, body interface{}) (*models.TenantRead, error) {
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/tenants/%s", url.PathEscape(tenantKey)))
+
+	var result models.TenantRead
+	if err := a.Patch(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("tenants.update_raw", err)
 	}
 	return &result, nil
 }
 
 // Delete deletes a tenant.
 func (a *TenantsAPI) Delete(ctx context.Context, tenantKey string) error {
-	url := a.BuildFactsURL(fmt.Sprintf("/tenants/%s", tenantKey))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/tenants/%s", url.PathEscape(tenantKey)))
+	return wrapErr("tenants.delete", a.BaseClient.Delete(ctx, endpoint, nil))
 }
 
 // Sync creates or updates a tenant (upsert).
 func (a *TenantsAPI) Sync(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, error) {
-	url := a.BuildFactsURL("/tenants")
+	result, _, err := a.Upsert(ctx, tenant)
+	return result, err
+}
+
+// Upsert is like Sync but also reports whether the tenant was created
+// rather than updated.
+func (a *TenantsAPI) Upsert(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, bool, error) {
+	endpoint := a.BuildFactsURL("/tenants")
 
 	var result models.TenantRead
-	if err := a.Put(ctx, url, tenant, &result); err != nil {
-		return nil, err
+	created, err := a.upsert(ctx, endpoint, tenant, &result)
+	if err != nil {
+		return nil, false, wrapErr("tenants.sync", err)
 	}
-	return &result, nil
+	return &result, created, nil
 }
 
 // AddUser adds a user to a tenant.
+// The error matches ErrConflict if the user is already a member.
 func (a *TenantsAPI) AddUser(ctx context.Context, tenantKey, userKey string) error {
-	url := a.BuildFactsURL(fmt.Sprintf("/tenants/%s/users", tenantKey))
-	body := map[string]string{"user": userKey}
-	return a.Post(ctx, url, body, nil)
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/tenants/%s/users", url.PathEscape(tenantKey)))
+	body := map[string]string{"user": a.config.APIUserKey(userKey)}
+	return wrapErr("tenants.add_user", a.Post(ctx, endpoint, body, nil))
+}
+
+// AddUserIfAbsent is like AddUser but succeeds if the user is already a
+// member, so reconciliation loops can re-run it safely. It reports whether
+// the user was added.
+func (a *TenantsAPI) AddUserIfAbsent(ctx context.Context, tenantKey, userKey string) (bool, error) {
+	if err := a.AddUser(ctx, tenantKey, userKey); err != nil {
+		if errors.Is(err, ErrConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 // RemoveUser removes a user from a tenant.
 func (a *TenantsAPI) RemoveUser(ctx context.Context, tenantKey, userKey string) error {
-	url := a.BuildFactsURL(fmt.Sprintf("/tenants/%s/users/%s", tenantKey, userKey))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/tenants/%s/users/%s", url.PathEscape(tenantKey), url.PathEscape(a.config.APIUserKey(userKey))))
+	return wrapErr("tenants.remove_user", a.BaseClient.Delete(ctx, endpoint, nil))
 }
 
 // GetUsers returns the users in a tenant.
 func (a *TenantsAPI) GetUsers(ctx context.Context, tenantKey string) ([]string, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/tenants/%s/users", tenantKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/tenants/%s/users", url.PathEscape(tenantKey)))
 
 	var result struct {
 		Users []string `json:"users"`
 	}
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("tenants.get_users", err)
+	}
+	for i, userKey := range result.Users {
+		result.Users[i] = a.config.AppUserKey(userKey)
 	}
 	return result.Users, nil
 }
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// membershipHandler serves tenant membership adds, answering 409 for users
+// already in the tenant.
+func membershipHandler() http.Handler {
+	var mu sync.Mutex
+	members := map[string]bool{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if members[r.URL.Path] {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message":"user is already a member","code":"CONFLICT"}`))
+			return
+		}
+		members[r.URL.Path] = true
+		w.WriteHeader(http.StatusCreated)
+	})
+}
+
+func TestTenantsAddUserIfAbsent(t *testing.T) {
+	tenants := NewTenantsAPI(newTestConfig(t, membershipHandler()))
+	ctx := context.Background()
+
+	for i, wantAdded := range []bool{true, false} {
+		added, err := tenants.AddUserIfAbsent(ctx, "acme", "alice")
+		if err != nil {
+			t.Fatalf("AddUserIfAbsent() #%d error: %v", i+1, err)
+		}
+		if added != wantAdded {
+			t.Errorf("AddUserIfAbsent() #%d added = %v, want %v", i+1, added, wantAdded)
+		}
+	}
+
+	if err := tenants.AddUser(ctx, "acme", "alice"); !errors.Is(err, ErrConflict) {
+		t.Errorf("AddUser() for a member = %v, want ErrConflict", err)
+	}
+}
+
+func TestUsersAddTenantIfAbsent(t *testing.T) {
+	users := NewUsersAPI(newTestConfig(t, membershipHandler()))
+	ctx := context.Background()
+
+	if added, err := users.AddTenantIfAbsent(ctx, "alice", "acme"); err != nil || !added {
+		t.Fatalf("AddTenantIfAbsent() = %v, %v, want added", added, err)
+	}
+	if added, err := users.AddTenantIfAbsent(ctx, "alice", "acme"); err != nil || added {
+		t.Errorf("AddTenantIfAbsent() for a member = %v, %v, want a no-op success", added, err)
+	}
+}
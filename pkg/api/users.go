@@ -2,7 +2,11 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
 
 	"github.com/permissio/permissio-go/pkg/config"
 	"github.com/permissio/permissio-go/pkg/models"
@@ -20,140 +24,330 @@ func NewUsersAPI(cfg *config.Config) *UsersAPI {
 	}
 }
 
-// List returns a paginated list of users.
+// List returns a paginated list of users. params may be nil.
+// It fails if params filter on more than one role; use ListAll instead.
 func (a *UsersAPI) List(ctx context.Context, params *models.UserListParams) (*models.UserList, error) {
-	url := a.BuildFactsURL("/users")
-
-	if params != nil {
-		queryParams := ListParamsToMap(params.Page, params.PerPage, map[string]string{
-			"search": params.Search,
-			"role":   params.Role,
-			"tenant": params.Tenant,
-		})
-		url = BuildQueryParams(url, queryParams)
+	if params == nil {
+		params = &models.UserListParams{}
+	}
+	roles := roleFilter(params)
+	if len(roles) > 1 {
+		return nil, errors.New("users.list: a page can only be filtered on one role; use ListAll for several roles")
+	}
+	role := ""
+	if len(roles) == 1 {
+		role = roles[0]
 	}
+	endpoint := a.listURL(a.BuildFactsURL("/users"), params.Page, params.PerPage, map[string]string{
+		"search": params.Search,
+		"role":   role,
+		"tenant": params.Tenant,
+		"fields": strings.Join(params.Fields, ","),
+	})
 
 	var result models.UserList
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("users.list", err)
+	}
+	for i := range result.Data {
+		result.Data[i].Key = a.config.AppUserKey(result.Data[i].Key)
 	}
 	return &result, nil
 }
 
+// ListAll returns every user matching params, following all pages.
+// params may be nil; its Page is ignored.
+//
+// When params filter on several roles, each role is listed separately and
+// the results are merged: users appear once, in the order first found.
+func (a *UsersAPI) ListAll(ctx context.Context, params *models.UserListParams) ([]models.UserRead, error) {
+	query := models.UserListParams{}
+	if params != nil {
+		query = *params
+	}
+
+	if roles := roleFilter(&query); len(roles) > 1 {
+		seen := make(map[string]struct{})
+		var users []models.UserRead
+		for _, role := range roles {
+			roleQuery := query
+			roleQuery.Role, roleQuery.Roles = role, nil
+			roleUsers, err := a.ListAll(ctx, &roleQuery)
+			if err != nil {
+				return nil, err
+			}
+			for _, user := range roleUsers {
+				if _, ok := seen[user.Key]; !ok {
+					seen[user.Key] = struct{}{}
+					users = append(users, user)
+				}
+			}
+		}
+		return users, nil
+	}
+
+	return NewPaginator(func(ctx context.Context, page, perPage int) ([]models.UserRead, models.PaginatedResponse, error) {
+		query.Page, query.PerPage = page, perPage
+		result, err := a.List(ctx, &query)
+		if err != nil {
+			return nil, models.PaginatedResponse{}, err
+		}
+		return result.Data, result.PaginatedResponse, nil
+	}, a.pageSize(query.PerPage)).All(ctx)
+}
+
+// Count returns the number of users matching params, as reported by the
+// pagination metadata of a single-item page. params may be nil.
+// With several roles, users holding more than one are counted once, which
+// requires listing them all.
+func (a *UsersAPI) Count(ctx context.Context, params *models.UserListParams) (int, error) {
+	query := models.UserListParams{}
+	if params != nil {
+		query = *params
+	}
+
+	if len(roleFilter(&query)) > 1 {
+		users, err := a.ListAll(ctx, &query)
+		if err != nil {
+			return 0, err
+		}
+		return len(users), nil
+	}
+	query.Page, query.PerPage = 1, 1
+
+	result, err := a.List(ctx, &query)
+	if err != nil {
+		return 0, err
+	}
+	return result.Total, nil
+}
+
+// roleFilter returns the roles params filter on, Role first, without duplicates.
+func roleFilter(params *models.UserListParams) []string {
+	var roles []string
+	seen := make(map[string]struct{})
+	for _, role := range append([]string{params.Role}, params.Roles...) {
+		if _, ok := seen[role]; ok || role == "" {
+			continue
+		}
+		seen[role] = struct{}{}
+		roles = append(roles, role)
+	}
+	return roles
+}
+
 // Get retrieves a user by key.
 func (a *UsersAPI) Get(ctx context.Context, userKey string) (*models.UserRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s", userKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s", url.PathEscape(a.config.APIUserKey(userKey))))
 
 	var result models.UserRead
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("users.get", err)
 	}
+	result.Key = a.config.AppUserKey(result.Key)
 	return &result, nil
 }
 
 // Create creates a new user.
+// The error matches ErrConflict if the user already exists.
 func (a *UsersAPI) Create(ctx context.Context, user *models.UserCreate) (*models.UserRead, error) {
-	url := a.BuildFactsURL("/users")
+	endpoint := a.BuildFactsURL("/users")
+
+	body := *user
+	body.Key = a.config.APIUserKey(body.Key)
 
 	var result models.UserRead
-	if err := a.Post(ctx, url, user, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("users.create", err)
 	}
+	result.Key = a.config.AppUserKey(result.Key)
 	return &result, nil
 }
 
 // Update updates an existing user.
 func (a *UsersAPI) Update(ctx context.Context, userKey string, data *models.UserUpdate) (*models.UserRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s", userKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s", url.PathEscape(a.config.APIUserKey(userKey))))
+
+	var result models.UserRead
+	if err := a.Patch(ctx, endpoint, data, &result); err != nil {
+		return nil, wrapErr("users.update", err)
+	}
+	result.Key = a.config.AppUserKey(result.Key)
+	return &result, nil
+}
+
+// CreateRaw creates a user from a body the SDK doesn't model, such as a
+// json.RawMessage or a map[string]interface{}, e.g. to set fields added to
+// the API after this SDK version. The body is sent as is: it is not
+// validated and the user key transform is not applied to it.
+func (a *UsersAPI) CreateRaw(ctx context.Context, body interface{}) (*models.UserRead, error) {
+	endpoint := a.BuildFactsURL("/users")
 
 	var result models.UserRead
-	if err := a.Patch(ctx, url, data, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("users.create_raw", err)
 	}
+	result.Key = a.config.AppUserKey(result.Key)
+	return &result, nil
+}
+
+// UpdateRaw is like Update with a body the SDK doesn't model; see CreateRaw.
+func (a *UsersAPI) UpdateRaw(ctx context.Context, userKey string, body interface{}) (*models.UserRead, error) {
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s", url.PathEscape(a.config.APIUserKey(userKey))))
+
+	var result models.UserRead
+	if err := a.Patch(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("users.update_raw", err)
+	}
+	result.Key = a.config.AppUserKey(result.Key)
 	return &result, nil
 }
 
 // Delete deletes a user.
 func (a *UsersAPI) Delete(ctx context.Context, userKey string) error {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s", userKey))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s", url.PathEscape(a.config.APIUserKey(userKey))))
+	return wrapErr("users.delete", a.BaseClient.Delete(ctx, endpoint, nil))
+}
+
+// BulkDeleteConcurrency is the number of users BulkDelete deletes in parallel.
+const BulkDeleteConcurrency = 8
+
+// BulkDelete deletes multiple users, up to BulkDeleteConcurrency at a time.
+// Users that no longer exist count as deleted. Failures do not stop the other
+// deletions; the response reports the outcome for each key, in input order.
+// If ctx is cancelled, the response is returned along with ctx's error.
+func (a *UsersAPI) BulkDelete(ctx context.Context, keys []string) (*models.BulkUserResponse, error) {
+	results := make([]models.BulkUserResult, len(keys))
+	sem := make(chan struct{}, BulkDeleteConcurrency)
+	var wg sync.WaitGroup
+
+	for i, key := range keys {
+		results[i].Key = key
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(result *models.BulkUserResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := a.Delete(ctx, result.Key); err != nil && !isNotFound(err) {
+				result.Error = err.Error()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	response := &models.BulkUserResponse{Results: results}
+	for _, result := range results {
+		if result.Error != "" {
+			response.Failed++
+		} else {
+			response.Deleted++
+		}
+	}
+	return response, ctx.Err()
 }
 
 // SyncUser creates or updates a user (upsert).
 // Uses PUT to replace/create the user with the given key.
 func (a *UsersAPI) SyncUser(ctx context.Context, user models.UserCreate) (*models.UserRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s", user.Key))
+	result, _, err := a.Upsert(ctx, user)
+	return result, err
+}
+
+// Upsert is like SyncUser but also reports whether the user was created
+// rather than updated.
+func (a *UsersAPI) Upsert(ctx context.Context, user models.UserCreate) (*models.UserRead, bool, error) {
+	user.Key = a.config.APIUserKey(user.Key)
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s", url.PathEscape(user.Key)))
 
 	var result models.UserRead
-	if err := a.Put(ctx, url, user, &result); err != nil {
-		return nil, err
+	created, err := a.upsert(ctx, endpoint, user, &result)
+	if err != nil {
+		return nil, false, wrapErr("users.sync_user", err)
 	}
-	return &result, nil
+	result.Key = a.config.AppUserKey(result.Key)
+	return &result, created, nil
 }
 
 // AssignRole assigns a role to a user.
 func (a *UsersAPI) AssignRole(ctx context.Context, userKey, role, tenant string) (*models.RoleAssignmentRead, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s/roles", userKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s/roles", url.PathEscape(a.config.APIUserKey(userKey))))
 
 	body := map[string]string{
 		"role":   role,
-		"tenant": tenant,
+		"tenant": a.config.TenantOrDefault(tenant),
 	}
 
 	var result models.RoleAssignmentRead
-	if err := a.Post(ctx, url, body, &result); err != nil {
-		return nil, err
+	if err := a.Post(ctx, endpoint, body, &result); err != nil {
+		return nil, wrapErr("users.assign_role", err)
 	}
+	result.User = a.config.AppUserKey(result.User)
 	return &result, nil
 }
 
 // UnassignRole removes a role from a user.
 func (a *UsersAPI) UnassignRole(ctx context.Context, userKey, role, tenant string) error {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s/roles/%s", userKey, role))
-	if tenant != "" {
-		url = BuildQueryParams(url, map[string]string{"tenant": tenant})
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s/roles/%s", url.PathEscape(a.config.APIUserKey(userKey)), url.PathEscape(role)))
+	if tenant = a.config.TenantOrDefault(tenant); tenant != "" {
+		endpoint = BuildQueryParams(endpoint, map[string]string{"tenant": tenant})
 	}
-	return a.BaseClient.Delete(ctx, url, nil)
+	return wrapErr("users.unassign_role", a.BaseClient.Delete(ctx, endpoint, nil))
 }
 
 // GetRoles returns the roles assigned to a user.
 func (a *UsersAPI) GetRoles(ctx context.Context, userKey string, tenant string) ([]string, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s/roles", userKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s/roles", url.PathEscape(a.config.APIUserKey(userKey))))
 	if tenant != "" {
-		url = BuildQueryParams(url, map[string]string{"tenant": tenant})
+		endpoint = BuildQueryParams(endpoint, map[string]string{"tenant": tenant})
 	}
 
 	var result struct {
 		Roles []string `json:"roles"`
 	}
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("users.get_roles", err)
 	}
 	return result.Roles, nil
 }
 
 // AddTenant adds a user to a tenant.
+// The error matches ErrConflict if the user is already a member.
 func (a *UsersAPI) AddTenant(ctx context.Context, userKey, tenantKey string) error {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s/tenants", userKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s/tenants", url.PathEscape(a.config.APIUserKey(userKey))))
 	body := map[string]string{"tenant": tenantKey}
-	return a.Post(ctx, url, body, nil)
+	return wrapErr("users.add_tenant", a.Post(ctx, endpoint, body, nil))
+}
+
+// AddTenantIfAbsent is like AddTenant but succeeds if the user is already a
+// member of the tenant. It reports whether the user was added.
+func (a *UsersAPI) AddTenantIfAbsent(ctx context.Context, userKey, tenantKey string) (bool, error) {
+	if err := a.AddTenant(ctx, userKey, tenantKey); err != nil {
+		if errors.Is(err, ErrConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 // RemoveTenant removes a user from a tenant.
 func (a *UsersAPI) RemoveTenant(ctx context.Context, userKey, tenantKey string) error {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s/tenants/%s", userKey, tenantKey))
-	return a.BaseClient.Delete(ctx, url, nil)
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s/tenants/%s", url.PathEscape(a.config.APIUserKey(userKey)), url.PathEscape(tenantKey)))
+	return wrapErr("users.remove_tenant", a.BaseClient.Delete(ctx, endpoint, nil))
 }
 
 // GetTenants returns the tenants a user belongs to.
 func (a *UsersAPI) GetTenants(ctx context.Context, userKey string) ([]string, error) {
-	url := a.BuildFactsURL(fmt.Sprintf("/users/%s/tenants", userKey))
+	endpoint := a.BuildFactsURL(fmt.Sprintf("/users/%s/tenants", url.PathEscape(a.config.APIUserKey(userKey))))
 
 	var result struct {
 		Tenants []string `json:"tenants"`
 	}
-	if err := a.BaseClient.Get(ctx, url, &result); err != nil {
-		return nil, err
+	if err := a.BaseClient.Get(ctx, endpoint, &result); err != nil {
+		return nil, wrapErr("users.get_tenants", err)
 	}
 	return result.Tenants, nil
 }
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+func TestUsersCountRequestsSingleItemPage(t *testing.T) {
+	var perPage string
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		perPage = r.URL.Query().Get("perPage")
+		_ = json.NewEncoder(w).Encode(models.UserList{
+			Data:              []models.UserRead{{Key: "alice"}},
+			PaginatedResponse: models.PaginatedResponse{Page: 1, PerPage: 1, Total: 42, TotalPages: 42},
+		})
+	}))
+
+	count, err := NewUsersAPI(cfg).Count(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Count() error: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("Count() = %d, want 42", count)
+	}
+	if perPage != "1" {
+		t.Errorf("perPage = %q, want %q", perPage, "1")
+	}
+}
+
+func TestUsersListRequestsFieldProjection(t *testing.T) {
+	var fields string
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = r.URL.Query().Get("fields")
+		_, _ = w.Write([]byte(`{"data":[{"key":"alice","email":"alice@example.com"}],"page":1,"totalPages":1,"total":1}`))
+	}))
+
+	list, err := NewUsersAPI(cfg).List(context.Background(), &models.UserListParams{Fields: []string{"key", "email"}})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if fields != "key,email" {
+		t.Errorf("fields = %q, want %q", fields, "key,email")
+	}
+	if user := list.Data[0]; user.Key != "alice" || user.Email != "alice@example.com" || user.FirstName != "" {
+		t.Errorf("List() user = %+v, want key and email only", user)
+	}
+}
+
+func TestUsersBulkDelete(t *testing.T) {
+	var mu sync.Mutex
+	existing := map[string]bool{"alice": true, "bob": true}
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/v1/facts/proj/env/users/")
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case key == "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case existing[key]:
+			delete(existing, key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	keys := []string{"alice", "gone", "bob", "broken"}
+	response, err := NewUsersAPI(cfg).BulkDelete(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("BulkDelete() error: %v", err)
+	}
+	if response.Deleted != 3 || response.Failed != 1 {
+		t.Errorf("deleted = %d, failed = %d, want 3 and 1", response.Deleted, response.Failed)
+	}
+	for i, result := range response.Results {
+		if result.Key != keys[i] {
+			t.Errorf("results[%d].Key = %q, want %q", i, result.Key, keys[i])
+		}
+		if failed := result.Error != ""; failed != (result.Key == "broken") {
+			t.Errorf("%s: error = %q", result.Key, result.Error)
+		}
+	}
+	if len(existing) != 0 {
+		t.Errorf("users not deleted: %v", existing)
+	}
+}
+
+func TestUsersListAllWithMultipleRoles(t *testing.T) {
+	byRole := map[string][]models.UserRead{
+		"admin": {{Key: "alice"}, {Key: "bob"}},
+		"owner": {{Key: "bob"}, {Key: "carol"}},
+	}
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		users := byRole[r.URL.Query().Get("role")]
+		_ = json.NewEncoder(w).Encode(models.UserList{
+			Data:              users,
+			PaginatedResponse: models.PaginatedResponse{Page: 1, Total: len(users), TotalPages: 1},
+		})
+	}))
+	usersAPI := NewUsersAPI(cfg)
+	params := &models.UserListParams{Roles: []string{"admin", "owner"}}
+
+	users, err := usersAPI.ListAll(context.Background(), params)
+	if err != nil {
+		t.Fatalf("ListAll() error: %v", err)
+	}
+	var keys []string
+	for _, user := range users {
+		keys = append(keys, user.Key)
+	}
+	if strings.Join(keys, ",") != "alice,bob,carol" {
+		t.Errorf("ListAll() keys = %v, want alice,bob,carol", keys)
+	}
+
+	if count, err := usersAPI.Count(context.Background(), params); err != nil || count != 3 {
+		t.Errorf("Count() = %d, %v, want 3", count, err)
+	}
+	if _, err := usersAPI.List(context.Background(), params); err == nil {
+		t.Error("expected List to reject several roles")
+	}
+}
+
+func TestUsersCreateRawSendsBodyAsIs(t *testing.T) {
+	var received map[string]interface{}
+	cfg := newTestConfig(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_ = json.NewEncoder(w).Encode(models.UserRead{Key: "alice", Email: "alice@example.com"})
+	}))
+
+	body := json.RawMessage(`{"key":"alice","email":"alice@example.com","new_field":true}`)
+	user, err := NewUsersAPI(cfg).CreateRaw(context.Background(), body)
+	if err != nil {
+		t.Fatalf("CreateRaw() error: %v", err)
+	}
+	if received["new_field"] != true {
+		t.Errorf("body received = %v, want new_field passed through", received)
+	}
+	if user.Key != "alice" || user.Email != "alice@example.com" {
+		t.Errorf("CreateRaw() = %+v", user)
+	}
+}
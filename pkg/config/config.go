@@ -2,11 +2,17 @@
 package config
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
+	"log"
 	"net/http"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/permissio/permissio-go/pkg/models"
 	"go.uber.org/zap"
 )
 
@@ -22,77 +28,465 @@ const (
 
 	// APIKeyPrefix is the expected prefix for API keys.
 	APIKeyPrefix = "permis_key_"
+
+	// DefaultAPIVersion is the default API version path segment.
+	DefaultAPIVersion = "v1"
+
+	// DefaultAuthHeader is the default header carrying the API key.
+	DefaultAuthHeader = "Authorization"
+
+	// DefaultAuthTemplate is the default value template for the auth header.
+	DefaultAuthTemplate = "Bearer %s"
+
+	// DefaultCheckTimeout bounds Client.Check, which takes no context, when
+	// no check timeout is configured.
+	DefaultCheckTimeout = 10 * time.Second
+
+	// DefaultBulkConcurrency is the default number of checks a bulk check
+	// runs in parallel.
+	DefaultBulkConcurrency = 8
+
+	// DefaultMaxInheritanceDepth is the default limit on role inheritance depth.
+	DefaultMaxInheritanceDepth = 32
 )
 
+// apiVersionPattern matches valid API version segments such as "v1", "v2" or "v2beta1".
+var apiVersionPattern = regexp.MustCompile(`^v[0-9]+([a-z]+[0-9]*)?$`)
+
+// Metrics receives SDK metrics so they can be exported to a monitoring
+// system without the SDK depending on one. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	// IncCounter increments the named counter.
+	IncCounter(name string, labels map[string]string)
+
+	// ObserveHistogram records a value in the named histogram.
+	ObserveHistogram(name string, value float64, labels map[string]string)
+}
+
+// PolicyDecisionPoint evaluates permission checks outside the SDK, e.g. on a
+// policy engine run by the application, for grants the client-side evaluator
+// can't see (ABAC, relationships). Implementations must be safe for
+// concurrent use.
+type PolicyDecisionPoint interface {
+	// Check decides request. Request.User is an enforcement.User keyed with
+	// the API user key, Request.Resource an enforcement.Resource, and
+	// Request.Context the check context attached with
+	// enforcement.WithCheckContext, if any. The returned Reason and Debug
+	// (e.g. PolicyRules and DecisionID) are passed on to callers.
+	Check(ctx context.Context, request models.CheckRequest) (*models.CheckResponse, error)
+}
+
+// CacheEventKind is the kind of a CacheEvent.
+type CacheEventKind string
+
+// Cache event kinds.
+const (
+	// CacheHit is a lookup served from the cache.
+	CacheHit CacheEventKind = "hit"
+
+	// CacheMiss is a lookup that had to fetch from the API, because the entry
+	// was missing or expired.
+	CacheMiss CacheEventKind = "miss"
+
+	// CacheEviction is an expired entry removed from the cache.
+	CacheEviction CacheEventKind = "eviction"
+)
+
+// CacheEvent reports a lookup in, or an eviction from, the check cache
+// enabled with WithCacheTTL.
+type CacheEvent struct {
+	Kind CacheEventKind
+
+	// Cache is the cache concerned: "roles" or "assignments".
+	Cache string
+}
+
 // Config represents the SDK configuration.
 type Config struct {
-	// Token is the API key for authentication (required).
+	// Token is the API key for authentication (required). Once the config is
+	// in use, read and replace it with CurrentToken and SetToken.
 	Token string
 
+	// TokenRefresher, if set, is called when a request fails with 401
+	// Unauthorized to obtain a fresh token, e.g. after a key rotation. The
+	// token is stored with SetToken and the request is retried once.
+	TokenRefresher func(ctx context.Context) (string, error)
+
+	// KeyPrefix is the prefix Validate requires API keys to start with.
+	// An empty value is treated as APIKeyPrefix.
+	KeyPrefix string
+
+	// SkipKeyPrefixCheck disables the API key prefix check in Validate,
+	// for self-hosted or proxied deployments with differently-prefixed keys.
+	SkipKeyPrefixCheck bool
+
 	// ApiURL is the base URL for the Permissio.io API.
 	ApiURL string
 
+	// ReadURL is the optional base URL of a read replica. When set, GET
+	// requests go to it while writes go to ApiURL. Replicas are eventually
+	// consistent: a read right after a write (e.g. a check after assigning a
+	// role) may not see the write yet.
+	ReadURL string
+
+	// APIVersion is the API version path segment (e.g. "v1").
+	// An empty value is treated as DefaultAPIVersion.
+	APIVersion string
+
+	// PDP is an optional policy decision point. When set, permission checks
+	// are evaluated by it instead of client-side.
+	PDP PolicyDecisionPoint
+
+	// HybridCheck evaluates checks locally first and only asks the PDP to
+	// confirm denies. Allows stay low-latency; denies pay a PDP round trip but
+	// pick up grants only the PDP can evaluate. Requires PDP.
+	HybridCheck bool
+
+	// ClientABAC evaluates role assignment attributes as conditions in
+	// client-side checks. Without it, conditional assignments apply unconditionally
+	// locally and their conditions are only honored by a backend that supports them.
+	ClientABAC bool
+
+	// TenantValidation makes client-side checks that find no role assignments
+	// in a tenant verify that the tenant exists, so a mistyped tenant is
+	// reported as not found instead of as a missing assignment.
+	TenantValidation bool
+
+	// MaxInheritanceDepth caps how many levels of Extends are followed when
+	// resolving a role's permissions in client-side checks. Permissions of
+	// deeper ancestors are not inherited. Zero means no limit.
+	MaxInheritanceDepth int
+
+	// BulkConcurrency is the number of checks BulkCheck and BulkCheckStream
+	// run in parallel. Zero means DefaultBulkConcurrency.
+	BulkConcurrency int
+
+	// CacheTTL is how long client-side checks keep the roles and each user's
+	// role assignments in memory instead of fetching them for every check.
+	// Zero disables the cache. Client.InvalidateCache flushes it.
+	CacheTTL time.Duration
+
+	// CacheObserver, if set, is called synchronously for every hit, miss and
+	// eviction of the check cache, e.g. to export hit rates. It must be fast
+	// and safe for concurrent use.
+	CacheObserver func(event CacheEvent)
+
+	// DefaultPageSize is the page size requested by List methods, and by
+	// auto-paginating methods such as ListAll, when params are nil or leave
+	// PerPage at zero. Zero leaves the size of a single page to the server.
+	DefaultPageSize int
+
+	// DefaultTenant is substituted for an empty tenant in checks, permission
+	// lookups, role assignment helpers and resource instance creation, so an
+	// instance created without a tenant is found by checks that omit one too.
+	// An explicit tenant always wins.
+	// When empty, an empty tenant means unscoped.
+	DefaultTenant string
+
+	// UserKeyTransform maps application user keys to Permissio.io user keys
+	// wherever a user key is sent to the API, including permission checks.
+	// UserKeyInverse maps user keys read back from responses to application
+	// keys. They are set together; nil means user keys are used as is.
+	UserKeyTransform func(string) string
+	UserKeyInverse   func(string) string
+
 	// ProjectID is the project identifier.
 	ProjectID string
 
 	// EnvironmentID is the environment identifier.
 	EnvironmentID string
 
+	// ProjectSlug and EnvironmentSlug name the scope by slug (e.g. "default"
+	// for a project's default environment) instead of by ID. They are
+	// resolved to IDs once, on first use; explicit IDs take precedence.
+	ProjectSlug     string
+	EnvironmentSlug string
+
+	// DisableAutoScope prevents the SDK from ever calling the API key scope
+	// endpoint; ProjectID and EnvironmentID must be configured explicitly.
+	DisableAutoScope bool
+
+	// ScopeRefreshInterval re-fetches an auto-fetched scope periodically (with
+	// jitter) and updates it if the API key's project or environment changed.
+	// Zero disables the refresh.
+	ScopeRefreshInterval time.Duration
+
+	// StrictScope makes API requests fail with api.ErrMissingScope while the
+	// project and environment are unknown, instead of silently falling back to
+	// unscoped URLs. Init (or an explicit scope) must succeed first.
+	StrictScope bool
+
 	// Timeout is the request timeout duration.
 	Timeout time.Duration
 
+	// CheckTimeout bounds each permission check (Check, CheckWithDetails and
+	// every check in BulkCheck), independently of Timeout, so authorization
+	// fails fast while admin operations keep the longer limit. A shorter
+	// deadline already set on the caller's context wins. Zero disables it,
+	// except for Check, which then uses DefaultCheckTimeout.
+	CheckTimeout time.Duration
+
 	// Debug enables debug logging.
 	Debug bool
 
 	// RetryAttempts is the number of retry attempts for failed requests.
+	// A count set on a request's context with enforcement.WithRetries
+	// overrides it for that request.
 	RetryAttempts int
 
+	// RetryWrites enables retries of non-idempotent methods (POST, PATCH).
+	// By default they are only retried when an Idempotency-Key header is set.
+	RetryWrites bool
+
 	// ThrowOnError determines if errors should cause panics (default: false).
 	ThrowOnError bool
 
-	// CustomHeaders are additional headers to include in requests.
+	// ValidatePermissions rejects role permissions not in "resourceType:action"
+	// format when roles are created or updated.
+	ValidatePermissions bool
+
+	// DeniedPermissions are permission patterns (e.g. "*:delete") that are
+	// denied for everyone regardless of role configuration.
+	DeniedPermissions []string
+
+	// AuthHeaderName is the header carrying the API key.
+	// An empty value is treated as DefaultAuthHeader.
+	AuthHeaderName string
+
+	// AuthHeaderTemplate is the auth header value, with a single "%s"
+	// replaced by the API key. An empty value is treated as DefaultAuthTemplate.
+	AuthHeaderTemplate string
+
+	// CustomHeaders are additional headers to include in requests. Headers set
+	// for a single request with enforcement.WithHeaders override them.
 	CustomHeaders map[string]string
 
+	// Metrics is the optional metrics sink. Nothing is recorded when nil.
+	Metrics Metrics
+
 	// Logger is the optional zap logger for debug output.
 	Logger *zap.Logger
 
 	// HTTPClient is the optional custom HTTP client.
 	HTTPClient *http.Client
+
+	// builtHTTPClient is the HTTP client created by Build, if any, so that
+	// clients can tell it apart from one supplied by the application.
+	builtHTTPClient *http.Client
+
+	// InsecureSkipVerify disables TLS certificate verification on the SDK-built
+	// HTTP client. It is ignored when a custom HTTPClient is supplied.
+	//
+	// SECURITY: this makes connections vulnerable to man-in-the-middle attacks
+	// and must only be used against local development servers.
+	InsecureSkipVerify bool
+
+	// locks guards the fields updated while the config is in use. It is set
+	// by NewConfigBuilder, Merge and Clone, and held by pointer so that two
+	// copies of a config never share fields guarded by different locks.
+	locks *configLocks
+}
+
+// configLocks guards the fields of a Config updated while it is in use.
+type configLocks struct {
+	// scope guards ProjectID and EnvironmentID against concurrent updates by
+	// UpdateScope, e.g. from a background scope refresh.
+	scope sync.RWMutex
+
+	// token guards Token against concurrent replacement by SetToken, e.g.
+	// from a token refresh.
+	token sync.RWMutex
+}
+
+// literalLocks are the locks of Configs created as struct literals, which
+// have none of their own.
+var literalLocks configLocks
+
+// lock returns the locks of the config.
+func (c *Config) lock() *configLocks {
+	if c.locks == nil {
+		return &literalLocks
+	}
+	return c.locks
+}
+
+// CurrentToken returns the API key requests are authenticated with.
+func (c *Config) CurrentToken() string {
+	locks := c.lock()
+	locks.token.RLock()
+	defer locks.token.RUnlock()
+	return c.Token
+}
+
+// SetToken replaces the API key for subsequent requests. It is safe to call
+// while requests are in flight.
+func (c *Config) SetToken(token string) {
+	locks := c.lock()
+	locks.token.Lock()
+	defer locks.token.Unlock()
+	c.Token = token
 }
 
 // HasScope returns true if both ProjectID and EnvironmentID are set.
 func (c *Config) HasScope() bool {
-	return c.ProjectID != "" && c.EnvironmentID != ""
+	projectID, environmentID := c.Scope()
+	return projectID != "" && environmentID != ""
+}
+
+// Scope returns the ProjectID and EnvironmentID as a consistent pair.
+func (c *Config) Scope() (projectID, environmentID string) {
+	locks := c.lock()
+	locks.scope.RLock()
+	defer locks.scope.RUnlock()
+	return c.ProjectID, c.EnvironmentID
+}
+
+// Version returns the API version path segment, falling back to DefaultAPIVersion.
+func (c *Config) Version() string {
+	if c.APIVersion == "" {
+		return DefaultAPIVersion
+	}
+	return c.APIVersion
+}
+
+// AuthHeader returns the header name and value used to authenticate requests.
+func (c *Config) AuthHeader() (name, value string) {
+	name, template := c.AuthHeaderName, c.AuthHeaderTemplate
+	if name == "" {
+		name = DefaultAuthHeader
+	}
+	if template == "" {
+		template = DefaultAuthTemplate
+	}
+	return name, strings.Replace(template, "%s", c.CurrentToken(), 1)
+}
+
+// TenantOrDefault returns tenant, or DefaultTenant if tenant is empty.
+func (c *Config) TenantOrDefault(tenant string) string {
+	if tenant == "" {
+		return c.DefaultTenant
+	}
+	return tenant
+}
+
+// APIUserKey returns the user key sent to the API for the application user
+// key, after UserKeyTransform. An empty key stays empty.
+func (c *Config) APIUserKey(key string) string {
+	if key == "" || c.UserKeyTransform == nil {
+		return key
+	}
+	return c.UserKeyTransform(key)
+}
+
+// AppUserKey returns the application user key for a user key read from the
+// API, after UserKeyInverse. An empty key stays empty.
+func (c *Config) AppUserKey(key string) string {
+	if key == "" || c.UserKeyInverse == nil {
+		return key
+	}
+	return c.UserKeyInverse(key)
+}
+
+// UsePDP returns true if permission checks should be evaluated by the PDP.
+func (c *Config) UsePDP() bool {
+	return c.PDP != nil
 }
 
-// UpdateScope updates the ProjectID and EnvironmentID.
+// UpdateScope atomically updates the ProjectID and EnvironmentID.
 func (c *Config) UpdateScope(projectID, environmentID string) {
+	locks := c.lock()
+	locks.scope.Lock()
+	defer locks.scope.Unlock()
 	c.ProjectID = projectID
 	c.EnvironmentID = environmentID
 }
 
+// Clone returns a copy of the config with locks of its own, reading Token and
+// the scope under the config's locks so the copy is consistent while SetToken
+// or UpdateScope run. Copy a Config in use through Clone rather than by
+// dereferencing it.
+func (c *Config) Clone() *Config {
+	locks := c.lock()
+	locks.token.RLock()
+	locks.scope.RLock()
+	clone := *c
+	locks.scope.RUnlock()
+	locks.token.RUnlock()
+
+	clone.locks = &configLocks{}
+	return &clone
+}
+
 // Validate validates the configuration.
 func (c *Config) Validate() error {
-	if c.Token == "" {
+	token := c.CurrentToken()
+	if token == "" {
 		return errors.New("API token is required")
 	}
 
-	if !strings.HasPrefix(c.Token, APIKeyPrefix) {
-		return errors.New("invalid API key format: must start with '" + APIKeyPrefix + "'")
+	if !c.SkipKeyPrefixCheck {
+		prefix := c.KeyPrefix
+		if prefix == "" {
+			prefix = APIKeyPrefix
+		}
+		if !strings.HasPrefix(token, prefix) {
+			return errors.New("invalid API key format: must start with '" + prefix + "'")
+		}
 	}
 
 	if c.ApiURL == "" {
 		return errors.New("API URL is required")
 	}
 
+	if c.EnvironmentSlug != "" && c.ProjectSlug == "" {
+		return errors.New("project slug is required with an environment slug")
+	}
+
+	if c.DisableAutoScope && !c.HasScope() && c.EnvironmentSlug == "" {
+		return errors.New("project and environment IDs are required when auto scope is disabled")
+	}
+
+	if !apiVersionPattern.MatchString(c.Version()) {
+		return errors.New("invalid API version: must look like 'v1', 'v2' or 'v2beta1'")
+	}
+
+	if c.AuthHeaderTemplate != "" && strings.Count(c.AuthHeaderTemplate, "%s") != 1 {
+		return errors.New("invalid auth header template: must contain exactly one '%s'")
+	}
+
 	if c.Timeout <= 0 {
 		return errors.New("timeout must be positive")
 	}
 
+	if c.CheckTimeout < 0 {
+		return errors.New("check timeout must be non-negative")
+	}
+
 	if c.RetryAttempts < 0 {
 		return errors.New("retry attempts must be non-negative")
 	}
 
+	if c.BulkConcurrency < 0 {
+		return errors.New("bulk concurrency must be non-negative")
+	}
+
+	if c.CacheTTL < 0 {
+		return errors.New("cache TTL must be non-negative")
+	}
+
+	if c.DefaultPageSize < 0 {
+		return errors.New("default page size must be non-negative")
+	}
+
+	if c.MaxInheritanceDepth < 0 {
+		return errors.New("max inheritance depth must be non-negative")
+	}
+
+	if (c.UserKeyTransform == nil) != (c.UserKeyInverse == nil) {
+		return errors.New("user key transform and its inverse must be set together")
+	}
+
 	return nil
 }
 
@@ -105,13 +499,17 @@ type ConfigBuilder struct {
 func NewConfigBuilder(token string) *ConfigBuilder {
 	return &ConfigBuilder{
 		config: &Config{
-			Token:         token,
-			ApiURL:        DefaultAPIURL,
-			Timeout:       DefaultTimeout,
-			RetryAttempts: DefaultRetryAttempts,
-			Debug:         false,
-			ThrowOnError:  false,
-			CustomHeaders: make(map[string]string),
+			Token:               token,
+			ApiURL:              DefaultAPIURL,
+			APIVersion:          DefaultAPIVersion,
+			Timeout:             DefaultTimeout,
+			RetryAttempts:       DefaultRetryAttempts,
+			MaxInheritanceDepth: DefaultMaxInheritanceDepth,
+			BulkConcurrency:     DefaultBulkConcurrency,
+			Debug:               false,
+			ThrowOnError:        false,
+			CustomHeaders:       make(map[string]string),
+			locks:               &configLocks{},
 		},
 	}
 }
@@ -122,6 +520,123 @@ func (b *ConfigBuilder) WithApiUrl(url string) *ConfigBuilder {
 	return b
 }
 
+// WithReadURL routes GET requests to a read replica at url, while writes
+// keep using the API URL. Reads may briefly lag behind writes.
+func (b *ConfigBuilder) WithReadURL(url string) *ConfigBuilder {
+	b.config.ReadURL = url
+	return b
+}
+
+// WithAPIVersion sets the API version path segment (e.g. "v2").
+func (b *ConfigBuilder) WithAPIVersion(version string) *ConfigBuilder {
+	b.config.APIVersion = version
+	return b
+}
+
+// WithPDP sets the policy decision point that evaluates permission checks
+// instead of the client-side evaluator.
+func (b *ConfigBuilder) WithPDP(pdp PolicyDecisionPoint) *ConfigBuilder {
+	b.config.PDP = pdp
+	return b
+}
+
+// WithHybridCheck evaluates checks locally and confirms only denies with the
+// PDP configured via WithPDP. A PDP error keeps the local deny.
+func (b *ConfigBuilder) WithHybridCheck(enabled bool) *ConfigBuilder {
+	b.config.HybridCheck = enabled
+	return b
+}
+
+// WithClientABAC enables evaluating role assignment conditions in
+// client-side checks.
+func (b *ConfigBuilder) WithClientABAC(enabled bool) *ConfigBuilder {
+	b.config.ClientABAC = enabled
+	return b
+}
+
+// WithTenantValidation makes checks that find no role assignments in the
+// check's tenant look the tenant up, and deny with a "tenant not found" reason
+// if it doesn't exist. Existing tenants are cached for 10 minutes, so the
+// lookup only costs a request the first time in that period.
+func (b *ConfigBuilder) WithTenantValidation(enabled bool) *ConfigBuilder {
+	b.config.TenantValidation = enabled
+	return b
+}
+
+// WithMaxInheritanceDepth caps the depth of role inheritance followed in
+// client-side checks (default 32), guarding the check path against
+// pathologically deep role hierarchies. A warning is logged when a role's
+// hierarchy is cut short. Zero removes the limit.
+func (b *ConfigBuilder) WithMaxInheritanceDepth(depth int) *ConfigBuilder {
+	b.config.MaxInheritanceDepth = depth
+	return b
+}
+
+// WithBulkConcurrency sets how many checks of a bulk check run in parallel.
+// Use 1 to run them sequentially.
+func (b *ConfigBuilder) WithBulkConcurrency(n int) *ConfigBuilder {
+	b.config.BulkConcurrency = n
+	return b
+}
+
+// WithCacheTTL caches the roles and role assignments fetched by client-side
+// checks for ttl, trading freshness for fewer API calls: changes made through
+// other clients are seen once the TTL expires. Zero disables the cache.
+func (b *ConfigBuilder) WithCacheTTL(ttl time.Duration) *ConfigBuilder {
+	b.config.CacheTTL = ttl
+	return b
+}
+
+// WithCacheObserver sets a callback receiving the hits, misses and evictions
+// of the cache enabled with WithCacheTTL, to wire cache metrics into a
+// monitoring system. Client.CacheStats reports the same counts.
+func (b *ConfigBuilder) WithCacheObserver(observer func(event CacheEvent)) *ConfigBuilder {
+	b.config.CacheObserver = observer
+	return b
+}
+
+// WithDefaultPageSize sets the page size used by list calls that don't
+// specify one, including calls with nil params.
+func (b *ConfigBuilder) WithDefaultPageSize(perPage int) *ConfigBuilder {
+	b.config.DefaultPageSize = perPage
+	return b
+}
+
+// WithDefaultTenant sets the tenant used when a check, role assignment or
+// resource instance doesn't specify one (e.g. "default").
+func (b *ConfigBuilder) WithDefaultTenant(tenant string) *ConfigBuilder {
+	b.config.DefaultTenant = tenant
+	return b
+}
+
+// WithUserKeyTransform maps user keys between the application and
+// Permissio.io, e.g. adding an "auth0|" prefix. transform applies to every
+// user key sent to the API or used in a check; inverse restores the
+// application key in responses. It affects all user-keyed operations.
+func (b *ConfigBuilder) WithUserKeyTransform(transform, inverse func(string) string) *ConfigBuilder {
+	b.config.UserKeyTransform = transform
+	b.config.UserKeyInverse = inverse
+	return b
+}
+
+// WithTokenRefresher sets a function returning a fresh API key, called when
+// a request is rejected with 401 Unauthorized. The request is retried once
+// with the new key; if refreshing fails or the retry is rejected too, the
+// 401 error is returned. Use it with short-lived or rotated keys.
+func (b *ConfigBuilder) WithTokenRefresher(refresher func(ctx context.Context) (string, error)) *ConfigBuilder {
+	b.config.TokenRefresher = refresher
+	return b
+}
+
+// WithEnvironmentSlug targets an environment by project and environment slug
+// instead of by ID, e.g. WithEnvironmentSlug("my-app", "default"). The slugs
+// are resolved to IDs on first use (or Init) and the result is kept.
+func (b *ConfigBuilder) WithEnvironmentSlug(projectSlug, environmentSlug string) *ConfigBuilder {
+	b.config.ProjectSlug = projectSlug
+	b.config.EnvironmentSlug = environmentSlug
+	return b
+}
+
 // WithProjectID sets the project ID.
 func (b *ConfigBuilder) WithProjectID(projectID string) *ConfigBuilder {
 	b.config.ProjectID = projectID
@@ -134,12 +649,41 @@ func (b *ConfigBuilder) WithEnvironmentID(environmentID string) *ConfigBuilder {
 	return b
 }
 
+// WithoutAutoScope disables fetching the project and environment from the
+// API key scope endpoint, for environments where it is unreachable. The scope
+// must then be set with WithProjectID and WithEnvironmentID.
+func (b *ConfigBuilder) WithoutAutoScope() *ConfigBuilder {
+	b.config.DisableAutoScope = true
+	return b
+}
+
+// WithScopeRefreshInterval periodically re-fetches the auto-fetched scope in
+// the background, picking up a rotated project or environment in long-lived
+// processes. The refresh stops when the client is closed.
+func (b *ConfigBuilder) WithScopeRefreshInterval(interval time.Duration) *ConfigBuilder {
+	b.config.ScopeRefreshInterval = interval
+	return b
+}
+
+// WithStrictScope makes API requests fail fast when no scope is available.
+func (b *ConfigBuilder) WithStrictScope(strict bool) *ConfigBuilder {
+	b.config.StrictScope = strict
+	return b
+}
+
 // WithTimeout sets the request timeout.
 func (b *ConfigBuilder) WithTimeout(timeout time.Duration) *ConfigBuilder {
 	b.config.Timeout = timeout
 	return b
 }
 
+// WithCheckTimeout bounds the duration of each permission check, independently
+// of the request timeout. A shorter caller-provided context deadline wins.
+func (b *ConfigBuilder) WithCheckTimeout(timeout time.Duration) *ConfigBuilder {
+	b.config.CheckTimeout = timeout
+	return b
+}
+
 // WithDebug enables or disables debug logging.
 func (b *ConfigBuilder) WithDebug(debug bool) *ConfigBuilder {
 	b.config.Debug = debug
@@ -152,12 +696,33 @@ func (b *ConfigBuilder) WithRetryAttempts(attempts int) *ConfigBuilder {
 	return b
 }
 
+// WithRetryWrites sets whether non-idempotent requests (POST, PATCH) are retried.
+// Retrying writes without an Idempotency-Key header may duplicate them.
+func (b *ConfigBuilder) WithRetryWrites(retryWrites bool) *ConfigBuilder {
+	b.config.RetryWrites = retryWrites
+	return b
+}
+
 // WithThrowOnError sets whether errors should cause panics.
 func (b *ConfigBuilder) WithThrowOnError(throwOnError bool) *ConfigBuilder {
 	b.config.ThrowOnError = throwOnError
 	return b
 }
 
+// WithPermissionValidation enables validation of role permissions on create/update.
+func (b *ConfigBuilder) WithPermissionValidation(validate bool) *ConfigBuilder {
+	b.config.ValidatePermissions = validate
+	return b
+}
+
+// WithDeniedPermissions sets permission patterns that are globally denied,
+// e.g. to switch off dangerous operations during an incident. Patterns use
+// the "resourceType:action" format and either side may be "*".
+func (b *ConfigBuilder) WithDeniedPermissions(patterns []string) *ConfigBuilder {
+	b.config.DeniedPermissions = patterns
+	return b
+}
+
 // WithCustomHeader adds a custom header.
 func (b *ConfigBuilder) WithCustomHeader(key, value string) *ConfigBuilder {
 	b.config.CustomHeaders[key] = value
@@ -178,25 +743,94 @@ func (b *ConfigBuilder) WithLogger(logger *zap.Logger) *ConfigBuilder {
 	return b
 }
 
+// WithMetrics sets the metrics sink that receives SDK metrics.
+func (b *ConfigBuilder) WithMetrics(metrics Metrics) *ConfigBuilder {
+	b.config.Metrics = metrics
+	return b
+}
+
 // WithHTTPClient sets the custom HTTP client.
 func (b *ConfigBuilder) WithHTTPClient(client *http.Client) *ConfigBuilder {
 	b.config.HTTPClient = client
 	return b
 }
 
+// WithInsecureTLS disables TLS certificate verification for self-signed dev servers.
+//
+// SECURITY: never enable this in production. With verification disabled, any
+// party able to intercept traffic can impersonate the API and read the API key.
+// A warning is logged when the configuration is built, and the option is ignored
+// (with a warning) when a custom HTTP client is supplied via WithHTTPClient.
+func (b *ConfigBuilder) WithInsecureTLS(insecure bool) *ConfigBuilder {
+	b.config.InsecureSkipVerify = insecure
+	return b
+}
+
+// WithAPIKeyPrefix sets the prefix Validate requires API keys to start with.
+func (b *ConfigBuilder) WithAPIKeyPrefix(prefix string) *ConfigBuilder {
+	b.config.KeyPrefix = prefix
+	return b
+}
+
+// WithoutKeyPrefixCheck disables the API key prefix check in Validate.
+func (b *ConfigBuilder) WithoutKeyPrefixCheck() *ConfigBuilder {
+	b.config.SkipKeyPrefixCheck = true
+	return b
+}
+
+// WithAuthHeader sets the header used to send the API key, for gateways that
+// expect something other than "Authorization: Bearer <key>". The template must
+// contain exactly one "%s", which is replaced by the key, e.g.
+// WithAuthHeader("X-API-Key", "%s").
+func (b *ConfigBuilder) WithAuthHeader(name, valueTemplate string) *ConfigBuilder {
+	b.config.AuthHeaderName = name
+	b.config.AuthHeaderTemplate = valueTemplate
+	return b
+}
+
 // Build returns the built configuration.
 // It applies default values but does not validate.
 func (b *ConfigBuilder) Build() *Config {
 	// Ensure HTTP client is set
 	if b.config.HTTPClient == nil {
+		// The SDK's own transport, so closing its connections leaves
+		// http.DefaultTransport alone
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if b.config.InsecureSkipVerify {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // explicit opt-in for dev servers
+			b.config.warn("TLS certificate verification is DISABLED (WithInsecureTLS). " +
+				"Never use this setting in production.")
+		}
 		b.config.HTTPClient = &http.Client{
-			Timeout: b.config.Timeout,
+			Timeout:   b.config.Timeout,
+			Transport: transport,
 		}
+		b.config.builtHTTPClient = b.config.HTTPClient
+	} else if b.config.InsecureSkipVerify {
+		b.config.warn("WithInsecureTLS is ignored because a custom HTTP client was supplied; " +
+			"configure TLS on that client instead.")
 	}
 
 	return b.config
 }
 
+// OwnsHTTPClient reports whether HTTPClient was created by Build, with a
+// transport of its own, rather than supplied with WithHTTPClient. Only an
+// owned client may have its connections closed by the SDK.
+func (c *Config) OwnsHTTPClient() bool {
+	return c.HTTPClient != nil && c.HTTPClient == c.builtHTTPClient
+}
+
+// warn logs a warning through the configured logger, falling back to the
+// standard logger so security-relevant warnings are never silent.
+func (c *Config) warn(msg string) {
+	if c.Logger != nil {
+		c.Logger.Warn(msg)
+		return
+	}
+	log.Printf("permissio: WARNING: %s", msg)
+}
+
 // BuildWithValidation returns the built configuration after validation.
 func (b *ConfigBuilder) BuildWithValidation() (*Config, error) {
 	config := b.Build()
@@ -0,0 +1,289 @@
+package config
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestValidateAPIVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		valid   bool
+	}{
+		{"v1", true},
+		{"v2", true},
+		{"v2beta1", true},
+		{"2", false},
+		{"v", false},
+		{"v1/extra", false},
+		{"V1", false},
+	}
+
+	for _, tt := range tests {
+		cfg := NewConfigBuilder("permis_key_test").WithAPIVersion(tt.version).Build()
+		err := cfg.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("version %q: unexpected error: %v", tt.version, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("version %q: expected validation error", tt.version)
+		}
+	}
+}
+
+func TestDefaultAPIVersion(t *testing.T) {
+	cfg := NewConfigBuilder("permis_key_test").Build()
+	if cfg.Version() != DefaultAPIVersion {
+		t.Errorf("Version() = %q, want %q", cfg.Version(), DefaultAPIVersion)
+	}
+
+	if (&Config{}).Version() != DefaultAPIVersion {
+		t.Error("empty APIVersion should fall back to the default")
+	}
+}
+
+func TestWithInsecureTLS(t *testing.T) {
+	cfg := NewConfigBuilder("permis_key_test").WithInsecureTLS(true).Build()
+
+	transport, ok := cfg.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", cfg.HTTPClient.Transport)
+	}
+	if transport.TLSClientConfig == nil || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected InsecureSkipVerify to be set on the SDK-built transport")
+	}
+}
+
+func TestWithInsecureTLSIgnoredForCustomClient(t *testing.T) {
+	custom := &http.Client{}
+	cfg := NewConfigBuilder("permis_key_test").
+		WithHTTPClient(custom).
+		WithInsecureTLS(true).
+		Build()
+
+	if cfg.HTTPClient != custom {
+		t.Fatal("expected the custom HTTP client to be kept")
+	}
+	if custom.Transport != nil {
+		t.Error("custom HTTP client transport must not be modified")
+	}
+}
+
+func TestOwnsHTTPClient(t *testing.T) {
+	built := NewConfigBuilder("permis_key_test").Build()
+	if !built.OwnsHTTPClient() {
+		t.Error("expected the SDK-built HTTP client to be owned")
+	}
+	if built.HTTPClient.Transport == nil || built.HTTPClient.Transport == http.DefaultTransport {
+		t.Error("expected the SDK-built HTTP client to have its own transport")
+	}
+
+	custom := NewConfigBuilder("permis_key_test").WithHTTPClient(&http.Client{}).Build()
+	if custom.OwnsHTTPClient() {
+		t.Error("expected a supplied HTTP client not to be owned")
+	}
+	if Merge(built, custom).OwnsHTTPClient() {
+		t.Error("expected a supplied HTTP client not to be owned after Merge")
+	}
+	if !Merge(built, &Config{}).OwnsHTTPClient() {
+		t.Error("expected the base's SDK-built HTTP client to stay owned after Merge")
+	}
+}
+
+func TestAuthHeader(t *testing.T) {
+	name, value := NewConfigBuilder("permis_key_abc").Build().AuthHeader()
+	if name != "Authorization" || value != "Bearer permis_key_abc" {
+		t.Errorf("default AuthHeader() = %q, %q", name, value)
+	}
+
+	name, value = NewConfigBuilder("permis_key_abc").WithAuthHeader("X-API-Key", "%s").Build().AuthHeader()
+	if name != "X-API-Key" || value != "permis_key_abc" {
+		t.Errorf("custom AuthHeader() = %q, %q", name, value)
+	}
+}
+
+func TestClone(t *testing.T) {
+	cfg := NewConfigBuilder("permis_key_abc").WithProjectID("proj").WithEnvironmentID("env").Build()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cfg.SetToken("permis_key_def")
+		cfg.UpdateScope("proj", "staging")
+	}()
+	clone := cfg.Clone()
+	<-done
+
+	clone.UpdateScope("proj", "prod")
+	if _, env := cfg.Scope(); env != "staging" {
+		t.Errorf("original environment = %q, want staging", env)
+	}
+	if _, env := clone.Scope(); env != "prod" {
+		t.Errorf("clone environment = %q, want prod", env)
+	}
+
+	other := NewConfigBuilder("permis_key_ghi").Build()
+	if cfg.lock() == clone.lock() || cfg.lock() == other.lock() || Merge(cfg, other).lock() == cfg.lock() {
+		t.Error("expected every config to have locks of its own")
+	}
+}
+
+func TestValidateAuthHeaderTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		valid    bool
+	}{
+		{"%s", true},
+		{"Token %s", true},
+		{"static", false},
+		{"%s:%s", false},
+	}
+
+	for _, tt := range tests {
+		_, err := NewConfigBuilder("permis_key_abc").WithAuthHeader("X-API-Key", tt.template).BuildWithValidation()
+		if (err == nil) != tt.valid {
+			t.Errorf("template %q: err = %v, want valid=%v", tt.template, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateKeyPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ConfigBuilder
+		valid   bool
+	}{
+		{"default prefix", NewConfigBuilder("permis_key_abc"), true},
+		{"wrong default prefix", NewConfigBuilder("onprem_abc"), false},
+		{"custom prefix", NewConfigBuilder("onprem_abc").WithAPIKeyPrefix("onprem_"), true},
+		{"wrong custom prefix", NewConfigBuilder("permis_key_abc").WithAPIKeyPrefix("onprem_"), false},
+		{"check disabled", NewConfigBuilder("anything").WithoutKeyPrefixCheck(), true},
+	}
+
+	for _, tt := range tests {
+		_, err := tt.builder.BuildWithValidation()
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: err = %v, want valid=%v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateWithoutAutoScopeRequiresScope(t *testing.T) {
+	if _, err := NewConfigBuilder("permis_key_abc").WithoutAutoScope().BuildWithValidation(); err == nil {
+		t.Error("expected an error without project and environment IDs")
+	}
+	_, err := NewConfigBuilder("permis_key_abc").
+		WithoutAutoScope().
+		WithProjectID("proj").
+		WithEnvironmentID("env").
+		BuildWithValidation()
+	if err != nil {
+		t.Errorf("unexpected error with explicit scope: %v", err)
+	}
+}
+
+func TestValidateUserKeyTransform(t *testing.T) {
+	prefix := func(key string) string { return "auth0|" + key }
+	trim := func(key string) string { return strings.TrimPrefix(key, "auth0|") }
+
+	cfg, err := NewConfigBuilder("permis_key_abc").WithUserKeyTransform(prefix, trim).BuildWithValidation()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.APIUserKey("alice"); got != "auth0|alice" {
+		t.Errorf("APIUserKey() = %q", got)
+	}
+	if got := cfg.AppUserKey("auth0|alice"); got != "alice" {
+		t.Errorf("AppUserKey() = %q", got)
+	}
+	if got := cfg.APIUserKey(""); got != "" {
+		t.Errorf("APIUserKey(\"\") = %q, want empty", got)
+	}
+
+	if _, err := NewConfigBuilder("permis_key_abc").WithUserKeyTransform(prefix, nil).BuildWithValidation(); err == nil {
+		t.Error("expected an error for a transform without an inverse")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	logger := zap.NewNop()
+	base := NewConfigBuilder("permis_key_base").
+		WithProjectID("proj").
+		WithEnvironmentID("env").
+		WithTimeout(10*time.Second).
+		WithDebug(true).
+		WithLogger(logger).
+		WithDeniedPermissions([]string{"*:delete"}).
+		WithCustomHeader("X-Base", "base").
+		WithCustomHeader("X-Shared", "base").
+		Build()
+	override := &Config{
+		Token:         "permis_key_override",
+		EnvironmentID: "staging",
+		CheckTimeout:  2 * time.Second,
+		CustomHeaders: map[string]string{"X-Shared": "override"},
+	}
+
+	merged := Merge(base, override)
+
+	// Explicit overrides survive
+	if merged.Token != "permis_key_override" || merged.EnvironmentID != "staging" || merged.CheckTimeout != 2*time.Second {
+		t.Errorf("overrides lost: token=%q env=%q checkTimeout=%v", merged.Token, merged.EnvironmentID, merged.CheckTimeout)
+	}
+	// Defaults fill gaps
+	if merged.ProjectID != "proj" || merged.ApiURL != DefaultAPIURL || merged.Timeout != 10*time.Second ||
+		merged.RetryAttempts != DefaultRetryAttempts || !merged.Debug || merged.Logger != logger || merged.HTTPClient != base.HTTPClient {
+		t.Errorf("base values not filled in: %+v", merged)
+	}
+	if len(merged.DeniedPermissions) != 1 || merged.DeniedPermissions[0] != "*:delete" {
+		t.Errorf("DeniedPermissions = %v, want [*:delete]", merged.DeniedPermissions)
+	}
+	wantHeaders := map[string]string{"X-Base": "base", "X-Shared": "override"}
+	if !reflect.DeepEqual(merged.CustomHeaders, wantHeaders) {
+		t.Errorf("CustomHeaders = %v, want %v", merged.CustomHeaders, wantHeaders)
+	}
+
+	// Inputs are left untouched
+	if base.EnvironmentID != "env" || base.CustomHeaders["X-Shared"] != "base" || override.ProjectID != "" {
+		t.Error("Merge modified its arguments")
+	}
+
+	observed := 0
+	withObserver := &Config{CacheObserver: func(CacheEvent) { observed++ }}
+	if merged := Merge(withObserver, override); merged.CacheObserver == nil {
+		t.Error("CacheObserver not taken from base")
+	} else if merged.CacheObserver(CacheEvent{Kind: CacheHit}); observed != 1 {
+		t.Errorf("merged CacheObserver calls = %d, want 1", observed)
+	}
+
+	if got := Merge(nil, override); got.Token != override.Token || got == override {
+		t.Errorf("Merge(nil, override) = %+v, want a copy of override", got)
+	}
+}
+
+func TestMergeScalarFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     *Config
+		override *Config
+		get      func(*Config) interface{}
+		want     interface{}
+	}{
+		{"default tenant from base", &Config{DefaultTenant: "acme"}, &Config{}, func(c *Config) interface{} { return c.DefaultTenant }, "acme"},
+		{"default tenant override", &Config{DefaultTenant: "acme"}, &Config{DefaultTenant: "globex"}, func(c *Config) interface{} { return c.DefaultTenant }, "globex"},
+		{"cache TTL from base", &Config{CacheTTL: time.Minute}, &Config{}, func(c *Config) interface{} { return c.CacheTTL }, time.Minute},
+		{"page size override", &Config{DefaultPageSize: 50}, &Config{DefaultPageSize: 20}, func(c *Config) interface{} { return c.DefaultPageSize }, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.get(Merge(tt.base, tt.override)); got != tt.want {
+				t.Errorf("merged value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+// Merge returns a new Config with the fields set in override, and every field
+// unset in override taken from base. Neither argument is modified; either may
+// be nil.
+//
+// A field is unset when it holds its zero value: an empty string, a zero
+// duration or count, false, or a nil slice, map, function, logger, metrics
+// sink, policy decision point or HTTP client. As a consequence, override cannot turn off a boolean
+// enabled in base or set RetryAttempts to 0 when base has retries.
+// CustomHeaders are merged key by key, with override's values winning, and the
+// user key transform and its inverse are taken together.
+//
+// Configs returned by ConfigBuilder.Build already carry the builder defaults
+// (API URL, timeout, retry attempts, HTTP client), which count as set.
+func Merge(base, override *Config) *Config {
+	if base == nil {
+		base = &Config{}
+	}
+	if override == nil {
+		override = &Config{}
+	}
+	// Either config may be in use, so read consistent copies of them
+	base, override = base.Clone(), override.Clone()
+
+	merged := override.Clone()
+	merged.Token = orDefault(override.Token, base.Token)
+	merged.KeyPrefix = orDefault(override.KeyPrefix, base.KeyPrefix)
+	merged.SkipKeyPrefixCheck = override.SkipKeyPrefixCheck || base.SkipKeyPrefixCheck
+	merged.ApiURL = orDefault(override.ApiURL, base.ApiURL)
+	merged.ReadURL = orDefault(override.ReadURL, base.ReadURL)
+	merged.APIVersion = orDefault(override.APIVersion, base.APIVersion)
+	merged.HybridCheck = override.HybridCheck || base.HybridCheck
+	merged.ClientABAC = override.ClientABAC || base.ClientABAC
+	merged.TenantValidation = override.TenantValidation || base.TenantValidation
+	merged.BulkConcurrency = orDefault(override.BulkConcurrency, base.BulkConcurrency)
+	merged.CacheTTL = orDefault(override.CacheTTL, base.CacheTTL)
+	merged.DefaultPageSize = orDefault(override.DefaultPageSize, base.DefaultPageSize)
+	merged.DefaultTenant = orDefault(override.DefaultTenant, base.DefaultTenant)
+	merged.MaxInheritanceDepth = orDefault(override.MaxInheritanceDepth, base.MaxInheritanceDepth)
+	merged.ProjectID = orDefault(override.ProjectID, base.ProjectID)
+	merged.EnvironmentID = orDefault(override.EnvironmentID, base.EnvironmentID)
+	merged.ProjectSlug = orDefault(override.ProjectSlug, base.ProjectSlug)
+	merged.EnvironmentSlug = orDefault(override.EnvironmentSlug, base.EnvironmentSlug)
+	merged.DisableAutoScope = override.DisableAutoScope || base.DisableAutoScope
+	merged.ScopeRefreshInterval = orDefault(override.ScopeRefreshInterval, base.ScopeRefreshInterval)
+	merged.StrictScope = override.StrictScope || base.StrictScope
+	merged.Timeout = orDefault(override.Timeout, base.Timeout)
+	merged.CheckTimeout = orDefault(override.CheckTimeout, base.CheckTimeout)
+	merged.Debug = override.Debug || base.Debug
+	merged.RetryAttempts = orDefault(override.RetryAttempts, base.RetryAttempts)
+	merged.RetryWrites = override.RetryWrites || base.RetryWrites
+	merged.ThrowOnError = override.ThrowOnError || base.ThrowOnError
+	merged.ValidatePermissions = override.ValidatePermissions || base.ValidatePermissions
+	merged.AuthHeaderName = orDefault(override.AuthHeaderName, base.AuthHeaderName)
+	merged.AuthHeaderTemplate = orDefault(override.AuthHeaderTemplate, base.AuthHeaderTemplate)
+	merged.Metrics = orDefault(override.Metrics, base.Metrics)
+	merged.PDP = orDefault(override.PDP, base.PDP)
+	merged.Logger = orDefault(override.Logger, base.Logger)
+	merged.HTTPClient = orDefault(override.HTTPClient, base.HTTPClient)
+	merged.builtHTTPClient = orDefault(override.builtHTTPClient, base.builtHTTPClient)
+	merged.InsecureSkipVerify = override.InsecureSkipVerify || base.InsecureSkipVerify
+
+	if override.TokenRefresher == nil {
+		merged.TokenRefresher = base.TokenRefresher
+	}
+
+	if override.CacheObserver == nil {
+		merged.CacheObserver = base.CacheObserver
+	}
+
+	if override.UserKeyTransform == nil && override.UserKeyInverse == nil {
+		merged.UserKeyTransform, merged.UserKeyInverse = base.UserKeyTransform, base.UserKeyInverse
+	}
+
+	if override.DeniedPermissions == nil {
+		merged.DeniedPermissions = append([]string(nil), base.DeniedPermissions...)
+	} else {
+		merged.DeniedPermissions = append([]string(nil), override.DeniedPermissions...)
+	}
+
+	if base.CustomHeaders != nil || override.CustomHeaders != nil {
+		merged.CustomHeaders = make(map[string]string, len(base.CustomHeaders)+len(override.CustomHeaders))
+		for key, value := range base.CustomHeaders {
+			merged.CustomHeaders[key] = value
+		}
+		for key, value := range override.CustomHeaders {
+			merged.CustomHeaders[key] = value
+		}
+	}
+
+	return merged
+}
+
+// orDefault returns value, or def if value is the zero value.
+func orDefault[T comparable](value, def T) T {
+	var zero T
+	if value == zero {
+		return def
+	}
+	return value
+}
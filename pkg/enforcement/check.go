@@ -0,0 +1,129 @@
+package enforcement
+
+import (
+	"fmt"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// checkBuilder provides a fluent interface for building models.CheckRequest.
+type checkBuilder struct {
+	user     User
+	action   Action
+	resource Resource
+	tenant   string
+	context  map[string]interface{}
+}
+
+// CheckBuilder creates a new checkBuilder for a bulk check of action by user on resource.
+func CheckBuilder(user User, action Action, resource Resource) *checkBuilder {
+	return &checkBuilder{
+		user:     user,
+		action:   action,
+		resource: resource,
+	}
+}
+
+// WithTenant sets the tenant for the check, overriding the resource's tenant.
+func (b *checkBuilder) WithTenant(tenant string) *checkBuilder {
+	b.tenant = tenant
+	return b
+}
+
+// WithContext sets the check context.
+func (b *checkBuilder) WithContext(ctx Context) *checkBuilder {
+	b.context = ctx.Data()
+	return b
+}
+
+// Build returns the built CheckRequest, with the user and resource normalized
+// into the string/map shapes accepted by BulkCheck.
+func (b *checkBuilder) Build() models.CheckRequest {
+	var user interface{} = b.user.Key
+	if len(b.user.Attributes) > 0 {
+		user = map[string]interface{}{
+			"key":        b.user.Key,
+			"attributes": b.user.Attributes,
+		}
+	}
+
+	resource := map[string]interface{}{"type": b.resource.Type}
+	if b.resource.Key != "" {
+		resource["key"] = b.resource.Key
+	}
+	if b.resource.Tenant != "" {
+		resource["tenant"] = b.resource.Tenant
+	}
+	if len(b.resource.Attributes) > 0 {
+		resource["attributes"] = b.resource.Attributes
+	}
+
+	request := models.CheckRequest{
+		User:     user,
+		Action:   string(b.action),
+		Resource: resource,
+		Tenant:   b.tenant,
+	}
+	if len(b.context) > 0 {
+		request.Context = b.context
+	}
+	return request
+}
+
+// FromCheckRequest converts a CheckRequest back into a typed user, action and
+// resource. User may be a key string, a User or a map with "key" and
+// "attributes"; Resource may be a type string, a Resource or a map with
+// "type", "key", "tenant" and "attributes". A non-empty request Tenant
+// overrides the resource's tenant.
+func FromCheckRequest(request models.CheckRequest) (User, Action, Resource, error) {
+	var user User
+	switch u := request.User.(type) {
+	case string:
+		user = User{Key: u}
+	case User:
+		user = u
+	case map[string]interface{}:
+		user.Key, _ = u["key"].(string)
+		user.Attributes, _ = u["attributes"].(map[string]interface{})
+	default:
+		return User{}, "", Resource{}, fmt.Errorf("unsupported check request user type %T", request.User)
+	}
+
+	var resource Resource
+	switch r := request.Resource.(type) {
+	case string:
+		resource = Resource{Type: r}
+	case Resource:
+		resource = r
+	case map[string]interface{}:
+		resource.Type, _ = r["type"].(string)
+		resource.Key, _ = r["key"].(string)
+		resource.Tenant, _ = r["tenant"].(string)
+		resource.Attributes, _ = r["attributes"].(map[string]interface{})
+	default:
+		return User{}, "", Resource{}, fmt.Errorf("unsupported check request resource type %T", request.Resource)
+	}
+
+	if request.Tenant != "" {
+		resource.Tenant = request.Tenant
+	}
+
+	return user, Action(request.Action), resource, nil
+}
+
+// CheckAgainst returns true if any of permissions allows action on the
+// resource's type, without any network call. It applies the same matching as
+// the client's local checks: an exact "resourceType:action" permission, a
+// resource wildcard ("document:*") or the global wildcard ("*:*").
+//
+// Use it with permissions fetched once via GetPermissions and cached by the
+// application. Role assignment conditions and the configured deny list are
+// not applied.
+func CheckAgainst(permissions []string, action Action, resource Resource) bool {
+	for _, perm := range permissions {
+		if models.PermissionMatches(perm, resource.Type, string(action)) {
+			return true
+		}
+	}
+	return false
+}
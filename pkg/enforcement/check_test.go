@@ -0,0 +1,62 @@
+package enforcement
+
+import "testing"
+
+func TestCheckBuilderRoundTrip(t *testing.T) {
+	user := UserBuilder("alice").WithAttribute("department", "eng").Build()
+	resource := ResourceBuilder("document").WithKey("doc-1").WithTenant("acme").Build()
+
+	request := CheckBuilder(user, "read", resource).
+		WithTenant("globex").
+		WithContext(ContextBuilder().With("region", "eu").Build()).
+		Build()
+
+	if request.Context["region"] != "eu" {
+		t.Errorf("Context = %v, want region=eu", request.Context)
+	}
+
+	gotUser, gotAction, gotResource, err := FromCheckRequest(request)
+	if err != nil {
+		t.Fatalf("FromCheckRequest() error: %v", err)
+	}
+	if gotUser.Key != "alice" || gotUser.Attributes["department"] != "eng" {
+		t.Errorf("user = %+v", gotUser)
+	}
+	if gotAction != "read" {
+		t.Errorf("action = %q, want read", gotAction)
+	}
+	if gotResource.Type != "document" || gotResource.Key != "doc-1" || gotResource.Tenant != "globex" {
+		t.Errorf("resource = %+v, want document:doc-1 in tenant globex", gotResource)
+	}
+}
+
+func TestFromCheckRequestRejectsUnsupportedTypes(t *testing.T) {
+	request := CheckBuilder(User{Key: "alice"}, "read", Resource{Type: "document"}).Build()
+	request.User = 42
+
+	if _, _, _, err := FromCheckRequest(request); err == nil {
+		t.Error("expected an error for an unsupported user type")
+	}
+}
+
+func TestCheckAgainst(t *testing.T) {
+	permissions := []string{"document:read", "report:*"}
+	tests := []struct {
+		action   Action
+		resource Resource
+		want     bool
+	}{
+		{"read", Resource{Type: "document"}, true},
+		{"write", Resource{Type: "document"}, false},
+		{"delete", Resource{Type: "report", Key: "q3"}, true},
+		{"read", Resource{Type: "invoice"}, false},
+	}
+	for _, tt := range tests {
+		if got := CheckAgainst(permissions, tt.action, tt.resource); got != tt.want {
+			t.Errorf("CheckAgainst(%s on %s) = %v, want %v", tt.action, tt.resource.Type, got, tt.want)
+		}
+	}
+	if !CheckAgainst([]string{"*:*"}, "anything", Resource{Type: "anywhere"}) {
+		t.Error("expected the global wildcard to match")
+	}
+}
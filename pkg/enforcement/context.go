@@ -0,0 +1,114 @@
+package enforcement
+
+import (
+	"context"
+	"time"
+)
+
+// checkContextKey is the context key for the check Context.
+type checkContextKey struct{}
+
+// WithCheckContext returns a copy of ctx carrying the given check Context.
+// The check Context is forwarded to the PDP as part of the check payload.
+func WithCheckContext(ctx context.Context, checkCtx Context) context.Context {
+	return context.WithValue(ctx, checkContextKey{}, checkCtx)
+}
+
+// CheckContextFromContext returns the check Context stored in ctx, if any.
+func CheckContextFromContext(ctx context.Context) (Context, bool) {
+	checkCtx, ok := ctx.Value(checkContextKey{}).(Context)
+	return checkCtx, ok
+}
+
+// tenantKey is the context key for the default tenant.
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying a default tenant key, so that a
+// routing middleware can resolve the tenant once (e.g. from the subdomain)
+// for enforcement middleware later in the chain.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant key stored in ctx with WithTenant, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// debugKey is the context key for the per-request debug flag.
+type debugKey struct{}
+
+// WithDebug returns a copy of ctx that enables debug logging for the checks and
+// API requests made with it, without enabling debug mode for the whole client.
+// A logger must still be configured for output to be produced.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// DebugFromContext returns true if debug logging was enabled on ctx with WithDebug.
+func DebugFromContext(ctx context.Context) bool {
+	debug, _ := ctx.Value(debugKey{}).(bool)
+	return debug
+}
+
+// evalTimeKey is the context key for the evaluation timestamp.
+type evalTimeKey struct{}
+
+// WithEvalTime returns a copy of ctx that makes client-side checks evaluate
+// time-bound role assignments as of t instead of the current time.
+// It is ignored in PDP mode, where the server controls time.
+func WithEvalTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, evalTimeKey{}, t)
+}
+
+// EvalTimeFromContext returns the evaluation timestamp stored in ctx, if any.
+func EvalTimeFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(evalTimeKey{}).(time.Time)
+	return t, ok
+}
+
+// headersKey is the context key for per-request headers.
+type headersKey struct{}
+
+// WithHeaders returns a copy of ctx carrying extra HTTP headers for the API
+// requests made with it. They are sent in addition to the configured custom
+// headers and override them for the same key. Headers already on ctx are
+// kept unless headers sets the same key. The map is copied.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	existing := HeadersFromContext(ctx)
+	merged := make(map[string]string, len(existing)+len(headers))
+	for key, value := range existing {
+		merged[key] = value
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// HeadersFromContext returns the headers stored in ctx with WithHeaders, if any.
+// The returned map must not be modified.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
+
+// retriesKey is the context key for the per-request retry count.
+type retriesKey struct{}
+
+// WithRetries returns a copy of ctx that makes the API requests made with it
+// retry up to n times, overriding the configured RetryAttempts for those
+// requests only. WithRetries(ctx, 0) disables retries, e.g. for a
+// latency-sensitive check. Only the count is overridden: POST and PATCH
+// requests are still retried only when writes are retryable. A negative n is
+// treated as 0.
+func WithRetries(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, retriesKey{}, max(n, 0))
+}
+
+// RetriesFromContext returns the retry count stored in ctx with WithRetries, if any.
+func RetriesFromContext(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(retriesKey{}).(int)
+	return n, ok
+}
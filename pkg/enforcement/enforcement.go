@@ -1,9 +1,37 @@
 // Package enforcement provides builder types for constructing permission check requests.
 package enforcement
 
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrInstanceWithoutTenant is returned by Resource.Validate for a resource
+// instance (Key set) without a Tenant, which would be looked up in the wrong scope.
+var ErrInstanceWithoutTenant = errors.New("resource instance key is set but tenant is empty")
+
 // Action represents an action to check permission for.
 type Action string
 
+// ActionForMethod returns the conventional action for an HTTP method:
+// GET and HEAD map to "read", POST to "create", PUT and PATCH to "update" and
+// DELETE to "delete". It returns an empty Action for other methods.
+func ActionForMethod(method string) Action {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead:
+		return "read"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut, http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return ""
+	}
+}
+
 // User represents a user in a permission check.
 type User struct {
 	Key        string                 `json:"key"`
@@ -67,6 +95,12 @@ func ResourceBuilder(resourceType string) *resourceBuilder {
 	}
 }
 
+// InstanceBuilder creates a new resourceBuilder for a resource instance,
+// setting the type, instance key and tenant in one call.
+func InstanceBuilder(resourceType, key, tenant string) *resourceBuilder {
+	return ResourceBuilder(resourceType).WithKey(key).WithTenant(tenant)
+}
+
 // WithKey sets the resource instance key.
 func (b *resourceBuilder) WithKey(key string) *resourceBuilder {
 	b.resource.Key = key
@@ -93,11 +127,32 @@ func (b *resourceBuilder) WithAttributes(attributes map[string]interface{}) *res
 	return b
 }
 
-// Build returns the built Resource.
+// Build returns the built Resource without validating it. A resource instance
+// (Key set) without a Tenant is only accepted by checks when the client has a
+// default tenant; otherwise they fail with ErrInstanceWithoutTenant. Use
+// BuildWithValidation to catch this when building the resource.
 func (b *resourceBuilder) Build() Resource {
 	return b.resource
 }
 
+// BuildWithValidation returns the built Resource after validation
+// (see Resource.Validate).
+func (b *resourceBuilder) BuildWithValidation() (Resource, error) {
+	if err := b.resource.Validate(); err != nil {
+		return Resource{}, err
+	}
+	return b.resource, nil
+}
+
+// Validate returns ErrInstanceWithoutTenant if the resource has an instance
+// Key but no Tenant. Resource types without a Key need no tenant.
+func (r Resource) Validate() error {
+	if r.Key != "" && r.Tenant == "" {
+		return ErrInstanceWithoutTenant
+	}
+	return nil
+}
+
 // Context represents additional context for a permission check.
 type Context struct {
 	data map[string]interface{}
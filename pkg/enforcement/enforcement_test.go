@@ -0,0 +1,47 @@
+package enforcement
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResourceBuildWithValidation(t *testing.T) {
+	if _, err := InstanceBuilder("document", "doc-1", "acme").BuildWithValidation(); err != nil {
+		t.Errorf("instance with tenant: unexpected error %v", err)
+	}
+	if _, err := ResourceBuilder("document").BuildWithValidation(); err != nil {
+		t.Errorf("resource type without key: unexpected error %v", err)
+	}
+	if _, err := ResourceBuilder("document").WithKey("doc-1").BuildWithValidation(); err != ErrInstanceWithoutTenant {
+		t.Errorf("instance without tenant: err = %v, want ErrInstanceWithoutTenant", err)
+	}
+}
+
+func TestActionForMethod(t *testing.T) {
+	tests := map[string]Action{
+		"GET":     "read",
+		"HEAD":    "read",
+		"post":    "create",
+		"PUT":     "update",
+		"PATCH":   "update",
+		"DELETE":  "delete",
+		"OPTIONS": "",
+	}
+	for method, want := range tests {
+		if got := ActionForMethod(method); got != want {
+			t.Errorf("ActionForMethod(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
+
+func TestWithHeadersMergesOverOuterHeaders(t *testing.T) {
+	outer := WithHeaders(context.Background(), map[string]string{"A": "1", "B": "1"})
+	inner := WithHeaders(outer, map[string]string{"B": "2"})
+
+	if got := HeadersFromContext(inner); got["A"] != "1" || got["B"] != "2" {
+		t.Errorf("inner headers = %v", got)
+	}
+	if got := HeadersFromContext(outer); got["B"] != "1" {
+		t.Errorf("outer headers modified: %v", got)
+	}
+}
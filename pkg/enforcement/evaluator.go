@@ -0,0 +1,251 @@
+package enforcement
+
+import (
+	"sort"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// Evaluator decides permission checks from role definitions and role
+// assignments, without any network call. It implements the client's local
+// check semantics: role inheritance through Extends (with cycles ignored) and
+// the wildcard matching of CheckAgainst.
+//
+// The assignments are assumed to apply to the checks being evaluated; callers
+// filter them by tenant, time window and conditions beforehand.
+type Evaluator struct {
+	roles       map[string]*models.RoleRead
+	assignments models.RoleAssignmentList
+
+	// OnMalformedPermission, if set, is called for each permission not in
+	// "resourceType:action" format encountered while resolving a role.
+	OnMalformedPermission func(roleKey, permission string, err error)
+
+	// OnUnresolvedParent, if set, is called when a role extends a parent role
+	// missing from the roles map.
+	OnUnresolvedParent func(roleKey, parentKey string)
+
+	// MaxDepth, if positive, caps how many levels of Extends are followed
+	// from a role. Permissions of deeper ancestors are not inherited.
+	MaxDepth int
+
+	// OnDepthExceeded, if set, is called with the role whose inheritance was
+	// cut short by MaxDepth.
+	OnDepthExceeded func(roleKey string)
+}
+
+// Debug explains an Evaluator decision.
+type Debug struct {
+	// AssignedRoles lists the unique roles of the assignments, in assignment order.
+	AssignedRoles []string
+
+	// MatchedRoles lists the assigned roles that grant the permission.
+	MatchedRoles []string
+
+	// UnresolvedExtends lists, sorted, the parent roles reachable from the
+	// assigned roles that are missing from the roles map.
+	UnresolvedExtends []string
+
+	// TruncatedRoles lists the assigned roles whose inheritance was cut short
+	// by MaxDepth.
+	TruncatedRoles []string
+
+	// EmptyRoles lists the assigned roles that exist but grant no permission
+	// at all, not even inherited ones: typically placeholders not filled yet.
+	EmptyRoles []string
+}
+
+// NewEvaluator creates an Evaluator for the given roles, indexed by key, and
+// role assignments.
+func NewEvaluator(roles map[string]*models.RoleRead, assignments models.RoleAssignmentList) *Evaluator {
+	return &Evaluator{
+		roles:       roles,
+		assignments: assignments,
+	}
+}
+
+// Allowed returns true if any assigned role grants action on the resource.
+func (e *Evaluator) Allowed(action Action, resource Resource) (bool, Debug) {
+	debug := Debug{AssignedRoles: e.AssignedRoles()}
+	for _, roleKey := range debug.AssignedRoles {
+		permissions, truncated := e.resolve(roleKey)
+		if truncated {
+			debug.TruncatedRoles = append(debug.TruncatedRoles, roleKey)
+		}
+		if _, ok := e.roles[roleKey]; ok && len(permissions) == 0 {
+			debug.EmptyRoles = append(debug.EmptyRoles, roleKey)
+		}
+		if CheckAgainst(permissions, action, resource) {
+			debug.MatchedRoles = append(debug.MatchedRoles, roleKey)
+		}
+	}
+	debug.UnresolvedExtends = e.unresolvedExtends(debug.AssignedRoles)
+	return len(debug.MatchedRoles) > 0, debug
+}
+
+// AssignedRoles returns the unique roles of the assignments, in order.
+func (e *Evaluator) AssignedRoles() []string {
+	seen := make(map[string]struct{})
+	roles := make([]string, 0, len(e.assignments))
+	for _, assignment := range e.assignments {
+		if _, ok := seen[assignment.Role]; ok {
+			continue
+		}
+		seen[assignment.Role] = struct{}{}
+		roles = append(roles, assignment.Role)
+	}
+	return roles
+}
+
+// EffectivePermissions returns the unique permissions, including inherited
+// ones, granted by the given roles, sorted.
+func (e *Evaluator) EffectivePermissions(roleKeys []string) []string {
+	seen := make(map[string]struct{})
+	for _, roleKey := range roleKeys {
+		for _, perm := range e.RolePermissions(roleKey) {
+			seen[perm] = struct{}{}
+		}
+	}
+
+	permissions := make([]string, 0, len(seen))
+	for perm := range seen {
+		permissions = append(permissions, perm)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// RolePermissions returns the permissions of a role, including inherited
+// ones, without duplicates. Unknown roles have no permissions.
+func (e *Evaluator) RolePermissions(roleKey string) []string {
+	permissions, _ := e.resolve(roleKey)
+	return permissions
+}
+
+// resolve returns the permissions of roleKey and whether MaxDepth cut its
+// inheritance short.
+func (e *Evaluator) resolve(roleKey string) ([]string, bool) {
+	permissions, truncated := e.rolePermissions(roleKey)
+	if truncated && e.OnDepthExceeded != nil {
+		e.OnDepthExceeded(roleKey)
+	}
+	return permissions, truncated
+}
+
+// InheritedRoles returns the roles roleKey inherits from through Extends,
+// nearest first, within MaxDepth levels. Parents missing from the roles map
+// and roleKey itself are left out.
+func (e *Evaluator) InheritedRoles(roleKey string) []string {
+	closure, _ := e.closure(roleKey)
+	if len(closure) == 0 {
+		return []string{}
+	}
+	return closure[1:]
+}
+
+// rolePermissions resolves the permissions of roleKey, without duplicates,
+// from the roles of its closure. It reports whether MaxDepth stopped the
+// traversal.
+func (e *Evaluator) rolePermissions(roleKey string) ([]string, bool) {
+	closure, truncated := e.closure(roleKey)
+	seen := make(map[string]struct{})
+	var permissions []string
+	for _, key := range closure {
+		for _, perm := range e.roles[key].Permissions {
+			if e.OnMalformedPermission != nil {
+				if err := models.ValidatePermission(perm); err != nil {
+					e.OnMalformedPermission(key, perm, err)
+				}
+			}
+			if _, ok := seen[perm]; !ok {
+				seen[perm] = struct{}{}
+				permissions = append(permissions, perm)
+			}
+		}
+	}
+	return permissions, truncated
+}
+
+// closure returns roleKey followed by the roles it inherits from, nearest
+// first, or nothing if roleKey is unknown. Extends is walked one level at a
+// time, so every ancestor is reached at its shortest distance from roleKey
+// and MaxDepth applies to that distance, whatever the order of the paths.
+// Each role is visited once, which also breaks inheritance cycles. It reports
+// whether MaxDepth stopped the traversal.
+func (e *Evaluator) closure(roleKey string) ([]string, bool) {
+	if _, ok := e.roles[roleKey]; !ok {
+		return nil, false
+	}
+
+	truncated := false
+	visited := map[string]struct{}{roleKey: {}}
+	closure := []string{roleKey}
+
+	level := []string{roleKey}
+	for depth := 0; len(level) > 0; depth++ {
+		var next []string
+		for _, key := range level {
+			role := e.roles[key]
+			if e.MaxDepth > 0 && depth >= e.MaxDepth {
+				if len(role.Extends) > 0 {
+					truncated = true
+				}
+				continue
+			}
+			for _, parentKey := range role.Extends {
+				if _, ok := e.roles[parentKey]; !ok {
+					if e.OnUnresolvedParent != nil {
+						e.OnUnresolvedParent(key, parentKey)
+					}
+					continue
+				}
+				if _, ok := visited[parentKey]; ok {
+					continue
+				}
+				visited[parentKey] = struct{}{}
+				next = append(next, parentKey)
+			}
+		}
+		closure = append(closure, next...)
+		level = next
+	}
+	return closure, truncated
+}
+
+// unresolvedExtends returns the parent roles reachable from roleKeys through
+// Extends that are missing from the roles map, sorted.
+func (e *Evaluator) unresolvedExtends(roleKeys []string) []string {
+	visited := make(map[string]struct{})
+	missing := make(map[string]struct{})
+	queue := append([]string(nil), roleKeys...)
+	for len(queue) > 0 {
+		roleKey := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[roleKey]; ok {
+			continue
+		}
+		visited[roleKey] = struct{}{}
+
+		role, ok := e.roles[roleKey]
+		if !ok {
+			continue
+		}
+		for _, parentKey := range role.Extends {
+			if _, ok := e.roles[parentKey]; !ok {
+				missing[parentKey] = struct{}{}
+				continue
+			}
+			queue = append(queue, parentKey)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(missing))
+	for parentKey := range missing {
+		result = append(result, parentKey)
+	}
+	sort.Strings(result)
+	return result
+}
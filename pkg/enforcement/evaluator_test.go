@@ -0,0 +1,105 @@
+package enforcement
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+func TestEvaluator(t *testing.T) {
+	roles := map[string]*models.RoleRead{
+		"viewer": {Key: "viewer", Permissions: []string{"document:read"}},
+		"editor": {Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer", "deleted"}},
+		"admin":  {Key: "admin", Permissions: []string{"report:*"}, Extends: []string{"admin"}},
+	}
+	assignments := models.RoleAssignmentList{
+		{User: "alice", Role: "editor"},
+		{User: "alice", Role: "admin"},
+		{User: "alice", Role: "editor", Tenant: "other"},
+	}
+
+	var unresolved []string
+	evaluator := NewEvaluator(roles, assignments)
+	evaluator.OnUnresolvedParent = func(roleKey, parentKey string) {
+		unresolved = append(unresolved, roleKey+"->"+parentKey)
+	}
+
+	allowed, debug := evaluator.Allowed("read", Resource{Type: "document"})
+	if !allowed || !reflect.DeepEqual(debug.MatchedRoles, []string{"editor"}) {
+		t.Errorf("Allowed(read document) = %v, matched %v", allowed, debug.MatchedRoles)
+	}
+	if !reflect.DeepEqual(debug.AssignedRoles, []string{"editor", "admin"}) {
+		t.Errorf("AssignedRoles = %v", debug.AssignedRoles)
+	}
+	if !reflect.DeepEqual(debug.UnresolvedExtends, []string{"deleted"}) {
+		t.Errorf("UnresolvedExtends = %v", debug.UnresolvedExtends)
+	}
+	if len(unresolved) == 0 || unresolved[0] != "editor->deleted" {
+		t.Errorf("OnUnresolvedParent calls = %v", unresolved)
+	}
+
+	if allowed, _ := evaluator.Allowed("delete", Resource{Type: "document"}); allowed {
+		t.Error("expected delete on document to be denied")
+	}
+
+	got := evaluator.EffectivePermissions([]string{"editor", "admin"})
+	want := []string{"document:read", "document:write", "report:*"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EffectivePermissions() = %v, want %v", got, want)
+	}
+}
+
+func TestEvaluatorMaxDepth(t *testing.T) {
+	roles := map[string]*models.RoleRead{
+		"level0": {Key: "level0", Permissions: []string{"document:read"}, Extends: []string{"level1"}},
+		"level1": {Key: "level1", Permissions: []string{"document:write"}, Extends: []string{"level2"}},
+		"level2": {Key: "level2", Permissions: []string{"document:delete"}},
+	}
+
+	var exceeded []string
+	evaluator := NewEvaluator(roles, models.RoleAssignmentList{{User: "alice", Role: "level0"}})
+	evaluator.MaxDepth = 1
+	evaluator.OnDepthExceeded = func(roleKey string) { exceeded = append(exceeded, roleKey) }
+
+	if got := evaluator.RolePermissions("level0"); !reflect.DeepEqual(got, []string{"document:read", "document:write"}) {
+		t.Errorf("RolePermissions() = %v", got)
+	}
+
+	allowed, debug := evaluator.Allowed("delete", Resource{Type: "document"})
+	if allowed {
+		t.Error("expected the permission beyond MaxDepth not to be inherited")
+	}
+	if !reflect.DeepEqual(debug.TruncatedRoles, []string{"level0"}) {
+		t.Errorf("TruncatedRoles = %v", debug.TruncatedRoles)
+	}
+	if len(exceeded) == 0 || exceeded[0] != "level0" {
+		t.Errorf("OnDepthExceeded calls = %v", exceeded)
+	}
+
+	evaluator.MaxDepth = 2
+	if allowed, debug := evaluator.Allowed("delete", Resource{Type: "document"}); !allowed || debug.TruncatedRoles != nil {
+		t.Errorf("Allowed() with MaxDepth 2 = %v, truncated %v", allowed, debug.TruncatedRoles)
+	}
+}
+
+func TestEvaluatorMaxDepthUsesShortestPath(t *testing.T) {
+	// A reaches C both directly and through B; D is two levels away through C
+	roles := map[string]*models.RoleRead{
+		"a": {Key: "a", Extends: []string{"b", "c"}},
+		"b": {Key: "b", Extends: []string{"c"}},
+		"c": {Key: "c", Extends: []string{"d"}},
+		"d": {Key: "d", Permissions: []string{"document:read"}},
+	}
+	evaluator := NewEvaluator(roles, models.RoleAssignmentList{{User: "alice", Role: "a"}})
+	evaluator.MaxDepth = 2
+
+	if allowed, debug := evaluator.Allowed("read", Resource{Type: "document"}); !allowed {
+		t.Errorf("Allowed() = false, want the permission inherited through a->c->d (truncated %v)", debug.TruncatedRoles)
+	}
+
+	evaluator.MaxDepth = 1
+	if allowed, _ := evaluator.Allowed("read", Resource{Type: "document"}); allowed {
+		t.Error("expected d to be out of reach with MaxDepth 1")
+	}
+}
@@ -0,0 +1,192 @@
+// Package middleware provides net/http middleware for enforcing Permissio.io permissions.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/permissio/permissio-go/pkg/enforcement"
+)
+
+const (
+	// DefaultUserHeader is the header the user key is read from by default.
+	DefaultUserHeader = "X-User"
+
+	// DefaultTenantHeader is the header the tenant key is read from by default.
+	DefaultTenantHeader = "X-Tenant"
+)
+
+// Checker is the subset of the Permissio.io client used by the middleware.
+// It is satisfied by *permissio.Client.
+type Checker interface {
+	CheckWithContext(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (bool, error)
+}
+
+// Option configures the middleware.
+type Option func(*options)
+
+// options holds the middleware configuration.
+type options struct {
+	userKey            func(*http.Request) string
+	tenant             func(*http.Request) string
+	resourceKey        func(*http.Request) string
+	userAttributes     func(*http.Request) map[string]interface{}
+	resourceAttributes func(*http.Request) map[string]interface{}
+	methodActions      map[string]enforcement.Action
+}
+
+// WithUserKey sets how the user key is extracted from the request.
+// Defaults to the X-User header.
+func WithUserKey(fn func(*http.Request) string) Option {
+	return func(o *options) {
+		o.userKey = fn
+	}
+}
+
+// WithTenant sets how the tenant key is extracted from the request.
+// An extractor set here takes precedence over a tenant in the request context
+// (see enforcement.WithTenant), which takes precedence over the X-Tenant header.
+func WithTenant(fn func(*http.Request) string) Option {
+	return func(o *options) {
+		o.tenant = fn
+	}
+}
+
+// WithResourceKey sets how the resource instance key is extracted from the request.
+func WithResourceKey(fn func(*http.Request) string) Option {
+	return func(o *options) {
+		o.resourceKey = fn
+	}
+}
+
+// WithUserAttributes derives user attributes from the request.
+// Attributes only affect decisions in PDP mode or with client-side ABAC enabled.
+func WithUserAttributes(fn func(*http.Request) map[string]interface{}) Option {
+	return func(o *options) {
+		o.userAttributes = fn
+	}
+}
+
+// WithResourceAttributes derives resource attributes from the request
+// (e.g. the document's owner taken from the URL or body).
+// Attributes only affect decisions in PDP mode or with client-side ABAC enabled.
+func WithResourceAttributes(fn func(*http.Request) map[string]interface{}) Option {
+	return func(o *options) {
+		o.resourceAttributes = fn
+	}
+}
+
+// WithMethodActions overrides the action derived from the HTTP method when
+// Require is given an empty action, e.g. {"POST": "publish"} for a route
+// where POST publishes. Methods not listed keep the enforcement.ActionForMethod
+// mapping.
+func WithMethodActions(actions map[string]enforcement.Action) Option {
+	return func(o *options) {
+		if o.methodActions == nil {
+			o.methodActions = make(map[string]enforcement.Action, len(actions))
+		}
+		for method, action := range actions {
+			o.methodActions[strings.ToUpper(method)] = action
+		}
+	}
+}
+
+// Require returns middleware that only calls the next handler when the request's
+// user is allowed to perform action on resourceType.
+// An empty action is derived from the request method with
+// enforcement.ActionForMethod (GET→read, POST→create, ...), as overridden by
+// WithMethodActions.
+// It responds with 400 when no user key is present, 405 when no action can be
+// derived for the method, 403 when access is denied and 500 when the check fails.
+func Require(checker Checker, action enforcement.Action, resourceType string, opts ...Option) func(http.Handler) http.Handler {
+	o := &options{
+		userKey: headerExtractor(DefaultUserHeader),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tenant == nil {
+		o.tenant = contextTenant(headerExtractor(DefaultTenantHeader))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userKey := o.userKey(r)
+			if userKey == "" {
+				http.Error(w, "Missing user key", http.StatusBadRequest)
+				return
+			}
+
+			requestAction := action
+			if requestAction == "" {
+				requestAction = o.actionForMethod(r.Method)
+			}
+			if requestAction == "" {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			user, resource := o.build(r, userKey, resourceType)
+
+			allowed, err := checker.CheckWithContext(r.Context(), user, requestAction, resource)
+			if err != nil {
+				http.Error(w, "Permission check failed", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Access denied", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// actionForMethod returns the action for an HTTP method, honoring WithMethodActions.
+func (o *options) actionForMethod(method string) enforcement.Action {
+	if action, ok := o.methodActions[strings.ToUpper(method)]; ok {
+		return action
+	}
+	return enforcement.ActionForMethod(method)
+}
+
+// build constructs the user and resource for a check from the request.
+func (o *options) build(r *http.Request, userKey, resourceType string) (enforcement.User, enforcement.Resource) {
+	userBuilder := enforcement.UserBuilder(userKey)
+	if o.userAttributes != nil {
+		userBuilder.WithAttributes(o.userAttributes(r))
+	}
+
+	resourceBuilder := enforcement.ResourceBuilder(resourceType)
+	if tenant := o.tenant(r); tenant != "" {
+		resourceBuilder.WithTenant(tenant)
+	}
+	if o.resourceKey != nil {
+		resourceBuilder.WithKey(o.resourceKey(r))
+	}
+	if o.resourceAttributes != nil {
+		resourceBuilder.WithAttributes(o.resourceAttributes(r))
+	}
+
+	return userBuilder.Build(), resourceBuilder.Build()
+}
+
+// headerExtractor returns an extractor reading the given request header.
+func headerExtractor(name string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// contextTenant returns an extractor reading the tenant set with
+// enforcement.WithTenant on the request context, falling back to fallback.
+func contextTenant(fallback func(*http.Request) string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if tenant, ok := enforcement.TenantFromContext(r.Context()); ok {
+			return tenant
+		}
+		return fallback(r)
+	}
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/enforcement"
+	"github.com/permissio/permissio-go/pkg/permissio"
+)
+
+// recordingChecker records the last check and returns a fixed decision.
+type recordingChecker struct {
+	allowed  bool
+	err      error
+	ctx      context.Context
+	user     enforcement.User
+	action   enforcement.Action
+	resource enforcement.Resource
+}
+
+func (c *recordingChecker) CheckWithContext(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (bool, error) {
+	c.ctx, c.user, c.action, c.resource = ctx, user, action, resource
+	return c.allowed, c.err
+}
+
+// okHandler responds with 200 OK.
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestRequireStatusCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		allowed bool
+		want    int
+	}{
+		{"missing user", "", true, http.StatusBadRequest},
+		{"denied", "alice", false, http.StatusForbidden},
+		{"allowed", "alice", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &recordingChecker{allowed: tt.allowed}
+			handler := Require(checker, "read", "document")(okHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/documents", nil)
+			if tt.user != "" {
+				req.Header.Set(DefaultUserHeader, tt.user)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireWithAttributes(t *testing.T) {
+	checker := &recordingChecker{allowed: true}
+	handler := Require(checker, "read", "document",
+		WithResourceKey(func(r *http.Request) string { return r.URL.Query().Get("id") }),
+		WithResourceAttributes(func(r *http.Request) map[string]interface{} {
+			return map[string]interface{}{"owner": r.URL.Query().Get("owner")}
+		}),
+		WithUserAttributes(func(r *http.Request) map[string]interface{} {
+			return map[string]interface{}{"department": r.Header.Get("X-Department")}
+		}),
+	)(okHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/documents?id=doc-1&owner=bob", nil)
+	req.Header.Set(DefaultUserHeader, "alice")
+	req.Header.Set(DefaultTenantHeader, "acme")
+	req.Header.Set("X-Department", "engineering")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if checker.resource.Key != "doc-1" || checker.resource.Tenant != "acme" {
+		t.Errorf("unexpected resource %+v", checker.resource)
+	}
+	if checker.resource.Attributes["owner"] != "bob" {
+		t.Errorf("resource owner attribute = %v, want bob", checker.resource.Attributes["owner"])
+	}
+	if checker.user.Attributes["department"] != "engineering" {
+		t.Errorf("user department attribute = %v, want engineering", checker.user.Attributes["department"])
+	}
+}
+
+func TestRequireTenantPrecedence(t *testing.T) {
+	fromOption := WithTenant(func(*http.Request) string { return "option" })
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		context string
+		header  string
+		want    string
+	}{
+		{"option wins over context and header", []Option{fromOption}, "context", "header", "option"},
+		{"context wins over header", nil, "context", "header", "context"},
+		{"header when nothing else is set", nil, "", "header", "header"},
+		{"no tenant", nil, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &recordingChecker{allowed: true}
+			handler := Require(checker, "read", "document", tt.opts...)(okHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/documents", nil)
+			req.Header.Set(DefaultUserHeader, "alice")
+			if tt.header != "" {
+				req.Header.Set(DefaultTenantHeader, tt.header)
+			}
+			if tt.context != "" {
+				req = req.WithContext(enforcement.WithTenant(req.Context(), tt.context))
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if checker.resource.Tenant != tt.want {
+				t.Errorf("tenant = %q, want %q", checker.resource.Tenant, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireDerivesActionFromMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		opts   []Option
+		want   enforcement.Action
+		status int
+	}{
+		{http.MethodGet, nil, "read", http.StatusOK},
+		{http.MethodPost, nil, "create", http.StatusOK},
+		{http.MethodPatch, nil, "update", http.StatusOK},
+		{http.MethodDelete, nil, "delete", http.StatusOK},
+		{http.MethodPost, []Option{WithMethodActions(map[string]enforcement.Action{"post": "publish"})}, "publish", http.StatusOK},
+		{http.MethodOptions, nil, "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+string(tt.want), func(t *testing.T) {
+			checker := &recordingChecker{allowed: true}
+			handler := Require(checker, "", "document", tt.opts...)(okHandler)
+
+			req := httptest.NewRequest(tt.method, "/documents", nil)
+			req.Header.Set(DefaultUserHeader, "alice")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if checker.action != tt.want {
+				t.Errorf("action = %q, want %q", checker.action, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireStopsCheckWhenRequestCancelled(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer api.Close()
+
+	client := permissio.New(config.NewConfigBuilder("permis_key_test").
+		WithApiUrl(api.URL).
+		WithProjectID("proj").
+		WithEnvironmentID("env").
+		WithRetryAttempts(0).
+		WithThrowOnError(true).
+		Build())
+	handler := Require(client, "read", "document")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler called for a cancelled request")
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/documents", nil).WithContext(ctx)
+	req.Header.Set(DefaultUserHeader, "alice")
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("check took %v after the request was cancelled", elapsed)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
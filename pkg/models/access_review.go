@@ -0,0 +1,28 @@
+package models
+
+// AccessReview describes a user's role assignments joined with their role definitions.
+type AccessReview struct {
+	User   string             `json:"user"`
+	Tenant string             `json:"tenant,omitempty"`
+	Roles  []RoleAccessReview `json:"roles"`
+}
+
+// RoleAccessReview describes a single assignment and the permissions its role grants.
+type RoleAccessReview struct {
+	Assignment RoleAssignmentRead `json:"assignment"`
+
+	// Role is the assigned role's definition, or nil if the role no longer exists.
+	Role *RoleRead `json:"role,omitempty"`
+
+	// Active reports whether the assignment's time window includes the evaluation time.
+	Active bool `json:"active"`
+
+	// DirectPermissions are the permissions defined on the role itself.
+	DirectPermissions []string `json:"directPermissions"`
+
+	// InheritedPermissions are the permissions granted only through extended roles.
+	InheritedPermissions []string `json:"inheritedPermissions"`
+
+	// ExtendsChain lists every role inherited from, nearest first.
+	ExtendsChain []string `json:"extendsChain"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "net/http"
+
 // CheckRequest represents a permission check request.
 type CheckRequest struct {
 	User     interface{}            `json:"user"`
@@ -10,17 +12,47 @@ type CheckRequest struct {
 }
 
 // CheckResponse represents a permission check response.
+//
+// Responses returned by the client have the same JSON shape whether the check
+// was evaluated locally or by a PDP: "allowed", "reason" and "debug" are always
+// present, and so are the debug fields "matchedRoles" and "matchedPermissions"
+// (as empty arrays on deny) and "requiredPermission". The remaining debug
+// fields are omitted when empty, as they only apply to some checks.
 type CheckResponse struct {
-	Allowed bool              `json:"allowed"`
-	Reason  string            `json:"reason,omitempty"`
-	Debug   *CheckDebugInfo   `json:"debug,omitempty"`
+	Allowed bool            `json:"allowed"`
+	Reason  string          `json:"reason"`
+	Debug   *CheckDebugInfo `json:"debug"`
 }
 
 // CheckDebugInfo contains debug information from a permission check.
 type CheckDebugInfo struct {
-	MatchedRoles       []string `json:"matchedRoles,omitempty"`
-	MatchedPermissions []string `json:"matchedPermissions,omitempty"`
+	MatchedRoles       []string `json:"matchedRoles"`
+	MatchedPermissions []string `json:"matchedPermissions"`
 	EvaluationTime     int64    `json:"evaluationTime,omitempty"`
+
+	// RequiredPermission is the "resourceType:action" string the check
+	// compared against role permissions, on both allow and deny.
+	RequiredPermission string `json:"requiredPermission"`
+
+	// UnresolvedExtends lists parent roles that the user's roles extend but
+	// that could not be found, so their permissions were not inherited.
+	UnresolvedExtends []string `json:"unresolvedExtends,omitempty"`
+
+	// TruncatedRoles lists the user's roles whose inheritance was cut short
+	// by the configured maximum inheritance depth.
+	TruncatedRoles []string `json:"truncatedRoles,omitempty"`
+
+	// EmptyRoles lists the user's roles that grant no permissions at all,
+	// directly or inherited. A deny caused by them is otherwise silent.
+	EmptyRoles []string `json:"emptyRoles,omitempty"`
+
+	// PolicyRules lists the policy rules the PDP reports as deciding the
+	// check. Only set in PDP mode, when the PDP reports them.
+	PolicyRules []string `json:"policyRules,omitempty"`
+
+	// DecisionID identifies the PDP decision, for looking it up in the PDP's
+	// decision logs. Only set in PDP mode, when the PDP returns one.
+	DecisionID string `json:"decisionId,omitempty"`
 }
 
 // BulkCheckRequest represents a bulk permission check request.
@@ -30,6 +62,9 @@ type BulkCheckRequest struct {
 
 // BulkCheckResult represents a single result in a bulk check response.
 type BulkCheckResult struct {
+	// Index is the position of Request in the submitted checks.
+	Index int `json:"index"`
+
 	Request  CheckRequest  `json:"request"`
 	Response CheckResponse `json:"response"`
 }
@@ -44,10 +79,43 @@ type GetPermissionsRequest struct {
 	User     string `json:"user"`
 	Tenant   string `json:"tenant,omitempty"`
 	Resource string `json:"resource,omitempty"`
+
+	// ExpandWildcards expands wildcard permissions (e.g. "document:*", "*:*")
+	// into the concrete permissions of the resource catalog that a check
+	// would allow. Like checks, "*:action" permissions grant nothing.
+	ExpandWildcards bool `json:"expandWildcards,omitempty"`
 }
 
 // GetPermissionsResponse represents the response containing user permissions.
 type GetPermissionsResponse struct {
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
+
+	// ExpandedPermissions lists concrete "resourceType:action" permissions.
+	// Only populated when ExpandWildcards is requested.
+	ExpandedPermissions []string `json:"expandedPermissions,omitempty"`
+}
+
+// Normalize fills in the fields of the stable JSON contract that are unset:
+// Debug, and its MatchedRoles and MatchedPermissions, become empty rather
+// than nil.
+func (r *CheckResponse) Normalize() {
+	if r.Debug == nil {
+		r.Debug = &CheckDebugInfo{}
+	}
+	if r.Debug.MatchedRoles == nil {
+		r.Debug.MatchedRoles = []string{}
+	}
+	if r.Debug.MatchedPermissions == nil {
+		r.Debug.MatchedPermissions = []string{}
+	}
+}
+
+// HTTPStatus returns the HTTP status for the decision: 200 if allowed,
+// 403 if denied.
+func (r *CheckResponse) HTTPStatus() int {
+	if r.Allowed {
+		return http.StatusOK
+	}
+	return http.StatusForbidden
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCheckResponseHTTPMapping(t *testing.T) {
+	allowed := &CheckResponse{Allowed: true, Reason: "Granted by role(s): editor"}
+	if allowed.HTTPStatus() != http.StatusOK {
+		t.Errorf("allowed: status = %d, want 200", allowed.HTTPStatus())
+	}
+
+	denied := &CheckResponse{Reason: "No role grants permission document:delete"}
+	if denied.HTTPStatus() != http.StatusForbidden {
+		t.Errorf("denied: status = %d, want 403", denied.HTTPStatus())
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Permission represents a parsed "resourceType:action" permission string.
+type Permission struct {
+	ResourceType string
+	Action       string
+}
+
+// String returns the permission in "resourceType:action" format.
+func (p Permission) String() string {
+	return p.ResourceType + ":" + p.Action
+}
+
+// IsWildcard returns true if either part of the permission is the "*"
+// wildcard, as in "document:*" or "*:*".
+func (p Permission) IsWildcard() bool {
+	return p.ResourceType == "*" || p.Action == "*"
+}
+
+// ParsePermission parses a permission in "resourceType:action" format.
+// Either part may be the "*" wildcard.
+func ParsePermission(s string) (Permission, error) {
+	resourceType, action, ok := strings.Cut(s, ":")
+	if !ok {
+		for _, sep := range []string{".", "/"} {
+			if strings.Contains(s, sep) {
+				return Permission{}, fmt.Errorf("invalid permission %q: use 'resourceType:action' (':' instead of '%s')", s, sep)
+			}
+		}
+		return Permission{}, fmt.Errorf("invalid permission %q: expected 'resourceType:action'", s)
+	}
+	if resourceType == "" || action == "" || strings.Contains(action, ":") {
+		return Permission{}, fmt.Errorf("invalid permission %q: expected 'resourceType:action'", s)
+	}
+	return Permission{ResourceType: resourceType, Action: action}, nil
+}
+
+// ValidatePermission returns an error if s is not in "resourceType:action" format.
+// Permissions written as "document.read" or "document/read" never match a check.
+func ValidatePermission(s string) error {
+	_, err := ParsePermission(s)
+	return err
+}
+
+// ValidatePermissions validates each permission, returning the first error.
+func ValidatePermissions(permissions []string) error {
+	for _, perm := range permissions {
+		if err := ValidatePermission(perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PermissionMatches returns true if the granted permission allows action on
+// resourceType. A permission matches exactly ("document:read"), through a
+// resource wildcard ("document:*"), or through the global wildcard ("*:*").
+func PermissionMatches(granted, resourceType, action string) bool {
+	return granted == resourceType+":"+action ||
+		granted == resourceType+":*" ||
+		granted == "*:*"
+}
+
+// PermissionPatternMatches returns true if pattern matches action on
+// resourceType, where either side of the pattern may be the "*" wildcard
+// (e.g. "*:delete", "document:*", "*:*").
+func PermissionPatternMatches(pattern, resourceType, action string) bool {
+	patternType, patternAction, ok := strings.Cut(pattern, ":")
+	if !ok {
+		return false
+	}
+	return (patternType == "*" || patternType == resourceType) &&
+		(patternAction == "*" || patternAction == action)
+}
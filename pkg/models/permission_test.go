@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+func TestValidatePermission(t *testing.T) {
+	tests := []struct {
+		permission string
+		valid      bool
+	}{
+		{"document:read", true},
+		{"document:*", true},
+		{"*:*", true},
+		{"document.read", false},
+		{"document/read", false},
+		{"document", false},
+		{":read", false},
+		{"document:", false},
+		{"a:b:c", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePermission(tt.permission)
+		if tt.valid && err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.permission, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%q: expected an error", tt.permission)
+		}
+	}
+}
+
+func TestParsePermission(t *testing.T) {
+	p, err := ParsePermission("document:read")
+	if err != nil {
+		t.Fatalf("ParsePermission() error: %v", err)
+	}
+	if p.ResourceType != "document" || p.Action != "read" || p.String() != "document:read" {
+		t.Errorf("unexpected permission %+v", p)
+	}
+}
+
+func TestPermissionPatternMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   bool
+	}{
+		{"document:delete", true},
+		{"*:delete", true},
+		{"document:*", true},
+		{"*:*", true},
+		{"*:read", false},
+		{"report:*", false},
+		{"document", false},
+	}
+
+	for _, tt := range tests {
+		if got := PermissionPatternMatches(tt.pattern, "document", "delete"); got != tt.match {
+			t.Errorf("PermissionPatternMatches(%q) = %v, want %v", tt.pattern, got, tt.match)
+		}
+	}
+}
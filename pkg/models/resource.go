@@ -86,6 +86,15 @@ func (r *ResourceCreate) SetAttributes(attributes map[string]interface{}) *Resou
 	return r
 }
 
+// SetAttribute sets a single custom attribute for the resource.
+func (r *ResourceCreate) SetAttribute(key string, value interface{}) *ResourceCreate {
+	if r.Attributes == nil {
+		r.Attributes = make(map[string]interface{})
+	}
+	r.Attributes[key] = value
+	return r
+}
+
 // ResourceUpdate represents the data for updating a resource.
 type ResourceUpdate struct {
 	Name        *string                `json:"name,omitempty"`
@@ -121,6 +130,14 @@ func (r ResourceUpdate) MarshalJSON() ([]byte, error) {
 	return json.Marshal(a)
 }
 
+// ResourceAction describes an action on a resource type, with its display
+// name and description for UI rendering.
+type ResourceAction struct {
+	Key         string `json:"key"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
 // ResourceRead represents a resource returned from the API.
 type ResourceRead struct {
 	ID          string                 `json:"id"`
@@ -203,6 +220,35 @@ type ResourceInstanceCreate struct {
 	Attributes   map[string]interface{} `json:"attributes,omitempty"`
 }
 
+// NewResourceInstanceCreate creates a new ResourceInstanceCreate for the given resource type and key.
+func NewResourceInstanceCreate(resourceType, key string) *ResourceInstanceCreate {
+	return &ResourceInstanceCreate{
+		Key:          key,
+		ResourceType: resourceType,
+	}
+}
+
+// SetTenant sets the tenant for the resource instance.
+func (r *ResourceInstanceCreate) SetTenant(tenant string) *ResourceInstanceCreate {
+	r.Tenant = tenant
+	return r
+}
+
+// SetAttributes sets custom attributes for the resource instance.
+func (r *ResourceInstanceCreate) SetAttributes(attributes map[string]interface{}) *ResourceInstanceCreate {
+	r.Attributes = attributes
+	return r
+}
+
+// SetAttribute sets a single custom attribute for the resource instance.
+func (r *ResourceInstanceCreate) SetAttribute(key string, value interface{}) *ResourceInstanceCreate {
+	if r.Attributes == nil {
+		r.Attributes = make(map[string]interface{})
+	}
+	r.Attributes[key] = value
+	return r
+}
+
 // ResourceInstanceRead represents a resource instance returned from the API.
 type ResourceInstanceRead struct {
 	ID           string                 `json:"id"`
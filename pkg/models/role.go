@@ -51,6 +51,15 @@ func (r *RoleCreate) SetAttributes(attributes map[string]interface{}) *RoleCreat
 	return r
 }
 
+// SetAttribute sets a single custom attribute for the role.
+func (r *RoleCreate) SetAttribute(key string, value interface{}) *RoleCreate {
+	if r.Attributes == nil {
+		r.Attributes = make(map[string]interface{})
+	}
+	r.Attributes[key] = value
+	return r
+}
+
 // RoleUpdate represents the data for updating a role.
 type RoleUpdate struct {
 	Name        *string                `json:"name,omitempty"`
@@ -84,3 +93,38 @@ type RoleListParams struct {
 	ListParams
 	Search string `json:"search,omitempty"`
 }
+
+// PermissionSource describes where a role gets a permission from.
+type PermissionSource struct {
+	// Role is the role that was inspected.
+	Role string `json:"role"`
+
+	// Permission is the permission that was looked up.
+	Permission string `json:"permission"`
+
+	// Granted is true if the role has the permission, directly or inherited.
+	Granted bool `json:"granted"`
+
+	// Direct is true if the permission is defined on the role itself.
+	Direct bool `json:"direct"`
+
+	// GrantedBy is the role that defines the granting permission.
+	GrantedBy string `json:"grantedBy,omitempty"`
+
+	// GrantingPermission is the permission that matched (e.g. "document:*").
+	GrantingPermission string `json:"grantingPermission,omitempty"`
+
+	// Path is the inheritance path from Role to GrantedBy, inclusive.
+	Path []string `json:"path,omitempty"`
+}
+
+// RoleWildcards lists the wildcard permissions ("document:*", "*:*") a role
+// grants.
+type RoleWildcards struct {
+	// Direct are the wildcard permissions defined on the role itself.
+	Direct []string `json:"direct"`
+
+	// Inherited are the wildcard permissions granted only through extended
+	// roles.
+	Inherited []string `json:"inherited"`
+}
@@ -1,12 +1,28 @@
 package models
 
+import (
+	"sort"
+	"time"
+)
+
 // RoleAssignmentCreate represents the data for creating a role assignment.
+//
+// StartsAt and ExpiresAt bound the assignment to a time window. They are
+// always honored by client-side checks; backends that don't support
+// expirations ignore them.
+//
+// Attributes are conditions on the grant (e.g. {"region": "eu"}). Client-side
+// checks evaluate them only when client-side ABAC is enabled; server-side
+// evaluation requires backend support.
 type RoleAssignmentCreate struct {
-	User             string `json:"user"`
-	Role             string `json:"role"`
-	Tenant           string `json:"tenant,omitempty"`
-	Resource         string `json:"resource,omitempty"`
-	ResourceInstance string `json:"resource_instance,omitempty"`
+	User             string                 `json:"user"`
+	Role             string                 `json:"role"`
+	Tenant           string                 `json:"tenant,omitempty"`
+	Resource         string                 `json:"resource,omitempty"`
+	ResourceInstance string                 `json:"resource_instance,omitempty"`
+	StartsAt         *time.Time             `json:"starts_at,omitempty"`
+	ExpiresAt        *time.Time             `json:"expires_at,omitempty"`
+	Attributes       map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // NewRoleAssignmentCreate creates a new RoleAssignmentCreate.
@@ -35,28 +51,139 @@ func (r *RoleAssignmentCreate) SetResourceInstance(resourceInstance string) *Rol
 	return r
 }
 
+// SetStartsAt sets the time from which the role assignment is active.
+func (r *RoleAssignmentCreate) SetStartsAt(startsAt time.Time) *RoleAssignmentCreate {
+	r.StartsAt = &startsAt
+	return r
+}
+
+// SetExpiresAt sets the time at which the role assignment expires.
+func (r *RoleAssignmentCreate) SetExpiresAt(expiresAt time.Time) *RoleAssignmentCreate {
+	r.ExpiresAt = &expiresAt
+	return r
+}
+
+// SetAttributes sets the conditions for the role assignment.
+func (r *RoleAssignmentCreate) SetAttributes(attributes map[string]interface{}) *RoleAssignmentCreate {
+	r.Attributes = attributes
+	return r
+}
+
+// SetAttribute sets a single condition for the role assignment.
+func (r *RoleAssignmentCreate) SetAttribute(key string, value interface{}) *RoleAssignmentCreate {
+	if r.Attributes == nil {
+		r.Attributes = make(map[string]interface{})
+	}
+	r.Attributes[key] = value
+	return r
+}
+
 // RoleAssignmentRead represents a role assignment returned from the API.
 type RoleAssignmentRead struct {
-	ID               string `json:"id"`
-	User             string `json:"user"`
-	Role             string `json:"role"`
-	Tenant           string `json:"tenant,omitempty"`
-	Resource         string `json:"resource,omitempty"`
-	ResourceInstance string `json:"resource_instance,omitempty"`
-	UserID           string `json:"user_id,omitempty"`
-	RoleID           string `json:"role_id,omitempty"`
-	TenantID         string `json:"tenant_id,omitempty"`
-	OrganizationID   string `json:"organization_id,omitempty"`
-	ProjectID        string `json:"project_id,omitempty"`
-	EnvironmentID    string `json:"environment_id,omitempty"`
-	CreatedAt        string `json:"created_at"`
-	UpdatedAt        string `json:"updated_at,omitempty"`
+	ID               string     `json:"id"`
+	User             string     `json:"user"`
+	Role             string     `json:"role"`
+	Tenant           string     `json:"tenant,omitempty"`
+	Resource         string     `json:"resource,omitempty"`
+	ResourceInstance string     `json:"resource_instance,omitempty"`
+	UserID           string     `json:"user_id,omitempty"`
+	RoleID           string     `json:"role_id,omitempty"`
+	TenantID         string     `json:"tenant_id,omitempty"`
+	OrganizationID   string     `json:"organization_id,omitempty"`
+	ProjectID        string     `json:"project_id,omitempty"`
+	EnvironmentID    string     `json:"environment_id,omitempty"`
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	CreatedAt        string     `json:"created_at"`
+	UpdatedAt        string     `json:"updated_at,omitempty"`
+
+	// Attributes are the grant's conditions (see RoleAssignmentCreate).
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// ActiveAt returns true if the assignment's time window includes t.
+// Assignments without StartsAt/ExpiresAt are always active.
+func (r RoleAssignmentRead) ActiveAt(t time.Time) bool {
+	if r.StartsAt != nil && t.Before(*r.StartsAt) {
+		return false
+	}
+	if r.ExpiresAt != nil && !t.Before(*r.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// ExpiredAt returns true if the assignment has an ExpiresAt at or before t.
+// Assignments without ExpiresAt never expire.
+func (r RoleAssignmentRead) ExpiredAt(t time.Time) bool {
+	return r.ExpiresAt != nil && !t.Before(*r.ExpiresAt)
+}
+
+// RoleAssignmentWithRole is a role assignment joined with its role's display name.
+type RoleAssignmentWithRole struct {
+	RoleAssignmentRead
+
+	// RoleName is the role's display name, or its key if the role has no
+	// name or no longer exists.
+	RoleName string `json:"role_name"`
+
+	// RoleFound is false when the assignment references a role that no longer exists.
+	RoleFound bool `json:"role_found"`
 }
 
 // RoleAssignmentList represents a list of role assignments.
 // Note: The API returns an array directly, not a paginated object.
 type RoleAssignmentList []RoleAssignmentRead
 
+// CreatedTime parses CreatedAt as an RFC 3339 timestamp.
+func (r RoleAssignmentRead) CreatedTime() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, r.CreatedAt)
+}
+
+// SortByCreatedAt sorts assignments by creation time, oldest first.
+// Assignments with unparseable timestamps are sorted last.
+func SortByCreatedAt(assignments RoleAssignmentList) {
+	sortByCreatedAt(assignments, false)
+}
+
+// SortByCreatedAtDesc sorts assignments by creation time, most recent first.
+// Assignments with unparseable timestamps are sorted last.
+func SortByCreatedAtDesc(assignments RoleAssignmentList) {
+	sortByCreatedAt(assignments, true)
+}
+
+// sortByCreatedAt stably sorts assignments by creation time.
+func sortByCreatedAt(assignments RoleAssignmentList, descending bool) {
+	type entry struct {
+		assignment RoleAssignmentRead
+		created    time.Time
+		valid      bool
+	}
+
+	entries := make([]entry, len(assignments))
+	for i, assignment := range assignments {
+		created, err := assignment.CreatedTime()
+		entries[i] = entry{assignment: assignment, created: created, valid: err == nil}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].valid != entries[j].valid {
+			return entries[i].valid
+		}
+		if !entries[i].valid {
+			return false
+		}
+		if descending {
+			return entries[i].created.After(entries[j].created)
+		}
+		return entries[i].created.Before(entries[j].created)
+	})
+
+	for i, e := range entries {
+		assignments[i] = e.assignment
+	}
+}
+
 // RoleAssignmentListParams represents parameters for listing role assignments.
 type RoleAssignmentListParams struct {
 	ListParams
@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestSortByCreatedAt(t *testing.T) {
+	assignments := func() RoleAssignmentList {
+		return RoleAssignmentList{
+			{ID: "bad", CreatedAt: "not-a-time"},
+			{ID: "new", CreatedAt: "2026-03-01T00:00:00Z"},
+			{ID: "empty", CreatedAt: ""},
+			{ID: "old", CreatedAt: "2025-01-01T00:00:00.123Z"},
+			{ID: "mid", CreatedAt: "2025-06-01T00:00:00+02:00"},
+		}
+	}
+
+	asc := assignments()
+	SortByCreatedAt(asc)
+	assertOrder(t, asc, "old", "mid", "new", "bad", "empty")
+
+	desc := assignments()
+	SortByCreatedAtDesc(desc)
+	assertOrder(t, desc, "new", "mid", "old", "bad", "empty")
+}
+
+// assertOrder fails the test if assignments aren't in the given ID order.
+func assertOrder(t *testing.T, assignments RoleAssignmentList, ids ...string) {
+	t.Helper()
+	for i, id := range ids {
+		if assignments[i].ID != id {
+			got := make([]string, len(assignments))
+			for j, a := range assignments {
+				got[j] = a.ID
+			}
+			t.Fatalf("order = %v, want %v", got, ids)
+		}
+	}
+}
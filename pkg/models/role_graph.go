@@ -0,0 +1,279 @@
+package models
+
+import "sort"
+
+// RoleGraph is the role inheritance hierarchy: one node per role and one edge
+// per Extends relationship. It is meant for drawing the inheritance tree and
+// validating it; the graph may contain cycles, which are reported in Cycles.
+type RoleGraph struct {
+	// Nodes lists the roles, sorted by key.
+	Nodes []RoleGraphNode `json:"nodes"`
+
+	// Edges lists the Extends relationships between known roles, sorted by
+	// role then parent.
+	Edges []RoleGraphEdge `json:"edges"`
+
+	// Unresolved lists the Extends relationships whose parent role does not
+	// exist. They are not part of Edges.
+	Unresolved []RoleGraphEdge `json:"unresolved"`
+
+	// Cycles lists the groups of roles that extend each other, directly or
+	// transitively, each sorted by key. A role extending itself is a cycle of
+	// one. The graph is a DAG when Cycles is empty.
+	Cycles [][]string `json:"cycles"`
+}
+
+// RoleGraphNode is a role of a RoleGraph.
+type RoleGraphNode struct {
+	Key  string `json:"key"`
+	Name string `json:"name,omitempty"`
+
+	// Permissions are the permissions defined on the role itself, without
+	// inherited ones.
+	Permissions []string `json:"permissions"`
+}
+
+// RoleGraphEdge records that Role extends Parent, inheriting its permissions.
+type RoleGraphEdge struct {
+	Role   string `json:"role"`
+	Parent string `json:"parent"`
+}
+
+// NewRoleGraph builds the RoleGraph of roles. Duplicate keys keep the first role.
+func NewRoleGraph(roles []RoleRead) *RoleGraph {
+	graph := &RoleGraph{
+		Nodes:      []RoleGraphNode{},
+		Edges:      []RoleGraphEdge{},
+		Unresolved: []RoleGraphEdge{},
+	}
+
+	known := make(map[string]struct{}, len(roles))
+	var unique []RoleRead
+	for _, role := range roles {
+		if _, ok := known[role.Key]; ok {
+			continue
+		}
+		known[role.Key] = struct{}{}
+		unique = append(unique, role)
+	}
+
+	for _, role := range unique {
+		graph.Nodes = append(graph.Nodes, RoleGraphNode{
+			Key:         role.Key,
+			Name:        role.Name,
+			Permissions: append([]string{}, role.Permissions...),
+		})
+		seen := make(map[string]struct{}, len(role.Extends))
+		for _, parent := range role.Extends {
+			if _, ok := seen[parent]; ok {
+				continue
+			}
+			seen[parent] = struct{}{}
+			edge := RoleGraphEdge{Role: role.Key, Parent: parent}
+			if _, ok := known[parent]; ok {
+				graph.Edges = append(graph.Edges, edge)
+			} else {
+				graph.Unresolved = append(graph.Unresolved, edge)
+			}
+		}
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Key < graph.Nodes[j].Key })
+	sortEdges(graph.Edges)
+	sortEdges(graph.Unresolved)
+	graph.Cycles = graph.DetectCycles()
+	return graph
+}
+
+// Parents returns the roles roleKey extends, sorted.
+func (g *RoleGraph) Parents(roleKey string) []string {
+	var parents []string
+	for _, edge := range g.Edges {
+		if edge.Role == roleKey {
+			parents = append(parents, edge.Parent)
+		}
+	}
+	return parents
+}
+
+// Children returns the roles extending roleKey, sorted.
+func (g *RoleGraph) Children(roleKey string) []string {
+	var children []string
+	for _, edge := range g.Edges {
+		if edge.Parent == roleKey {
+			children = append(children, edge.Role)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// Roots returns the roles that extend no other role, sorted. They are the
+// base of the hierarchy, such as "viewer".
+func (g *RoleGraph) Roots() []string {
+	extending := make(map[string]struct{}, len(g.Edges))
+	for _, edge := range g.Edges {
+		extending[edge.Role] = struct{}{}
+	}
+	return g.keysNotIn(extending)
+}
+
+// Leaves returns the roles no other role extends, sorted. They are the top of
+// the hierarchy, such as "admin".
+func (g *RoleGraph) Leaves() []string {
+	extended := make(map[string]struct{}, len(g.Edges))
+	for _, edge := range g.Edges {
+		extended[edge.Parent] = struct{}{}
+	}
+	return g.keysNotIn(extended)
+}
+
+// TopologicalSort returns the roles ordered so that every role comes after
+// the roles it extends, breaking ties by key. If the graph has cycles, ok is
+// false and the roles in or depending on a cycle are left out.
+func (g *RoleGraph) TopologicalSort() (order []string, ok bool) {
+	pending := make(map[string]int, len(g.Nodes))
+	for _, node := range g.Nodes {
+		pending[node.Key] = 0
+	}
+	for _, edge := range g.Edges {
+		pending[edge.Role]++
+	}
+
+	// Kahn's algorithm, always taking the smallest ready key
+	var ready []string
+	for _, node := range g.Nodes {
+		if pending[node.Key] == 0 {
+			ready = append(ready, node.Key)
+		}
+	}
+	order = make([]string, 0, len(g.Nodes))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		current := ready[0]
+		ready = ready[1:]
+		order = append(order, current)
+
+		for _, child := range g.Children(current) {
+			pending[child]--
+			if pending[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+	return order, len(order) == len(g.Nodes)
+}
+
+// Node returns the role roleKey of the graph, if it has one.
+func (g *RoleGraph) Node(roleKey string) (RoleGraphNode, bool) {
+	i := sort.Search(len(g.Nodes), func(i int) bool { return g.Nodes[i].Key >= roleKey })
+	if i < len(g.Nodes) && g.Nodes[i].Key == roleKey {
+		return g.Nodes[i], true
+	}
+	return RoleGraphNode{}, false
+}
+
+// ExtendedByChains returns every role that extends roleKey, directly or
+// transitively, mapped to its shortest extends chain from the dependent role
+// down to roleKey, e.g. "admin": ["admin", "editor", "viewer"] for viewer.
+func (g *RoleGraph) ExtendedByChains(roleKey string) map[string][]string {
+	// Breadth-first search so the shortest chain is reported
+	chains := map[string][]string{roleKey: {roleKey}}
+	queue := []string{roleKey}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, child := range g.Children(current) {
+			if _, seen := chains[child]; seen {
+				continue
+			}
+			chains[child] = append([]string{child}, chains[current]...)
+			queue = append(queue, child)
+		}
+	}
+
+	delete(chains, roleKey)
+	return chains
+}
+
+// DetectCycles returns the groups of roles that extend each other, computed
+// from Edges as the strongly connected components of the graph (Tarjan's
+// algorithm) with more than one role or a self-reference. Groups are sorted
+// by key and ordered by their first key.
+func (g *RoleGraph) DetectCycles() [][]string {
+	index := make(map[string]int, len(g.Nodes))
+	lowlink := make(map[string]int, len(g.Nodes))
+	onStack := make(map[string]bool)
+	var stack []string
+	cycles := [][]string{}
+
+	var visit func(roleKey string)
+	visit = func(roleKey string) {
+		index[roleKey] = len(index)
+		lowlink[roleKey] = index[roleKey]
+		stack = append(stack, roleKey)
+		onStack[roleKey] = true
+
+		selfLoop := false
+		for _, parent := range g.Parents(roleKey) {
+			if parent == roleKey {
+				selfLoop = true
+			}
+			if _, visited := index[parent]; !visited {
+				visit(parent)
+				lowlink[roleKey] = min(lowlink[roleKey], lowlink[parent])
+			} else if onStack[parent] {
+				lowlink[roleKey] = min(lowlink[roleKey], index[parent])
+			}
+		}
+
+		if lowlink[roleKey] != index[roleKey] {
+			return
+		}
+
+		var members []string
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[member] = false
+			members = append(members, member)
+			if member == roleKey {
+				break
+			}
+		}
+		if len(members) > 1 || selfLoop {
+			sort.Strings(members)
+			cycles = append(cycles, members)
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if _, visited := index[node.Key]; !visited {
+			visit(node.Key)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// keysNotIn returns the node keys missing from exclude, in node order.
+func (g *RoleGraph) keysNotIn(exclude map[string]struct{}) []string {
+	keys := []string{}
+	for _, node := range g.Nodes {
+		if _, ok := exclude[node.Key]; !ok {
+			keys = append(keys, node.Key)
+		}
+	}
+	return keys
+}
+
+// sortEdges sorts edges by role, then parent.
+func sortEdges(edges []RoleGraphEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Role != edges[j].Role {
+			return edges[i].Role < edges[j].Role
+		}
+		return edges[i].Parent < edges[j].Parent
+	})
+}
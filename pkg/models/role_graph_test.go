@@ -0,0 +1,63 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoleGraph(t *testing.T) {
+	graph := NewRoleGraph([]RoleRead{
+		{Key: "admin", Permissions: []string{"document:delete"}, Extends: []string{"editor", "deleted-role"}},
+		{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer"}},
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "auditor", Extends: []string{"viewer"}},
+	})
+
+	if want := []RoleGraphEdge{{"admin", "editor"}, {"auditor", "viewer"}, {"editor", "viewer"}}; !reflect.DeepEqual(graph.Edges, want) {
+		t.Errorf("Edges = %v, want %v", graph.Edges, want)
+	}
+	if want := []RoleGraphEdge{{"admin", "deleted-role"}}; !reflect.DeepEqual(graph.Unresolved, want) {
+		t.Errorf("Unresolved = %v, want %v", graph.Unresolved, want)
+	}
+	if len(graph.Cycles) != 0 {
+		t.Errorf("Cycles = %v, want none", graph.Cycles)
+	}
+	if got, want := graph.Roots(), []string{"viewer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Roots() = %v, want %v", got, want)
+	}
+	if got, want := graph.Leaves(), []string{"admin", "auditor"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Leaves() = %v, want %v", got, want)
+	}
+	order, ok := graph.TopologicalSort()
+	if want := []string{"viewer", "auditor", "editor", "admin"}; !ok || !reflect.DeepEqual(order, want) {
+		t.Errorf("TopologicalSort() = %v, %v, want %v, true", order, ok, want)
+	}
+	want := map[string][]string{"admin": {"admin", "editor", "viewer"}, "auditor": {"auditor", "viewer"}, "editor": {"editor", "viewer"}}
+	if got := graph.ExtendedByChains("viewer"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtendedByChains() = %v, want %v", got, want)
+	}
+	if node, ok := graph.Node("editor"); !ok || node.Key != "editor" {
+		t.Errorf("Node(editor) = %v, %v", node, ok)
+	}
+	if _, ok := graph.Node("deleted-role"); ok {
+		t.Error("Node() found an unresolved parent")
+	}
+}
+
+func TestRoleGraphReportsCycles(t *testing.T) {
+	graph := NewRoleGraph([]RoleRead{
+		{Key: "viewer"},
+		{Key: "cycle-a", Extends: []string{"cycle-b"}},
+		{Key: "cycle-b", Extends: []string{"cycle-a", "viewer"}},
+		{Key: "above-cycle", Extends: []string{"cycle-a"}},
+		{Key: "self", Extends: []string{"self"}},
+	})
+
+	if want := [][]string{{"cycle-a", "cycle-b"}, {"self"}}; !reflect.DeepEqual(graph.Cycles, want) {
+		t.Errorf("Cycles = %v, want %v", graph.Cycles, want)
+	}
+	order, ok := graph.TopologicalSort()
+	if want := []string{"viewer"}; ok || !reflect.DeepEqual(order, want) {
+		t.Errorf("TopologicalSort() = %v, %v, want %v, false", order, ok, want)
+	}
+}
@@ -31,6 +31,15 @@ func (t *TenantCreate) SetAttributes(attributes map[string]interface{}) *TenantC
 	return t
 }
 
+// SetAttribute sets a single custom attribute for the tenant.
+func (t *TenantCreate) SetAttribute(key string, value interface{}) *TenantCreate {
+	if t.Attributes == nil {
+		t.Attributes = make(map[string]interface{})
+	}
+	t.Attributes[key] = value
+	return t
+}
+
 // TenantUpdate represents the data for updating a tenant.
 type TenantUpdate struct {
 	Name        *string                `json:"name,omitempty"`
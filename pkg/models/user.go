@@ -38,6 +38,15 @@ func (u *UserCreate) SetAttributes(attributes map[string]interface{}) *UserCreat
 	return u
 }
 
+// SetAttribute sets a single custom attribute for the user.
+func (u *UserCreate) SetAttribute(key string, value interface{}) *UserCreate {
+	if u.Attributes == nil {
+		u.Attributes = make(map[string]interface{})
+	}
+	u.Attributes[key] = value
+	return u
+}
+
 // UserUpdate represents the data for updating a user.
 type UserUpdate struct {
 	Email      *string                `json:"email,omitempty"`
@@ -70,4 +79,30 @@ type UserListParams struct {
 	Search string `json:"search,omitempty"`
 	Role   string `json:"role,omitempty"`
 	Tenant string `json:"tenant,omitempty"`
+
+	// Roles lists users having any of these roles (in addition to Role).
+	// The API filters on a single role, so several roles are only supported
+	// by ListAll and Count, which query each role and merge the results.
+	Roles []string `json:"roles,omitempty"`
+
+	// Fields asks the API to return only these user fields (e.g. "key",
+	// "email"), to shrink large exports. Fields left out of the projection
+	// hold their zero value in UserRead. A server that doesn't support
+	// projections returns full users. Keep "key" when listing several Roles,
+	// since their results are merged by key.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// BulkUserResponse represents the result of a bulk user operation.
+type BulkUserResponse struct {
+	Deleted int              `json:"deleted"`
+	Failed  int              `json:"failed"`
+	Results []BulkUserResult `json:"results"`
+}
+
+// BulkUserResult represents the outcome for a single user in a bulk operation.
+// Error is empty if the operation succeeded.
+type BulkUserResult struct {
+	Key   string `json:"key"`
+	Error string `json:"error,omitempty"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "testing"
+
+func TestUserCreateSetAttribute(t *testing.T) {
+	user := NewUserCreate("alice").
+		SetAttribute("department", "engineering").
+		SetAttribute("level", 5)
+
+	if len(user.Attributes) != 2 || user.Attributes["department"] != "engineering" || user.Attributes["level"] != 5 {
+		t.Errorf("Attributes = %v", user.Attributes)
+	}
+
+	user.SetAttributes(map[string]interface{}{"team": "core"}).SetAttribute("level", 6)
+	if len(user.Attributes) != 2 || user.Attributes["team"] != "core" || user.Attributes["level"] != 6 {
+		t.Errorf("Attributes after SetAttributes = %v", user.Attributes)
+	}
+}
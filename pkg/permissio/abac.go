@@ -0,0 +1,55 @@
+package permissio
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/permissio/permissio-go/pkg/enforcement"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// conditionalAssignments returns the assignments whose conditions hold for
+// the check. Conditions are only evaluated with client-side ABAC enabled;
+// otherwise assignments are returned unchanged.
+func (c *Client) conditionalAssignments(ctx context.Context, assignments models.RoleAssignmentList, user enforcement.User, resource enforcement.Resource) models.RoleAssignmentList {
+	if !c.config.ClientABAC {
+		return assignments
+	}
+
+	var checkData map[string]interface{}
+	if checkCtx, ok := enforcement.CheckContextFromContext(ctx); ok {
+		checkData = checkCtx.Data()
+	}
+
+	matched := make(models.RoleAssignmentList, 0, len(assignments))
+	for _, assignment := range assignments {
+		if conditionsMet(assignment.Attributes, checkData, resource.Attributes, user.Attributes) {
+			matched = append(matched, assignment)
+		}
+	}
+	return matched
+}
+
+// conditionsMet returns true if every condition equals the value of the same
+// attribute in the first source that defines it. A condition whose attribute
+// is absent from all sources is not met. Values are compared by their string
+// form, so 5 and "5" are equal.
+func conditionsMet(conditions map[string]interface{}, sources ...map[string]interface{}) bool {
+	for key, want := range conditions {
+		got, ok := lookupAttribute(key, sources)
+		if !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupAttribute returns the value of key in the first source that has it.
+func lookupAttribute(key string, sources []map[string]interface{}) (interface{}, bool) {
+	for _, source := range sources {
+		if value, ok := source[key]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
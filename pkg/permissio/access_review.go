@@ -0,0 +1,71 @@
+package permissio
+
+import (
+	"context"
+	"sort"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// GetUserAccessReview returns the user's role assignments joined with their
+// role definitions, separating direct and inherited permissions per role.
+// An empty tenant means the configured default tenant; the review reports the
+// tenant that was used.
+func (c *Client) GetUserAccessReview(ctx context.Context, userKey, tenant string) (*models.AccessReview, error) {
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+
+	tenant = c.config.TenantOrDefault(tenant)
+	assignments, err := c.userAssignments(ctx, userKey, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &models.AccessReview{
+		User:   userKey,
+		Tenant: tenant,
+		Roles:  make([]models.RoleAccessReview, 0, len(assignments)),
+	}
+	if len(assignments) == 0 {
+		return review, nil
+	}
+
+	rolesMap, err := c.fetchRolesMap(ctx, assignments)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator := c.newEvaluator(rolesMap, nil)
+	at := evalTime(ctx)
+	for _, assignment := range assignments {
+		entry := models.RoleAccessReview{
+			Assignment:           assignment,
+			Active:               assignment.ActiveAt(at),
+			DirectPermissions:    []string{},
+			InheritedPermissions: []string{},
+			ExtendsChain:         evaluator.InheritedRoles(assignment.Role),
+		}
+
+		if role, ok := rolesMap[assignment.Role]; ok {
+			entry.Role = role
+			entry.DirectPermissions = append(entry.DirectPermissions, role.Permissions...)
+
+			direct := make(map[string]struct{}, len(role.Permissions))
+			for _, perm := range role.Permissions {
+				direct[perm] = struct{}{}
+			}
+			for _, perm := range evaluator.RolePermissions(assignment.Role) {
+				if _, ok := direct[perm]; !ok {
+					entry.InheritedPermissions = append(entry.InheritedPermissions, perm)
+				}
+			}
+			sort.Strings(entry.InheritedPermissions)
+		}
+
+		review.Roles = append(review.Roles, entry)
+	}
+
+	return review, nil
+}
@@ -0,0 +1,105 @@
+package permissio
+
+import (
+	"context"
+	"sync"
+
+	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// BulkCheckStream is BulkCheck for large batches: it evaluates the checks up
+// to the configured bulk concurrency at a time and emits each result on the
+// returned channel as soon as its check completes. Results arrive in
+// completion order, not input order; use their Index to correlate them with
+// checks. The channel is closed once every result has been emitted, or early
+// when ctx is cancelled or the client is closed, in which case the remaining
+// results are dropped. The caller must drain the channel or cancel ctx.
+func (c *Client) BulkCheckStream(ctx context.Context, checks []models.CheckRequest) (<-chan models.BulkCheckResult, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	// Resolve the scope once, before the workers race to do it
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+
+	results := make(chan models.BulkCheckResult)
+	ctx, cancel := c.withClientLifetime(withBatchRoles(ctx))
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range checks {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range min(c.bulkConcurrency(), len(checks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := c.bulkCheckOne(ctx, i, checks[i])
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(results)
+	}()
+
+	return results, nil
+}
+
+// bulkConcurrency returns the number of checks a bulk check runs in parallel.
+func (c *Client) bulkConcurrency() int {
+	if c.config.BulkConcurrency > 0 {
+		return c.config.BulkConcurrency
+	}
+	return config.DefaultBulkConcurrency
+}
+
+// batchRolesKey is the context key for the roles map shared by the checks of
+// a bulk check.
+type batchRolesKey struct{}
+
+// batchRoles is the roles map of a bulk check, fetched by its first check
+// that needs it.
+type batchRoles struct {
+	once     sync.Once
+	rolesMap map[string]*models.RoleRead
+	err      error
+}
+
+// withBatchRoles returns a copy of ctx on which fetchRolesMap fetches the
+// roles once and shares them between all the checks made with it.
+func withBatchRoles(ctx context.Context) context.Context {
+	return context.WithValue(ctx, batchRolesKey{}, &batchRoles{})
+}
+
+// batchRolesMap returns the batch's roles map if ctx comes from
+// withBatchRoles, fetching it with fetch on first use.
+func batchRolesMap(ctx context.Context, fetch func() (map[string]*models.RoleRead, error)) (map[string]*models.RoleRead, bool, error) {
+	batch, ok := ctx.Value(batchRolesKey{}).(*batchRoles)
+	if !ok {
+		return nil, false, nil
+	}
+	batch.once.Do(func() {
+		batch.rolesMap, batch.err = fetch()
+	})
+	return batch.rolesMap, true, batch.err
+}
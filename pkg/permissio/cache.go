@@ -0,0 +1,318 @@
+package permissio
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// Names of the caches reported in config.CacheEvent.
+const (
+	rolesCache       = "roles"
+	assignmentsCache = "assignments"
+)
+
+// checkCache keeps the roles map and per-user role assignments fetched for
+// client-side checks for the configured CacheTTL, and the tenants found by
+// tenant validation for knownTenantTTL. Cached values are shared between
+// checks and must not be modified.
+type checkCache struct {
+	mu sync.Mutex
+
+	roles        map[string]*models.RoleRead
+	rolesExpires time.Time
+
+	assignments map[assignmentsCacheKey]cachedAssignments
+
+	// nextSweep is when expired assignment entries are next removed, so
+	// that users checked once don't stay in memory for the client's life.
+	nextSweep time.Time
+
+	// tenants maps the tenants found to exist by tenant validation to when
+	// that expires; expired entries are removed at most once per
+	// knownTenantTTL, at nextTenantSweep.
+	tenants         map[string]time.Time
+	nextTenantSweep time.Time
+
+	// parents holds the parent roles fetched by key because the roles list
+	// lacked them, with a nil role for those that don't exist.
+	parents map[string]cachedParent
+
+	hits, misses, evictions atomic.Uint64
+}
+
+// CacheStats reports the activity of the check cache since the client was
+// created or the cache last invalidated.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+
+	// Size is the number of entries held: the roles map counts as one, plus
+	// one per cached user and tenant.
+	Size int
+}
+
+// knownTenantTTL is how long tenant validation trusts that a tenant exists, so
+// deleted tenants are eventually reported and the cache doesn't grow forever.
+const knownTenantTTL = 10 * time.Minute
+
+// cacheNow returns the current time for cache expiry. It is a variable so
+// tests can move the clock.
+var cacheNow = time.Now
+
+// assignmentsCacheKey identifies the role assignments of a user in a tenant.
+type assignmentsCacheKey struct {
+	user   string
+	tenant string
+}
+
+// cachedAssignments is a role assignments cache entry.
+type cachedAssignments struct {
+	assignments models.RoleAssignmentList
+	expires     time.Time
+}
+
+// cachedParent is a parent role cache entry. A zero expires never expires.
+type cachedParent struct {
+	role    *models.RoleRead
+	expires time.Time
+}
+
+// InvalidateCache empties the roles and role assignments cache enabled with
+// config.WithCacheTTL, so the next checks fetch them again, and resets
+// CacheStats. Call it after changing roles or role assignments to have checks
+// reflect the change before the TTL runs out. SyncUser and scope changes
+// invalidate the cache themselves. It also drops the resource catalog used to
+// expand wildcards in GetPermissions, which is kept regardless of the TTL, so
+// call it after a schema change too, the tenants known to exist by tenant
+// validation, and the extended parent roles found missing from the roles list.
+func (c *Client) InvalidateCache() {
+	c.cache.mu.Lock()
+	c.cache.roles = nil
+	c.cache.assignments = nil
+	c.cache.tenants = nil
+	c.cache.parents = nil
+	c.cache.hits.Store(0)
+	c.cache.misses.Store(0)
+	c.cache.evictions.Store(0)
+	c.cache.mu.Unlock()
+
+	c.catalogMu.Lock()
+	defer c.catalogMu.Unlock()
+	c.catalog = nil
+}
+
+// WarmCache fills the cache enabled with config.WithCacheTTL with the roles
+// and with the role assignments of userKeys in tenant (the default tenant if
+// empty), so that the first checks after startup make no requests. Call it
+// during readiness, after Init. It does nothing when caching is disabled.
+func (c *Client) WarmCache(ctx context.Context, tenant string, userKeys ...string) error {
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+	if c.config.CacheTTL <= 0 {
+		return nil
+	}
+
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return err
+	}
+
+	if _, err := c.loadRolesMap(ctx); err != nil {
+		return err
+	}
+	tenant = c.config.TenantOrDefault(tenant)
+	for _, userKey := range userKeys {
+		if _, err := c.userAssignments(ctx, userKey, tenant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CacheStats returns the hits, misses and evictions of the cache enabled with
+// config.WithCacheTTL, and its current size, e.g. to tune the TTL. The counts
+// are reset by InvalidateCache. All values are zero when caching is disabled.
+func (c *Client) CacheStats() CacheStats {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	size := len(c.cache.assignments)
+	if c.cache.roles != nil {
+		size++
+	}
+	return CacheStats{
+		Hits:      c.cache.hits.Load(),
+		Misses:    c.cache.misses.Load(),
+		Evictions: c.cache.evictions.Load(),
+		Size:      size,
+	}
+}
+
+// recordCacheEvent counts a cache event and passes it to the configured
+// observer.
+func (c *Client) recordCacheEvent(kind config.CacheEventKind, cache string) {
+	switch kind {
+	case config.CacheHit:
+		c.cache.hits.Add(1)
+	case config.CacheMiss:
+		c.cache.misses.Add(1)
+	case config.CacheEviction:
+		c.cache.evictions.Add(1)
+	}
+	if c.config.CacheObserver != nil {
+		c.config.CacheObserver(config.CacheEvent{Kind: kind, Cache: cache})
+	}
+}
+
+// userAssignments returns the role assignments of userKey in tenant (all
+// tenants if empty), following every page, from the cache when it holds them.
+func (c *Client) userAssignments(ctx context.Context, userKey, tenant string) (models.RoleAssignmentList, error) {
+	ttl := c.config.CacheTTL
+	key := assignmentsCacheKey{user: userKey, tenant: tenant}
+	if ttl > 0 {
+		c.cache.mu.Lock()
+		entry, ok := c.cache.assignments[key]
+		c.cache.mu.Unlock()
+		if ok && cacheNow().Before(entry.expires) {
+			c.recordCacheEvent(config.CacheHit, assignmentsCache)
+			return entry.assignments, nil
+		}
+		c.recordCacheEvent(config.CacheMiss, assignmentsCache)
+	}
+
+	assignments, err := c.Api.RoleAssignments.ListAll(ctx, &models.RoleAssignmentListParams{
+		User:   userKey,
+		Tenant: tenant,
+	})
+	if err != nil || ttl <= 0 {
+		return assignments, err
+	}
+
+	c.cache.mu.Lock()
+	now := cacheNow()
+	if c.cache.assignments == nil {
+		c.cache.assignments = make(map[assignmentsCacheKey]cachedAssignments)
+	}
+	evicted := 0
+	if !now.Before(c.cache.nextSweep) {
+		// At most once per TTL, so entries live for less than two TTLs
+		for cached, entry := range c.cache.assignments {
+			if !now.Before(entry.expires) {
+				delete(c.cache.assignments, cached)
+				evicted++
+			}
+		}
+		c.cache.nextSweep = now.Add(ttl)
+	}
+	if entry, ok := c.cache.assignments[key]; ok && !now.Before(entry.expires) {
+		evicted++
+	}
+	c.cache.assignments[key] = cachedAssignments{assignments: assignments, expires: now.Add(ttl)}
+	c.cache.mu.Unlock()
+
+	for i := 0; i < evicted; i++ {
+		c.recordCacheEvent(config.CacheEviction, assignmentsCache)
+	}
+	return assignments, nil
+}
+
+// cachedRolesMap returns the roles map cached by fetchRolesMap, if it is fresh.
+func (c *Client) cachedRolesMap() (map[string]*models.RoleRead, bool) {
+	if c.config.CacheTTL <= 0 {
+		return nil, false
+	}
+	c.cache.mu.Lock()
+	rolesMap, fresh := c.cache.roles, c.cache.roles != nil && cacheNow().Before(c.cache.rolesExpires)
+	c.cache.mu.Unlock()
+
+	if !fresh {
+		c.recordCacheEvent(config.CacheMiss, rolesCache)
+		return nil, false
+	}
+	c.recordCacheEvent(config.CacheHit, rolesCache)
+	return rolesMap, true
+}
+
+// cacheRolesMap stores rolesMap for CacheTTL, if caching is enabled.
+func (c *Client) cacheRolesMap(rolesMap map[string]*models.RoleRead) {
+	if c.config.CacheTTL <= 0 {
+		return
+	}
+	c.cache.mu.Lock()
+	now := cacheNow()
+	evicted := c.cache.roles != nil && !now.Before(c.cache.rolesExpires)
+	c.cache.roles = rolesMap
+	c.cache.rolesExpires = now.Add(c.config.CacheTTL)
+	c.cache.mu.Unlock()
+
+	if evicted {
+		c.recordCacheEvent(config.CacheEviction, rolesCache)
+	}
+}
+
+// cachedParent returns the parent role cached by cacheParent, and whether it
+// was cached. A nil role means the parent doesn't exist.
+func (c *Client) cachedParent(roleKey string) (*models.RoleRead, bool) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	entry, ok := c.cache.parents[roleKey]
+	if !ok || (!entry.expires.IsZero() && !cacheNow().Before(entry.expires)) {
+		return nil, false
+	}
+	return entry.role, true
+}
+
+// cacheParent stores a parent role fetched by key, or nil if it doesn't
+// exist, for CacheTTL. Without a cache, only missing parents are stored, and
+// for the client's lifetime.
+func (c *Client) cacheParent(roleKey string, role *models.RoleRead) {
+	ttl := c.config.CacheTTL
+	if ttl <= 0 && role != nil {
+		return
+	}
+	entry := cachedParent{role: role}
+
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if ttl > 0 {
+		entry.expires = cacheNow().Add(ttl)
+	}
+	if c.cache.parents == nil {
+		c.cache.parents = make(map[string]cachedParent)
+	}
+	c.cache.parents[roleKey] = entry
+}
+
+// knownTenant reports whether tenant validation recently found tenant to exist.
+func (c *Client) knownTenant(tenant string) bool {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	expires, ok := c.cache.tenants[tenant]
+	return ok && cacheNow().Before(expires)
+}
+
+// rememberTenant records that tenant exists for knownTenantTTL, removing the
+// expired tenants first if they are due for a sweep.
+func (c *Client) rememberTenant(tenant string) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	now := cacheNow()
+	if c.cache.tenants == nil {
+		c.cache.tenants = make(map[string]time.Time)
+	}
+	if !now.Before(c.cache.nextTenantSweep) {
+		for known, expires := range c.cache.tenants {
+			if !now.Before(expires) {
+				delete(c.cache.tenants, known)
+			}
+		}
+		c.cache.nextTenantSweep = now.Add(knownTenantTTL)
+	}
+	c.cache.tenants[tenant] = now.Add(knownTenantTTL)
+}
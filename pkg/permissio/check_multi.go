@@ -0,0 +1,249 @@
+package permissio
+
+import (
+	"context"
+	"sort"
+
+	"github.com/permissio/permissio-go/pkg/enforcement"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// CheckAnyResourceType reports, for each resource type, whether the user may
+// perform action on it. The user's assignments and the roles are fetched once
+// for all types, which suits rendering menus and feature flags. Role
+// assignments scoped to a resource instance are ignored, since they don't
+// grant anything on the type as a whole. Every requested type is present in
+// the result, including denied ones.
+func (c *Client) CheckAnyResourceType(ctx context.Context, user enforcement.User, action enforcement.Action, resourceTypes []string, tenant string) (map[string]bool, error) {
+	tenant = c.config.TenantOrDefault(tenant)
+	results := make(map[string]bool, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		results[resourceType] = false
+	}
+
+	if c.config.UsePDP() {
+		for _, resourceType := range resourceTypes {
+			allowed, err := c.CheckWithContext(ctx, user, action, enforcement.Resource{Type: resourceType, Tenant: tenant})
+			if err != nil {
+				return nil, err
+			}
+			results[resourceType] = allowed
+		}
+		return results, nil
+	}
+
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+
+	assignments, err := c.userAssignments(ctx, user.Key, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	// The gate is type-wide, so assignments scoped to one instance don't count
+	typeWide := enforcement.Resource{Tenant: tenant}
+	assignments = applicableAssignments(activeAssignments(assignments, evalTime(ctx)), typeWide)
+	assignments = c.conditionalAssignments(ctx, assignments, user, typeWide)
+	if len(assignments) == 0 {
+		return results, nil
+	}
+
+	rolesMap, err := c.fetchRolesMap(ctx, assignments)
+	if err != nil {
+		return nil, err
+	}
+
+	_, permissions := c.collectPermissions(assignments, rolesMap)
+	for _, resourceType := range resourceTypes {
+		results[resourceType] = !c.isDenied(resourceType, string(action)) &&
+			grantsPermission(permissions, resourceType, string(action))
+	}
+
+	return results, nil
+}
+
+// GetInstanceCapabilities returns, for each instance of resourceType, the
+// actions the user may perform on it, e.g. for rendering a file browser.
+// Instance-scoped role assignments only apply to their instance; other
+// assignments apply to every instance. The user's assignments, the roles and
+// the resource catalog are fetched once for all instances. Every requested
+// instance is present in the result, with an empty slice if nothing is allowed.
+func (c *Client) GetInstanceCapabilities(ctx context.Context, user enforcement.User, resourceType string, instanceKeys []string, tenant string) (map[string][]string, error) {
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+	tenant = c.config.TenantOrDefault(tenant)
+
+	catalog, err := c.resourceCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actions := append([]string(nil), catalog[resourceType]...)
+	sort.Strings(actions)
+
+	results := make(map[string][]string, len(instanceKeys))
+	for _, key := range instanceKeys {
+		results[key] = []string{}
+	}
+
+	if c.config.UsePDP() {
+		for _, key := range instanceKeys {
+			resource := enforcement.Resource{Type: resourceType, Key: key, Tenant: tenant}
+			for _, action := range actions {
+				allowed, err := c.CheckWithContext(ctx, user, enforcement.Action(action), resource)
+				if err != nil {
+					return nil, err
+				}
+				if allowed {
+					results[key] = append(results[key], action)
+				}
+			}
+		}
+		return results, nil
+	}
+
+	assignments, err := c.userAssignments(ctx, user.Key, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments = activeAssignments(assignments, evalTime(ctx))
+	if len(assignments) == 0 {
+		return results, nil
+	}
+
+	rolesMap, err := c.fetchRolesMap(ctx, assignments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split type-wide assignments from those scoped to a single instance
+	var shared models.RoleAssignmentList
+	scoped := make(map[string]models.RoleAssignmentList)
+	for _, assignment := range assignments {
+		switch {
+		case assignment.ResourceInstance == "":
+			shared = append(shared, assignment)
+		case assignment.Resource == "" || assignment.Resource == resourceType:
+			scoped[assignment.ResourceInstance] = append(scoped[assignment.ResourceInstance], assignment)
+		}
+	}
+
+	for _, key := range instanceKeys {
+		resource := enforcement.Resource{Type: resourceType, Key: key, Tenant: tenant}
+		applicable := append(append(models.RoleAssignmentList(nil), shared...), scoped[key]...)
+		applicable = c.conditionalAssignments(ctx, applicable, user, resource)
+		_, permissions := c.collectPermissions(applicable, rolesMap)
+
+		for _, action := range actions {
+			if !c.isDenied(resourceType, action) && grantsPermission(permissions, resourceType, action) {
+				results[key] = append(results[key], action)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// FilterAuthorized returns the resources the user may perform action on, in
+// their original order, e.g. to filter a listing before returning it.
+//
+// Each resource is evaluated with its own type, key, tenant and attributes.
+// Instance-scoped role assignments only apply to their instance. With
+// client-side ABAC enabled, assignment conditions are matched against each
+// resource's Attributes, so decisions can differ between instances of the
+// same type. The user's assignments (once per tenant) and the roles are
+// fetched once for all resources.
+func (c *Client) FilterAuthorized(ctx context.Context, user enforcement.User, action enforcement.Action, resources []enforcement.Resource) ([]enforcement.Resource, error) {
+	authorized := make([]enforcement.Resource, 0, len(resources))
+
+	if c.config.UsePDP() {
+		for _, resource := range resources {
+			allowed, err := c.CheckWithContext(ctx, user, action, resource)
+			if err != nil {
+				return nil, err
+			}
+			if allowed {
+				authorized = append(authorized, resource)
+			}
+		}
+		return authorized, nil
+	}
+
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+
+	at := evalTime(ctx)
+	assignmentsByTenant := make(map[string]models.RoleAssignmentList)
+	var rolesMap map[string]*models.RoleRead
+
+	for i, resource := range resources {
+		resource.Tenant = c.config.TenantOrDefault(resource.Tenant)
+		if c.isDenied(resource.Type, string(action)) {
+			continue
+		}
+
+		assignments, ok := assignmentsByTenant[resource.Tenant]
+		if !ok {
+			listed, err := c.userAssignments(ctx, user.Key, resource.Tenant)
+			if err != nil {
+				return nil, err
+			}
+			assignments = activeAssignments(listed, at)
+			assignmentsByTenant[resource.Tenant] = assignments
+		}
+
+		applicable := applicableAssignments(assignments, resource)
+		applicable = c.conditionalAssignments(ctx, applicable, user, resource)
+		if len(applicable) == 0 {
+			continue
+		}
+
+		if rolesMap == nil {
+			var err error
+			if rolesMap, err = c.fetchRolesMap(ctx, applicable); err != nil {
+				return nil, err
+			}
+		} else {
+			rolesMap = c.resolveMissingParents(ctx, rolesMap, applicable)
+		}
+
+		if allowed, _ := c.newEvaluator(rolesMap, applicable).Allowed(action, resource); allowed {
+			authorized = append(authorized, resources[i])
+		}
+	}
+
+	return authorized, nil
+}
+
+// assignmentAppliesTo returns true if the assignment applies to the resource:
+// it isn't scoped to an instance, or is scoped to this one.
+func assignmentAppliesTo(assignment models.RoleAssignmentRead, resource enforcement.Resource) bool {
+	if assignment.ResourceInstance == "" {
+		return true
+	}
+	return assignment.ResourceInstance == resource.Key &&
+		(assignment.Resource == "" || assignment.Resource == resource.Type)
+}
+
+// applicableAssignments returns the assignments that apply to the resource.
+// A resource without a key only gets the assignments not scoped to an instance.
+func applicableAssignments(assignments models.RoleAssignmentList, resource enforcement.Resource) models.RoleAssignmentList {
+	applicable := make(models.RoleAssignmentList, 0, len(assignments))
+	for _, assignment := range assignments {
+		if assignmentAppliesTo(assignment, resource) {
+			applicable = append(applicable, assignment)
+		}
+	}
+	return applicable
+}
+
+// grantsPermission returns true if any permission allows action on resourceType.
+func grantsPermission(permissions []string, resourceType, action string) bool {
+	return enforcement.CheckAgainst(permissions, enforcement.Action(action), enforcement.Resource{Type: resourceType})
+}
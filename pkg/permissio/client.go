@@ -4,11 +4,13 @@ package permissio
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/permissio/permissio-go/pkg/api"
 	"github.com/permissio/permissio-go/pkg/config"
@@ -17,13 +19,14 @@ import (
 	"go.uber.org/zap"
 )
 
-// Api contains all API clients.
+// Api contains all API clients. The fields hold interfaces so that tests can
+// replace them with mocks, such as those in the permittest package.
 type Api struct {
-	Users           *api.UsersAPI
-	Tenants         *api.TenantsAPI
-	Roles           *api.RolesAPI
-	Resources       *api.ResourcesAPI
-	RoleAssignments *api.RoleAssignmentsAPI
+	Users           api.UsersClient
+	Tenants         api.TenantsClient
+	Roles           api.RolesClient
+	Resources       api.ResourcesClient
+	RoleAssignments api.RoleAssignmentsClient
 }
 
 // Client is the main Permissio.io SDK client.
@@ -34,17 +37,49 @@ type Client struct {
 	// config holds the SDK configuration.
 	config *config.Config
 
+	// base performs requests outside the API groups (PDP checks, raw requests).
+	base *api.BaseClient
+
 	// scopeInitialized tracks if scope has been fetched.
 	scopeInitialized bool
 
 	// scopeMu protects scope initialization.
 	scopeMu sync.Mutex
+
+	// scopeFetchedAt is when the scope was last fetched or resolved from the
+	// API, zero for a configured scope. It is guarded by scopeInfoMu.
+	scopeFetchedAt time.Time
+	scopeInfoMu    sync.RWMutex
+
+	// warnedPermissions records malformed permissions already logged.
+	warnedPermissions sync.Map
+
+	// warnedParents records missing extended parent roles already logged.
+	warnedParents sync.Map
+
+	// warnedDepth records roles whose too-deep inheritance was already logged.
+	warnedDepth sync.Map
+
+	// catalog caches resource types and their actions for wildcard expansion.
+	catalog map[string][]string
+
+	// catalogMu protects catalog.
+	catalogMu sync.Mutex
+
+	// cache holds roles and role assignments for CacheTTL.
+	cache checkCache
+
+	// closeCtx is cancelled by Close to stop background goroutines.
+	closeCtx    context.Context
+	closeCancel context.CancelFunc
 }
 
 // New creates a new Permissio.io SDK client.
 func New(cfg *config.Config) *Client {
-	return &Client{
+	closeCtx, closeCancel := context.WithCancel(context.Background())
+	c := &Client{
 		config: cfg,
+		base:   api.NewBaseClient(cfg),
 		Api: &Api{
 			Users:           api.NewUsersAPI(cfg),
 			Tenants:         api.NewTenantsAPI(cfg),
@@ -52,15 +87,38 @@ func New(cfg *config.Config) *Client {
 			Resources:       api.NewResourcesAPI(cfg),
 			RoleAssignments: api.NewRoleAssignmentsAPI(cfg),
 		},
+		closeCtx:    closeCtx,
+		closeCancel: closeCancel,
+	}
+
+	// Only auto-fetched scopes are refreshed; a configured scope is kept as is
+	if cfg.ScopeRefreshInterval > 0 && !cfg.DisableAutoScope && !cfg.HasScope() && cfg.EnvironmentSlug == "" {
+		go c.refreshScopePeriodically(cfg.ScopeRefreshInterval)
 	}
+	return c
 }
 
 // Check performs a permission check.
 // Returns true if the user is allowed to perform the action on the resource.
+//
+// Since Check takes no context, it is always bounded by a deadline so that a
+// hung connection can't block the caller forever: the configured check
+// timeout, or config.DefaultCheckTimeout if none is set. Use CheckWithContext
+// to control the deadline.
 func (c *Client) Check(user enforcement.User, action enforcement.Action, resource enforcement.Resource) (bool, error) {
-	return c.CheckWithContext(context.Background(), user, action, resource)
+	ctx := context.Background()
+	if c.config.CheckTimeout <= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultCheckTimeout)
+		defer cancel()
+	}
+	return c.CheckWithContext(ctx, user, action, resource)
 }
 
+// defaultCheckTimeout bounds Check when no check timeout is configured.
+// It is a variable so tests can shorten it.
+var defaultCheckTimeout = config.DefaultCheckTimeout
+
 // CheckWithContext performs a permission check with context.
 func (c *Client) CheckWithContext(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (bool, error) {
 	response, err := c.CheckWithDetails(ctx, user, action, resource)
@@ -70,12 +128,89 @@ func (c *Client) CheckWithContext(ctx context.Context, user enforcement.User, ac
 	return response.Allowed, nil
 }
 
+// CheckPermission performs a permission check for a permission string in
+// "resourceType:action" format, such as "document:read", on the resource type
+// in tenant (the default tenant if empty). It suits gates whose permissions
+// are stored as strings. A malformed or wildcard permission is an error.
+func (c *Client) CheckPermission(ctx context.Context, user enforcement.User, permission string, tenant string) (bool, error) {
+	parsed, err := models.ParsePermission(permission)
+	if err != nil {
+		return false, err
+	}
+	if parsed.IsWildcard() {
+		return false, fmt.Errorf("invalid permission %q: a checked permission cannot contain wildcards", permission)
+	}
+	return c.CheckWithContext(ctx, user, enforcement.Action(parsed.Action), enforcement.Resource{
+		Type:   parsed.ResourceType,
+		Tenant: tenant,
+	})
+}
+
 // CheckWithDetails performs a permission check and returns full response details.
 // This performs client-side permission checking by:
 // 1. Fetching user's role assignments
 // 2. Fetching role definitions with permissions
 // 3. Checking if any role grants the required permission
+//
+// When a PDP URL is configured, the check is delegated to the PDP instead.
+// Permissions matching a configured denied pattern are always denied.
+// The check is bounded by the configured check timeout, if any, and an empty
+// resource tenant is replaced by the configured default tenant. A resource
+// instance left without a tenant fails with enforcement.ErrInstanceWithoutTenant.
+// Role assignments scoped to a resource instance only apply to checks on that
+// instance, as in FilterAuthorized and GetInstanceCapabilities.
 func (c *Client) CheckWithDetails(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (*models.CheckResponse, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	if c.config.CheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.CheckTimeout)
+		defer cancel()
+	}
+
+	resource.Tenant = c.config.TenantOrDefault(resource.Tenant)
+	if err := resource.Validate(); err != nil {
+		return nil, err
+	}
+
+	response, err := c.evaluate(ctx, user, action, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	// Give both modes the same response shape, and always report the
+	// permission string compared against role permissions
+	response.Normalize()
+	response.Debug.RequiredPermission = fmt.Sprintf("%s:%s", resource.Type, string(action))
+	return response, nil
+}
+
+// evaluate applies the deny list and dispatches the check to the PDP or the
+// client-side evaluator.
+func (c *Client) evaluate(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (*models.CheckResponse, error) {
+	// Globally denied permissions short-circuit before any evaluation
+	if c.isDenied(resource.Type, string(action)) {
+		return &models.CheckResponse{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Permission %s:%s is globally denied", resource.Type, string(action)),
+		}, nil
+	}
+
+	if c.config.UsePDP() {
+		if c.config.HybridCheck {
+			return c.checkHybrid(ctx, user, action, resource)
+		}
+		return c.checkWithPDP(ctx, user, action, resource)
+	}
+
+	return c.checkLocal(ctx, user, action, resource)
+}
+
+// checkLocal evaluates a permission check client-side from the user's role
+// assignments and the role definitions.
+func (c *Client) checkLocal(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (*models.CheckResponse, error) {
 	// Ensure scope is initialized
 	if err := c.ensureScope(ctx); err != nil {
 		return nil, err
@@ -85,7 +220,7 @@ func (c *Client) CheckWithDetails(ctx context.Context, user enforcement.User, ac
 	resourceType := resource.Type
 	requiredPermission := fmt.Sprintf("%s:%s", resourceType, string(action))
 
-	if c.config.Debug && c.config.Logger != nil {
+	if c.debugEnabled(ctx) {
 		c.config.Logger.Debug("Permission check",
 			zap.String("user", userKey),
 			zap.String("action", string(action)),
@@ -94,14 +229,7 @@ func (c *Client) CheckWithDetails(ctx context.Context, user enforcement.User, ac
 	}
 
 	// 1. Get user's role assignments (filtered by tenant if provided)
-	listParams := &models.RoleAssignmentListParams{
-		User: userKey,
-	}
-	if resource.Tenant != "" {
-		listParams.Tenant = resource.Tenant
-	}
-
-	assignments, err := c.Api.RoleAssignments.List(ctx, listParams)
+	assignments, err := c.userAssignments(ctx, userKey, resource.Tenant)
 	if err != nil {
 		if c.config.ThrowOnError {
 			return nil, err
@@ -112,36 +240,49 @@ func (c *Client) CheckWithDetails(ctx context.Context, user enforcement.User, ac
 		}, nil
 	}
 
-	if c.config.Debug && c.config.Logger != nil {
+	if c.debugEnabled(ctx) {
 		c.config.Logger.Debug("Role assignments fetched",
 			zap.Int("count", len(assignments)))
 	}
 
 	if len(assignments) == 0 {
+		reason := fmt.Sprintf("User %s has no role assignments", userKey)
+		if c.config.TenantValidation && resource.Tenant != "" && !c.tenantExists(ctx, resource.Tenant) {
+			reason = fmt.Sprintf("Tenant %s not found", resource.Tenant)
+		}
 		return &models.CheckResponse{
 			Allowed: false,
-			Reason:  fmt.Sprintf("User %s has no role assignments", userKey),
+			Reason:  reason,
 		}, nil
 	}
 
-	// 2. Get unique role keys from assignments
-	roleKeys := make(map[string]struct{})
-	for _, assignment := range assignments {
-		roleKeys[assignment.Role] = struct{}{}
+	assignments = activeAssignments(assignments, evalTime(ctx))
+	if len(assignments) == 0 {
+		return &models.CheckResponse{
+			Allowed: false,
+			Reason:  fmt.Sprintf("User %s has no active role assignments", userKey),
+		}, nil
 	}
 
-	if c.config.Debug && c.config.Logger != nil {
-		keys := make([]string, 0, len(roleKeys))
-		for k := range roleKeys {
-			keys = append(keys, k)
-		}
-		c.config.Logger.Debug("User's role keys", zap.Strings("roles", keys))
+	// Instance-scoped assignments only count for their own instance
+	assignments = applicableAssignments(assignments, resource)
+	if len(assignments) == 0 {
+		return &models.CheckResponse{
+			Allowed: false,
+			Reason:  fmt.Sprintf("User %s has no role assignments that apply to this resource", userKey),
+		}, nil
 	}
 
-	// 3. Fetch all roles and build permission map (with role inheritance)
-	rolesResponse, err := c.Api.Roles.List(ctx, &models.RoleListParams{
-		ListParams: models.ListParams{PerPage: 100},
-	})
+	assignments = c.conditionalAssignments(ctx, assignments, user, resource)
+	if len(assignments) == 0 {
+		return &models.CheckResponse{
+			Allowed: false,
+			Reason:  fmt.Sprintf("User %s has no role assignments whose conditions are met", userKey),
+		}, nil
+	}
+
+	// 2. Fetch all roles and build permission map (with role inheritance)
+	rolesMap, err := c.fetchRolesMap(ctx, assignments)
 	if err != nil {
 		if c.config.ThrowOnError {
 			return nil, err
@@ -152,140 +293,216 @@ func (c *Client) CheckWithDetails(ctx context.Context, user enforcement.User, ac
 		}, nil
 	}
 
-	rolesMap := make(map[string]*models.RoleRead)
-	for i := range rolesResponse.Data {
-		role := &rolesResponse.Data[i]
-		rolesMap[role.Key] = role
-	}
-
-	// 4. Check if any assigned role grants the required permission
-	var matchedRoles []string
-	var matchedPermissions []string
+	// 3. Check if any assigned role grants the required permission
+	evaluator := c.newEvaluator(rolesMap, assignments)
+	allowed, debug := evaluator.Allowed(action, resource)
 
-	for roleKey := range roleKeys {
-		permissions := c.getRolePermissions(roleKey, rolesMap, make(map[string]struct{}))
-
-		if c.config.Debug && c.config.Logger != nil {
+	if c.debugEnabled(ctx) {
+		c.config.Logger.Debug("User's role keys", zap.Strings("roles", debug.AssignedRoles))
+		for _, roleKey := range debug.AssignedRoles {
 			c.config.Logger.Debug("Role permissions",
 				zap.String("role", roleKey),
-				zap.Strings("permissions", permissions))
+				zap.Strings("permissions", evaluator.RolePermissions(roleKey)))
 		}
-
-		for _, perm := range permissions {
-			if perm == requiredPermission ||
-				perm == fmt.Sprintf("%s:*", resourceType) ||
-				perm == "*:*" {
-				matchedRoles = append(matchedRoles, roleKey)
-				matchedPermissions = append(matchedPermissions, requiredPermission)
-				break
-			}
+		for _, roleKey := range debug.EmptyRoles {
+			c.config.Logger.Warn("Assigned role has no permissions",
+				zap.String("user", userKey),
+				zap.String("role", roleKey))
 		}
-	}
-
-	allowed := len(matchedRoles) > 0
-
-	if c.config.Debug && c.config.Logger != nil {
 		c.config.Logger.Debug("Permission check result",
 			zap.Bool("allowed", allowed),
-			zap.Strings("matchedRoles", matchedRoles))
+			zap.Strings("matchedRoles", debug.MatchedRoles))
+	}
+
+	var matchedPermissions []string
+	for range debug.MatchedRoles {
+		matchedPermissions = append(matchedPermissions, requiredPermission)
 	}
 
 	reason := fmt.Sprintf("No role grants permission %s", requiredPermission)
 	if allowed {
-		reason = fmt.Sprintf("Granted by role(s): %s", strings.Join(matchedRoles, ", "))
+		reason = fmt.Sprintf("Granted by role(s): %s", strings.Join(debug.MatchedRoles, ", "))
+	} else {
+		// An empty role is usually a placeholder; say so rather than deny silently
+		for _, roleKey := range debug.EmptyRoles {
+			reason += fmt.Sprintf("; user's role '%s' has no permissions", roleKey)
+		}
 	}
 
 	return &models.CheckResponse{
 		Allowed: allowed,
 		Reason:  reason,
 		Debug: &models.CheckDebugInfo{
-			MatchedRoles:       matchedRoles,
+			MatchedRoles:       debug.MatchedRoles,
 			MatchedPermissions: matchedPermissions,
+			UnresolvedExtends:  debug.UnresolvedExtends,
+			TruncatedRoles:     debug.TruncatedRoles,
+			EmptyRoles:         debug.EmptyRoles,
 		},
 	}, nil
 }
 
-// getRolePermissions returns all permissions for a role, including inherited ones.
-func (c *Client) getRolePermissions(roleKey string, rolesMap map[string]*models.RoleRead, visited map[string]struct{}) []string {
-	// Prevent circular inheritance
-	if _, ok := visited[roleKey]; ok {
-		return nil
+// tenantExists returns false only if the tenant is known not to exist.
+// Existing tenants are cached for knownTenantTTL; lookup errors other than not
+// found count as existing, so validation never masks the original deny reason.
+func (c *Client) tenantExists(ctx context.Context, tenant string) bool {
+	if c.knownTenant(tenant) {
+		return true
 	}
-	visited[roleKey] = struct{}{}
 
-	role, ok := rolesMap[roleKey]
-	if !ok {
-		return nil
+	_, err := c.Api.Tenants.Get(ctx, tenant)
+	var apiErr *api.PermisError
+	if errors.As(err, &apiErr) && apiErr.IsNotFound() {
+		return false
 	}
+	if err == nil {
+		c.rememberTenant(tenant)
+	}
+	return true
+}
 
-	permissions := make([]string, len(role.Permissions))
-	copy(permissions, role.Permissions)
+// isDenied returns true if action on resourceType matches a globally denied pattern.
+func (c *Client) isDenied(resourceType, action string) bool {
+	for _, pattern := range c.config.DeniedPermissions {
+		if models.PermissionPatternMatches(pattern, resourceType, action) {
+			return true
+		}
+	}
+	return false
+}
 
-	// Add inherited permissions from parent roles
-	for _, parentRoleKey := range role.Extends {
-		parentPermissions := c.getRolePermissions(parentRoleKey, rolesMap, visited)
-		permissions = append(permissions, parentPermissions...)
+// evalTime returns the time at which assignment validity is evaluated:
+// the time set with enforcement.WithEvalTime, or now when unset.
+func evalTime(ctx context.Context) time.Time {
+	if t, ok := enforcement.EvalTimeFromContext(ctx); ok {
+		return t
 	}
+	return time.Now()
+}
 
-	// Remove duplicates
-	seen := make(map[string]struct{})
-	unique := permissions[:0]
-	for _, perm := range permissions {
-		if _, ok := seen[perm]; !ok {
-			seen[perm] = struct{}{}
-			unique = append(unique, perm)
+// activeAssignments returns the assignments whose time window includes t.
+func activeAssignments(assignments models.RoleAssignmentList, t time.Time) models.RoleAssignmentList {
+	active := make(models.RoleAssignmentList, 0, len(assignments))
+	for _, assignment := range assignments {
+		if assignment.ActiveAt(t) {
+			active = append(active, assignment)
 		}
 	}
+	return active
+}
+
+// newEvaluator returns an enforcement.Evaluator for rolesMap and assignments
+// that logs malformed permissions and unresolved parent roles.
+func (c *Client) newEvaluator(rolesMap map[string]*models.RoleRead, assignments models.RoleAssignmentList) *enforcement.Evaluator {
+	evaluator := enforcement.NewEvaluator(rolesMap, assignments)
+	evaluator.OnMalformedPermission = c.warnMalformedPermission
+	evaluator.OnUnresolvedParent = c.warnUnresolvedParent
+	evaluator.MaxDepth = c.config.MaxInheritanceDepth
+	evaluator.OnDepthExceeded = c.warnDepthExceeded
+	return evaluator
+}
 
-	return unique
+// warnMalformedPermission logs a warning (once per permission) when a stored
+// permission isn't in "resourceType:action" format and can never match.
+func (c *Client) warnMalformedPermission(roleKey, permission string, err error) {
+	if c.config.Logger == nil {
+		return
+	}
+	if _, warned := c.warnedPermissions.LoadOrStore(permission, struct{}{}); warned {
+		return
+	}
+	c.config.Logger.Warn("Role has a malformed permission that will never match",
+		zap.String("role", roleKey),
+		zap.String("permission", permission),
+		zap.Error(err))
 }
 
-// BulkCheck performs multiple permission checks at once.
+// BulkCheck performs multiple permission checks at once, running up to the
+// configured bulk concurrency (config.WithBulkConcurrency) in parallel.
+// Results are in the order of checks. The roles are fetched at most once for
+// the whole batch.
+// Each request's Context is forwarded to its check (see enforcement.WithCheckContext).
+// The configured check timeout applies to each check separately.
+// If ctx is cancelled, no more checks are started: the remaining results are
+// denied with the cancellation as reason, and ctx's error is returned along
+// with the response. No response is returned if the client is closed or the
+// scope can't be resolved.
 func (c *Client) BulkCheck(ctx context.Context, checks []models.CheckRequest) (*models.BulkCheckResponse, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	// Resolve the scope once, before the workers race to do it
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+
 	results := make([]models.BulkCheckResult, len(checks))
+	ctx = withBatchRoles(ctx)
+	sem := make(chan struct{}, c.bulkConcurrency())
+	var wg sync.WaitGroup
 
 	for i, check := range checks {
-		user := enforcement.User{Key: check.User.(string)}
-		action := enforcement.Action(check.Action)
-
-		var resource enforcement.Resource
-		switch r := check.Resource.(type) {
-		case string:
-			resource = enforcement.Resource{Type: r}
-		case map[string]interface{}:
-			if t, ok := r["type"].(string); ok {
-				resource.Type = t
-			}
-			if k, ok := r["key"].(string); ok {
-				resource.Key = k
-			}
-			if t, ok := r["tenant"].(string); ok {
-				resource.Tenant = t
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			results[i] = models.BulkCheckResult{
+				Index:    i,
+				Request:  check,
+				Response: models.CheckResponse{Allowed: false, Reason: err.Error()},
 			}
+			results[i].Response.Normalize()
+			continue
 		}
 
-		if check.Tenant != "" {
-			resource.Tenant = check.Tenant
+		wg.Add(1)
+		go func(i int, check models.CheckRequest) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = c.bulkCheckOne(ctx, i, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	return &models.BulkCheckResponse{Results: results}, ctx.Err()
+}
+
+// bulkCheckOne evaluates the check at index i of a bulk check. Failures are
+// reported as denied results with the error as reason.
+func (c *Client) bulkCheckOne(ctx context.Context, i int, check models.CheckRequest) models.BulkCheckResult {
+	result := models.BulkCheckResult{Index: i, Request: check}
+
+	user, action, resource, err := enforcement.FromCheckRequest(check)
+	if err != nil {
+		result.Response = models.CheckResponse{Allowed: false, Reason: err.Error()}
+	} else {
+		checkCtx := ctx
+		if len(check.Context) > 0 {
+			checkCtx = enforcement.WithCheckContext(ctx,
+				enforcement.ContextBuilder().WithData(check.Context).Build())
 		}
 
-		response, err := c.CheckWithDetails(ctx, user, action, resource)
+		response, err := c.CheckWithDetails(checkCtx, user, action, resource)
 		if err != nil {
-			results[i] = models.BulkCheckResult{
-				Request:  check,
-				Response: models.CheckResponse{Allowed: false, Reason: err.Error()},
-			}
+			result.Response = models.CheckResponse{Allowed: false, Reason: err.Error()}
 		} else {
-			results[i] = models.BulkCheckResult{
-				Request:  check,
-				Response: *response,
-			}
+			result.Response = *response
 		}
 	}
 
-	return &models.BulkCheckResponse{Results: results}, nil
+	// Checks that failed get the same response shape as evaluated ones
+	result.Response.Normalize()
+	return result
 }
 
 // GetPermissions returns all permissions for a user.
+// An empty response always means the user has no permissions: failures to
+// fetch assignments, roles or the resource catalog are returned as errors
+// regardless of ThrowOnError.
 func (c *Client) GetPermissions(ctx context.Context, request models.GetPermissionsRequest) (*models.GetPermissionsResponse, error) {
 	// Ensure scope is initialized
 	if err := c.ensureScope(ctx); err != nil {
@@ -294,10 +511,8 @@ func (c *Client) GetPermissions(ctx context.Context, request models.GetPermissio
 
 	// 1. Get user's role assignments
 	listParams := &models.RoleAssignmentListParams{
-		User: request.User,
-	}
-	if request.Tenant != "" {
-		listParams.Tenant = request.Tenant
+		User:   request.User,
+		Tenant: c.config.TenantOrDefault(request.Tenant),
 	}
 	if request.Resource != "" {
 		listParams.Resource = request.Resource
@@ -305,96 +520,185 @@ func (c *Client) GetPermissions(ctx context.Context, request models.GetPermissio
 
 	assignments, err := c.Api.RoleAssignments.List(ctx, listParams)
 	if err != nil {
-		if c.config.ThrowOnError {
-			return nil, err
-		}
-		return &models.GetPermissionsResponse{Roles: []string{}, Permissions: []string{}}, nil
+		return nil, err
 	}
 
+	assignments = activeAssignments(assignments, evalTime(ctx))
 	if len(assignments) == 0 {
 		return &models.GetPermissionsResponse{Roles: []string{}, Permissions: []string{}}, nil
 	}
 
-	// 2. Get unique role keys
-	roleKeys := make(map[string]struct{})
-	for _, assignment := range assignments {
-		roleKeys[assignment.Role] = struct{}{}
+	// 2. Fetch all roles
+	rolesMap, err := c.fetchRolesMap(ctx, assignments)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3. Fetch all roles
-	rolesResponse, err := c.Api.Roles.List(ctx, &models.RoleListParams{
-		ListParams: models.ListParams{PerPage: 100},
-	})
-	if err != nil {
-		if c.config.ThrowOnError {
+	// 3. Collect all permissions from assigned roles
+	roles, permissions := c.collectPermissions(assignments, rolesMap)
+
+	response := &models.GetPermissionsResponse{
+		Roles:       roles,
+		Permissions: permissions,
+	}
+
+	// 4. Optionally expand wildcards against the resource catalog
+	if request.ExpandWildcards {
+		catalog, err := c.resourceCatalog(ctx)
+		if err != nil {
 			return nil, err
 		}
-		return &models.GetPermissionsResponse{Roles: []string{}, Permissions: []string{}}, nil
+		response.ExpandedPermissions = expandPermissions(permissions, catalog)
 	}
 
-	rolesMap := make(map[string]*models.RoleRead)
-	for i := range rolesResponse.Data {
-		role := &rolesResponse.Data[i]
-		rolesMap[role.Key] = role
+	return response, nil
+}
+
+// GetPermissionsBatch returns all permissions for multiple users in a tenant.
+// Roles are fetched once and all assignments in the tenant are listed in a
+// single request, so the cost doesn't grow with the number of users.
+// Each user's result is identical to what GetPermissions returns for them,
+// and failures are likewise always returned as errors.
+func (c *Client) GetPermissionsBatch(ctx context.Context, users []string, tenant string) (map[string]*models.GetPermissionsResponse, error) {
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
 	}
 
-	// 4. Collect all permissions from assigned roles
-	allPermissions := make(map[string]struct{})
-	roles := make([]string, 0, len(roleKeys))
+	results := make(map[string]*models.GetPermissionsResponse, len(users))
+	byUser := make(map[string]models.RoleAssignmentList, len(users))
+	for _, user := range users {
+		results[user] = &models.GetPermissionsResponse{Roles: []string{}, Permissions: []string{}}
+		byUser[user] = nil
+	}
+	if len(users) == 0 {
+		return results, nil
+	}
+
+	// 1. Get all role assignments in the tenant, every page, and group them by user
+	assignments, err := c.Api.RoleAssignments.ListAll(ctx, &models.RoleAssignmentListParams{Tenant: c.config.TenantOrDefault(tenant)})
+	if err != nil {
+		return nil, err
+	}
 
-	for roleKey := range roleKeys {
-		roles = append(roles, roleKey)
-		permissions := c.getRolePermissions(roleKey, rolesMap, make(map[string]struct{}))
-		for _, perm := range permissions {
-			allPermissions[perm] = struct{}{}
+	var requested models.RoleAssignmentList
+	for _, assignment := range activeAssignments(assignments, evalTime(ctx)) {
+		if _, ok := byUser[assignment.User]; ok {
+			byUser[assignment.User] = append(byUser[assignment.User], assignment)
+			requested = append(requested, assignment)
 		}
 	}
+	if len(requested) == 0 {
+		return results, nil
+	}
 
-	permissions := make([]string, 0, len(allPermissions))
-	for perm := range allPermissions {
-		permissions = append(permissions, perm)
+	// 2. Fetch all roles once
+	rolesMap, err := c.fetchRolesMap(ctx, requested)
+	if err != nil {
+		return nil, err
 	}
 
-	return &models.GetPermissionsResponse{
-		Roles:       roles,
-		Permissions: permissions,
-	}, nil
+	// 3. Compute each user's permissions locally
+	for user, userAssignments := range byUser {
+		if len(userAssignments) == 0 {
+			continue
+		}
+		roles, permissions := c.collectPermissions(userAssignments, rolesMap)
+		results[user] = &models.GetPermissionsResponse{Roles: roles, Permissions: permissions}
+	}
+
+	return results, nil
+}
+
+// fetchRolesMap fetches all roles, following every page, and indexes them by
+// key. Extended parent roles reachable from the assigned roles but missing
+// from the list are fetched individually. With a CacheTTL, the map is cached
+// and shared between callers, which must not modify it; the same goes for the
+// checks of a bulk check, which share one map.
+func (c *Client) fetchRolesMap(ctx context.Context, assignments models.RoleAssignmentList) (map[string]*models.RoleRead, error) {
+	rolesMap, ok, err := batchRolesMap(ctx, func() (map[string]*models.RoleRead, error) {
+		return c.loadRolesMap(ctx)
+	})
+	if !ok {
+		rolesMap, err = c.loadRolesMap(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.resolveMissingParents(ctx, rolesMap, assignments), nil
+}
+
+// loadRolesMap implements fetchRolesMap, outside of bulk checks.
+func (c *Client) loadRolesMap(ctx context.Context) (map[string]*models.RoleRead, error) {
+	if rolesMap, ok := c.cachedRolesMap(); ok {
+		return rolesMap, nil
+	}
+
+	// Every page is read: a role missing from the map would silently deny
+	roles, err := c.Api.Roles.ListAll(ctx, &models.RoleListParams{
+		ListParams: models.ListParams{PerPage: 100},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rolesMap := make(map[string]*models.RoleRead, len(roles))
+	for i := range roles {
+		role := &roles[i]
+		rolesMap[role.Key] = role
+	}
+	c.cacheRolesMap(rolesMap)
+	return rolesMap, nil
+}
+
+// collectPermissions returns the unique roles and permissions (including
+// inherited ones) granted by the given assignments.
+func (c *Client) collectPermissions(assignments models.RoleAssignmentList, rolesMap map[string]*models.RoleRead) (roles, permissions []string) {
+	evaluator := c.newEvaluator(rolesMap, assignments)
+	roles = evaluator.AssignedRoles()
+	return roles, evaluator.EffectivePermissions(roles)
 }
 
 // SyncUser creates or updates a user and optionally assigns roles.
-func (c *Client) SyncUser(ctx context.Context, user models.UserCreate, roles []models.RoleAssignmentCreate) (*models.UserRead, error) {
+// Role assignment failures do not fail the call; they are reported per role in
+// the returned SyncResult. Use SyncUserStrict to treat them as an error.
+func (c *Client) SyncUser(ctx context.Context, user models.UserCreate, roles []models.RoleAssignmentCreate) (*SyncResult, error) {
 	// Ensure scope is initialized
 	if err := c.ensureScope(ctx); err != nil {
 		return nil, err
 	}
 
 	// Sync user
-	result, err := c.Api.Users.SyncUser(ctx, user)
+	synced, created, err := c.Api.Users.Upsert(ctx, user)
 	if err != nil {
 		return nil, err
 	}
 
+	result := &SyncResult{User: synced, Created: created}
+
 	// Assign roles if provided
-	if len(roles) > 0 {
-		for _, role := range roles {
-			role.User = user.Key
-			_, err := c.Api.RoleAssignments.Assign(ctx, &role)
-			if err != nil {
-				// Log but don't fail if role assignment fails
-				if c.config.Debug && c.config.Logger != nil {
-					c.config.Logger.Warn("Failed to assign role",
-						zap.String("user", user.Key),
-						zap.String("role", role.Role),
-						zap.Error(err))
-				}
+	for _, role := range roles {
+		role.User = user.Key
+		if _, err := c.Api.RoleAssignments.Assign(ctx, &role); err != nil {
+			if c.debugEnabled(ctx) {
+				c.config.Logger.Warn("Failed to assign role",
+					zap.String("user", user.Key),
+					zap.String("role", role.Role),
+					zap.Error(err))
 			}
+			result.AssignmentErrors = append(result.AssignmentErrors, AssignmentError{Assignment: role, Err: err})
 		}
 	}
+	if len(roles) > 0 {
+		c.InvalidateCache()
+	}
 
 	return result, nil
 }
 
 // CheckAndThrow performs a permission check and returns an error if not allowed.
+// A denial is returned as an ACCESS_DENIED *api.PermisError whose Details hold
+// the user, action, resource type, resource key and tenant.
 func (c *Client) CheckAndThrow(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) error {
 	response, err := c.CheckWithDetails(ctx, user, action, resource)
 	if err != nil {
@@ -402,9 +706,25 @@ func (c *Client) CheckAndThrow(ctx context.Context, user enforcement.User, actio
 	}
 
 	if !response.Allowed {
-		return api.AccessDeniedError(fmt.Sprintf(
+		target := resource.Type
+		if resource.Key != "" {
+			target += ":" + resource.Key
+		}
+		if resource.Tenant != "" {
+			target += " in tenant " + resource.Tenant
+		}
+
+		deniedErr := api.AccessDeniedError(fmt.Sprintf(
 			"Access denied: User %s is not allowed to perform %s on %s",
-			user.Key, string(action), resource.Type))
+			user.Key, string(action), target))
+		deniedErr.Details = map[string]interface{}{
+			"user":          user.Key,
+			"action":        string(action),
+			"resource_type": resource.Type,
+			"resource_key":  resource.Key,
+			"tenant":        resource.Tenant,
+		}
+		return deniedErr
 	}
 
 	return nil
@@ -428,11 +748,22 @@ func (c *Client) GetScope(ctx context.Context) (projectID, environmentID string,
 	if err := c.ensureScope(ctx); err != nil {
 		return "", "", err
 	}
-	return c.config.ProjectID, c.config.EnvironmentID, nil
+	projectID, environmentID = c.config.Scope()
+	return projectID, environmentID, nil
+}
+
+// debugEnabled returns true if debug logging is enabled for the call,
+// either globally in the config or for this context via enforcement.WithDebug.
+func (c *Client) debugEnabled(ctx context.Context) bool {
+	return c.config.Logger != nil && (c.config.Debug || enforcement.DebugFromContext(ctx))
 }
 
 // ensureScope ensures that projectId and environmentId are available.
 func (c *Client) ensureScope(ctx context.Context) error {
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+
 	// Fast path: already initialized or has scope from config
 	if c.scopeInitialized || c.config.HasScope() {
 		return nil
@@ -446,6 +777,18 @@ func (c *Client) ensureScope(ctx context.Context) error {
 		return nil
 	}
 
+	if c.config.EnvironmentSlug != "" {
+		if err := c.resolveEnvironmentSlug(ctx); err != nil {
+			return err
+		}
+		c.scopeInitialized = true
+		return nil
+	}
+
+	if c.config.DisableAutoScope {
+		return api.ErrMissingScope
+	}
+
 	// Fetch scope from API
 	if err := c.fetchAndSetScope(ctx); err != nil {
 		return err
@@ -457,52 +800,55 @@ func (c *Client) ensureScope(ctx context.Context) error {
 
 // fetchAndSetScope fetches scope from the API key scope endpoint.
 func (c *Client) fetchAndSetScope(ctx context.Context) error {
-	url := fmt.Sprintf("%s/v1/api-key/scope", c.config.ApiURL)
+	scope, err := c.fetchScope(ctx)
+	if err != nil {
+		if !c.config.HasScope() {
+			return err
+		}
+		return nil
+	}
+
+	c.setFetchedScope(scope.ProjectID, scope.EnvironmentID)
+
+	if c.debugEnabled(ctx) {
+		c.config.Logger.Debug("Auto-fetched scope",
+			zap.String("projectId", scope.ProjectID),
+			zap.String("environmentId", scope.EnvironmentID))
+	}
+
+	return nil
+}
+
+// fetchScope fetches the API key's scope from the scope endpoint.
+func (c *Client) fetchScope(ctx context.Context) (*models.APIKeyScope, error) {
+	url := fmt.Sprintf("%s/%s/api-key/scope", c.config.ApiURL, c.config.Version())
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set(c.config.AuthHeader())
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.config.HTTPClient.Do(req)
 	if err != nil {
-		if !c.config.HasScope() {
-			return fmt.Errorf("failed to fetch API key scope: %w. "+
-				"Either provide projectId and environmentId in config, "+
-				"or ensure the API key has valid scope", err)
-		}
-		return nil
+		return nil, fmt.Errorf("failed to fetch API key scope: %w. "+
+			"Either provide projectId and environmentId in config, "+
+			"or ensure the API key has valid scope", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		if !c.config.HasScope() {
-			return fmt.Errorf("failed to fetch API key scope: status %d, body: %s. "+
-				"Either provide projectId and environmentId in config, "+
-				"or ensure the API key has valid scope", resp.StatusCode, string(body))
-		}
-		return nil
+		return nil, fmt.Errorf("failed to fetch API key scope: status %d, body: %s. "+
+			"Either provide projectId and environmentId in config, "+
+			"or ensure the API key has valid scope", resp.StatusCode, string(body))
 	}
 
 	var scope models.APIKeyScope
 	if err := json.NewDecoder(resp.Body).Decode(&scope); err != nil {
-		if !c.config.HasScope() {
-			return fmt.Errorf("failed to decode API key scope: %w", err)
-		}
-		return nil
-	}
-
-	c.config.UpdateScope(scope.ProjectID, scope.EnvironmentID)
-
-	if c.config.Debug && c.config.Logger != nil {
-		c.config.Logger.Debug("Auto-fetched scope",
-			zap.String("projectId", scope.ProjectID),
-			zap.String("environmentId", scope.EnvironmentID))
+		return nil, fmt.Errorf("failed to decode API key scope: %w", err)
 	}
-
-	return nil
+	return &scope, nil
 }
@@ -0,0 +1,2168 @@
+package permissio_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/enforcement"
+	"github.com/permissio/permissio-go/pkg/models"
+	permissio "github.com/permissio/permissio-go/pkg/permissio"
+)
+
+// newTestClient returns a scoped client pointed at an httptest server running handler.
+func newTestClient(t *testing.T, handler http.Handler, configure ...func(*config.ConfigBuilder)) *permissio.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	builder := config.NewConfigBuilder("permis_key_test").
+		WithApiUrl(server.URL).
+		WithProjectID("proj").
+		WithEnvironmentID("env").
+		WithRetryAttempts(0)
+	for _, fn := range configure {
+		fn(builder)
+	}
+	return permissio.New(builder.Build())
+}
+
+// writeJSON writes v as a JSON response body.
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+}
+
+// pdpFunc adapts a function to config.PolicyDecisionPoint.
+type pdpFunc func(ctx context.Context, request models.CheckRequest) (*models.CheckResponse, error)
+
+func (f pdpFunc) Check(ctx context.Context, request models.CheckRequest) (*models.CheckResponse, error) {
+	return f(ctx, request)
+}
+
+func TestBulkCheckForwardsContextToPDP(t *testing.T) {
+	pdp := pdpFunc(func(ctx context.Context, req models.CheckRequest) (*models.CheckResponse, error) {
+		return &models.CheckResponse{Allowed: req.Context["region"] == "eu"}, nil
+	})
+	client := newTestClient(t, http.NotFoundHandler(), func(b *config.ConfigBuilder) {
+		b.WithPDP(pdp)
+	})
+
+	checks := []models.CheckRequest{
+		{User: "alice", Action: "read", Resource: "document", Context: map[string]interface{}{"region": "eu"}},
+		{User: "alice", Action: "read", Resource: "document", Context: map[string]interface{}{"region": "us"}},
+	}
+
+	result, err := client.BulkCheck(context.Background(), checks)
+	if err != nil {
+		t.Fatalf("BulkCheck() error: %v", err)
+	}
+	if !result.Results[0].Response.Allowed {
+		t.Error("expected check with region=eu to be allowed")
+	}
+	if result.Results[1].Response.Allowed {
+		t.Error("expected check with region=us to be denied")
+	}
+}
+
+func TestCheckResponseJSONContract(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "editor"}}
+	localClient := newTestClient(t, api)
+
+	pdp := pdpFunc(func(ctx context.Context, req models.CheckRequest) (*models.CheckResponse, error) {
+		if req.Action == "read" {
+			return &models.CheckResponse{
+				Allowed: true,
+				Reason:  "granted by role editor",
+				Debug:   &models.CheckDebugInfo{MatchedRoles: []string{"editor"}},
+			}, nil
+		}
+		return &models.CheckResponse{Allowed: false}, nil
+	})
+	pdpClient := newTestClient(t, http.NotFoundHandler(), func(b *config.ConfigBuilder) {
+		b.WithPDP(pdp)
+	})
+
+	tests := []struct {
+		name   string
+		client *permissio.Client
+		action string
+		golden string
+	}{
+		{
+			name:   "local allow",
+			client: localClient,
+			action: "read",
+			golden: `{"allowed":true,"reason":"Granted by role(s): editor","debug":{"matchedRoles":["editor"],"matchedPermissions":["document:read"],"requiredPermission":"document:read"}}`,
+		},
+		{
+			name:   "local deny",
+			client: localClient,
+			action: "delete",
+			golden: `{"allowed":false,"reason":"No role grants permission document:delete","debug":{"matchedRoles":[],"matchedPermissions":[],"requiredPermission":"document:delete"}}`,
+		},
+		{
+			name:   "pdp allow",
+			client: pdpClient,
+			action: "read",
+			golden: `{"allowed":true,"reason":"granted by role editor","debug":{"matchedRoles":["editor"],"matchedPermissions":[],"requiredPermission":"document:read"}}`,
+		},
+		{
+			name:   "pdp deny",
+			client: pdpClient,
+			action: "delete",
+			golden: `{"allowed":false,"reason":"","debug":{"matchedRoles":[],"matchedPermissions":[],"requiredPermission":"document:delete"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := tt.client.CheckWithDetails(context.Background(), enforcement.User{Key: "alice"},
+				enforcement.Action(tt.action), enforcement.Resource{Type: "document"})
+			if err != nil {
+				t.Fatalf("CheckWithDetails() error: %v", err)
+			}
+			got, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("failed to marshal response: %v", err)
+			}
+			if string(got) != tt.golden {
+				t.Errorf("JSON = %s\nwant   %s", got, tt.golden)
+			}
+		})
+	}
+}
+
+func TestGetPermissionsExpandsWildcards(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "admin", Permissions: []string{"document:*", "report:read"}},
+		{Key: "auditor", Permissions: []string{"*:read"}},
+	}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "admin"},
+		{User: "alice", Role: "auditor"},
+	}
+	api.resources = map[string][]string{
+		"document": {"read", "write"},
+		"invoice":  {"read", "pay"},
+	}
+	client := newTestClient(t, api)
+
+	request := models.GetPermissionsRequest{User: "alice", ExpandWildcards: true}
+	response, err := client.GetPermissions(context.Background(), request)
+	if err != nil {
+		t.Fatalf("GetPermissions() error: %v", err)
+	}
+
+	want := []string{"document:read", "document:write", "report:read"}
+	if strings.Join(response.ExpandedPermissions, ",") != strings.Join(want, ",") {
+		t.Errorf("ExpandedPermissions = %v, want %v", response.ExpandedPermissions, want)
+	}
+
+	// Expanded permissions are exactly those checks allow
+	for _, perm := range append(want, "invoice:read") {
+		parsed, _ := models.ParsePermission(perm)
+		allowed, err := client.CheckWithContext(context.Background(), enforcement.User{Key: "alice"},
+			enforcement.Action(parsed.Action), enforcement.Resource{Type: parsed.ResourceType})
+		if err != nil || allowed != (perm != "invoice:read") {
+			t.Errorf("Check(%s) = %v, %v, disagrees with ExpandedPermissions", perm, allowed, err)
+		}
+	}
+	if len(response.Permissions) != 3 {
+		t.Errorf("expected raw permissions to be kept, got %v", response.Permissions)
+	}
+
+	if _, err := client.GetPermissions(context.Background(), request); err != nil {
+		t.Fatalf("GetPermissions() error: %v", err)
+	}
+	if n := api.count("/resources"); n != 1 {
+		t.Errorf("expected resource catalog to be fetched once, got %d", n)
+	}
+
+	api.mu.Lock()
+	api.resources["document"] = []string{"read", "write", "archive"}
+	api.mu.Unlock()
+	client.InvalidateCache()
+	response, err = client.GetPermissions(context.Background(), request)
+	if err != nil || !slices.Contains(response.ExpandedPermissions, "document:archive") {
+		t.Errorf("ExpandedPermissions after InvalidateCache = %v, %v, want the new action", response.ExpandedPermissions, err)
+	}
+}
+
+func TestCheckHonorsAssignmentTimeWindow(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		startsAt  *time.Time
+		expiresAt *time.Time
+		allowed   bool
+	}{
+		{"not yet active", &future, nil, false},
+		{"active", &past, &future, true},
+		{"expired", nil, &past, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(t)
+			api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:write"}}}
+			api.assignments = []models.RoleAssignmentRead{
+				{User: "alice", Role: "editor", StartsAt: tt.startsAt, ExpiresAt: tt.expiresAt},
+			}
+			client := newTestClient(t, api)
+
+			allowed, err := client.Check(enforcement.User{Key: "alice"}, "write", enforcement.Resource{Type: "document"})
+			if err != nil {
+				t.Fatalf("Check() error: %v", err)
+			}
+			if allowed != tt.allowed {
+				t.Errorf("allowed = %v, want %v", allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestCheckUsesContextEvalTime(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:write"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "editor", ExpiresAt: &expiresAt}}
+	client := newTestClient(t, api)
+
+	check := func(at time.Time) bool {
+		ctx := enforcement.WithEvalTime(context.Background(), at)
+		allowed, err := client.CheckWithContext(ctx, enforcement.User{Key: "alice"}, "write", enforcement.Resource{Type: "document"})
+		if err != nil {
+			t.Fatalf("CheckWithContext() error: %v", err)
+		}
+		return allowed
+	}
+
+	if !check(expiresAt.Add(-time.Second)) {
+		t.Error("expected assignment to be valid just before expiry")
+	}
+	if check(expiresAt) {
+		t.Error("expected assignment to be expired at ExpiresAt")
+	}
+}
+
+func TestCheckEvaluatesAssignmentConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		abac     bool
+		region   string
+		resource enforcement.Resource
+		allowed  bool
+	}{
+		{"condition met in context", true, "eu", enforcement.Resource{Type: "document"}, true},
+		{"condition failed in context", true, "us", enforcement.Resource{Type: "document"}, false},
+		{"condition attribute missing", true, "", enforcement.Resource{Type: "document"}, false},
+		{"condition met by resource attribute", true, "",
+			enforcement.Resource{Type: "document", Attributes: map[string]interface{}{"region": "eu"}}, true},
+		{"conditions ignored without client ABAC", false, "us", enforcement.Resource{Type: "document"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(t)
+			api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:write"}}}
+			api.assignments = []models.RoleAssignmentRead{
+				{User: "alice", Role: "editor", Attributes: map[string]interface{}{"region": "eu"}},
+			}
+			client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithClientABAC(tt.abac) })
+
+			ctx := context.Background()
+			if tt.region != "" {
+				ctx = enforcement.WithCheckContext(ctx, enforcement.ContextBuilder().With("region", tt.region).Build())
+			}
+			allowed, err := client.CheckWithContext(ctx, enforcement.User{Key: "alice"}, "write", tt.resource)
+			if err != nil {
+				t.Fatalf("CheckWithContext() error: %v", err)
+			}
+			if allowed != tt.allowed {
+				t.Errorf("allowed = %v, want %v", allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestFilterAuthorizedMatchesResourceAttributes(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "reader", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "reader", Attributes: map[string]interface{}{"department": "eng"}},
+		{User: "alice", Role: "reader", Resource: "document", ResourceInstance: "shared"},
+	}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithClientABAC(true) })
+
+	document := func(key, department string) enforcement.Resource {
+		return enforcement.Resource{Type: "document", Key: key, Attributes: map[string]interface{}{"department": department}}
+	}
+	resources := []enforcement.Resource{
+		document("eng-1", "eng"),
+		document("sales-1", "sales"),
+		document("shared", "sales"),
+		document("eng-2", "eng"),
+	}
+
+	authorized, err := client.FilterAuthorized(context.Background(), enforcement.User{Key: "alice"}, "read", resources)
+	if err != nil {
+		t.Fatalf("FilterAuthorized() error: %v", err)
+	}
+	var keys []string
+	for _, resource := range authorized {
+		keys = append(keys, resource.Key)
+	}
+	if strings.Join(keys, ",") != "eng-1,shared,eng-2" {
+		t.Errorf("FilterAuthorized() keys = %v, want eng-1,shared,eng-2", keys)
+	}
+	if got := api.count("/role_assignments"); got != 1 {
+		t.Errorf("role assignments fetched %d times, want 1", got)
+	}
+	if got := api.count("/roles"); got != 1 {
+		t.Errorf("roles fetched %d times, want 1", got)
+	}
+}
+
+func TestGetPermissionsReturnsFetchErrors(t *testing.T) {
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"boom"}`, http.StatusInternalServerError)
+	})
+	client := newTestClient(t, failing)
+	ctx := context.Background()
+
+	response, err := client.GetPermissions(ctx, models.GetPermissionsRequest{User: "alice"})
+	if err == nil {
+		t.Errorf("GetPermissions() = %+v, want an error without ThrowOnError", response)
+	}
+	if _, err := client.GetPermissionsBatch(ctx, []string{"alice"}, "acme"); err == nil {
+		t.Error("GetPermissionsBatch() should return the fetch error")
+	}
+
+	client = newTestClient(t, newFakeAPI(t))
+	response, err = client.GetPermissions(ctx, models.GetPermissionsRequest{User: "alice"})
+	if err != nil || len(response.Permissions) != 0 {
+		t.Errorf("GetPermissions() for a user without roles = %+v, %v, want an empty response", response, err)
+	}
+}
+
+func TestDefaultTenant(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:write"}}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithDefaultTenant("default") })
+	ctx := context.Background()
+	alice := enforcement.User{Key: "alice"}
+
+	if _, err := client.Api.RoleAssignments.Assign(ctx, models.NewRoleAssignmentCreate("alice", "editor")); err != nil {
+		t.Fatalf("Assign() error: %v", err)
+	}
+	if tenant := api.assignments[0].Tenant; tenant != "default" {
+		t.Errorf("assigned tenant = %q, want default", tenant)
+	}
+
+	allowed, err := client.CheckWithContext(ctx, alice, "write", enforcement.Resource{Type: "document"})
+	if err != nil || !allowed {
+		t.Errorf("check without tenant = %v, %v, want allowed in the default tenant", allowed, err)
+	}
+	allowed, err = client.CheckWithContext(ctx, alice, "write", enforcement.Resource{Type: "document", Tenant: "other"})
+	if err != nil || allowed {
+		t.Errorf("check with explicit tenant = %v, %v, want the explicit tenant to win", allowed, err)
+	}
+
+	response, err := client.GetPermissions(ctx, models.GetPermissionsRequest{User: "alice"})
+	if err != nil || len(response.Permissions) != 1 {
+		t.Errorf("GetPermissions() = %+v, %v, want the default tenant's permissions", response, err)
+	}
+}
+
+func TestDefaultTenantForResourceInstances(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:write"}}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithDefaultTenant("acme") })
+	ctx := context.Background()
+
+	instance, err := client.Api.Resources.CreateInstance(ctx, "document", models.NewResourceInstanceCreate("document", "doc-1"))
+	if err != nil {
+		t.Fatalf("CreateInstance() error: %v", err)
+	}
+	if instance.Tenant != "acme" {
+		t.Errorf("instance tenant = %q, want the configured default tenant", instance.Tenant)
+	}
+
+	assignment := models.NewRoleAssignmentCreate("alice", "editor").SetResource("document").SetResourceInstance("doc-1")
+	if _, err := client.Api.RoleAssignments.Assign(ctx, assignment); err != nil {
+		t.Fatalf("Assign() error: %v", err)
+	}
+
+	allowed, err := client.CheckWithContext(ctx, enforcement.User{Key: "alice"}, "write", enforcement.Resource{Type: "document", Key: "doc-1"})
+	if err != nil || !allowed {
+		t.Errorf("instance check without tenant = %v, %v, want allowed in the instance's tenant", allowed, err)
+	}
+}
+
+func TestGetPermissionsBatchMatchesSingleUser(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer"}},
+	}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "editor", Tenant: "acme"},
+		{User: "bob", Role: "viewer", Tenant: "acme"},
+		{User: "bob", Role: "editor", Tenant: "other"},
+	}
+	client := newTestClient(t, api)
+	ctx := context.Background()
+
+	users := []string{"alice", "bob", "carol"}
+	batch, err := client.GetPermissionsBatch(ctx, users, "acme")
+	if err != nil {
+		t.Fatalf("GetPermissionsBatch() error: %v", err)
+	}
+	if n := api.count("/roles"); n != 1 {
+		t.Errorf("expected roles to be fetched once, got %d", n)
+	}
+
+	for _, user := range users {
+		single, err := client.GetPermissions(ctx, models.GetPermissionsRequest{User: user, Tenant: "acme"})
+		if err != nil {
+			t.Fatalf("GetPermissions(%s) error: %v", user, err)
+		}
+		if !sameSet(batch[user].Roles, single.Roles) || !sameSet(batch[user].Permissions, single.Permissions) {
+			t.Errorf("%s: batch = %+v, single = %+v", user, batch[user], single)
+		}
+	}
+}
+
+func TestGetPermissionsBatchReadsEveryAssignmentsPage(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	for i := 0; i < 3; i++ {
+		api.assignments = append(api.assignments, models.RoleAssignmentRead{User: fmt.Sprintf("user-%d", i), Role: "viewer", Tenant: "acme"})
+	}
+	api.assignments = append(api.assignments, models.RoleAssignmentRead{User: "alice", Role: "viewer", Tenant: "acme"})
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithDefaultPageSize(2) })
+
+	batch, err := client.GetPermissionsBatch(context.Background(), []string{"alice"}, "acme")
+	if err != nil {
+		t.Fatalf("GetPermissionsBatch() error: %v", err)
+	}
+	if got := batch["alice"].Permissions; !reflect.DeepEqual(got, []string{"document:read"}) {
+		t.Errorf("alice's permissions = %v, want the assignment on the second page", got)
+	}
+	if n := api.count("/role_assignments"); n != 2 {
+		t.Errorf("expected 2 assignment pages to be fetched, got %d", n)
+	}
+}
+
+// sameSet returns true if a and b contain the same elements, ignoring order.
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int, len(a))
+	for _, s := range a {
+		seen[s]++
+	}
+	for _, s := range b {
+		if seen[s] == 0 {
+			return false
+		}
+		seen[s]--
+	}
+	return true
+}
+
+func TestGetUserAccessReview(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer"}},
+		{Key: "admin", Permissions: []string{"document:delete"}, Extends: []string{"editor"}},
+	}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "admin", Tenant: "acme"},
+		{User: "alice", Role: "deleted-role", Tenant: "acme"},
+	}
+	client := newTestClient(t, api)
+
+	review, err := client.GetUserAccessReview(context.Background(), "alice", "acme")
+	if err != nil {
+		t.Fatalf("GetUserAccessReview() error: %v", err)
+	}
+	if len(review.Roles) != 2 {
+		t.Fatalf("expected 2 roles, got %d", len(review.Roles))
+	}
+
+	admin := review.Roles[0]
+	if strings.Join(admin.DirectPermissions, ",") != "document:delete" {
+		t.Errorf("DirectPermissions = %v", admin.DirectPermissions)
+	}
+	if strings.Join(admin.InheritedPermissions, ",") != "document:read,document:write" {
+		t.Errorf("InheritedPermissions = %v", admin.InheritedPermissions)
+	}
+	if strings.Join(admin.ExtendsChain, ",") != "editor,viewer" {
+		t.Errorf("ExtendsChain = %v", admin.ExtendsChain)
+	}
+
+	if review.Roles[1].Role != nil || len(review.Roles[1].DirectPermissions) != 0 {
+		t.Errorf("expected missing role to have no definition, got %+v", review.Roles[1])
+	}
+}
+
+func TestGetUserAccessReviewUsesDefaultTenant(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "viewer", Tenant: "acme"},
+		{User: "alice", Role: "viewer", Tenant: "globex"},
+	}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithDefaultTenant("acme") })
+
+	review, err := client.GetUserAccessReview(context.Background(), "alice", "")
+	if err != nil {
+		t.Fatalf("GetUserAccessReview() error: %v", err)
+	}
+	if review.Tenant != "acme" {
+		t.Errorf("Tenant = %q, want acme", review.Tenant)
+	}
+	if len(review.Roles) != 1 || review.Roles[0].Assignment.Tenant != "acme" {
+		t.Errorf("expected only the acme assignment, got %+v", review.Roles)
+	}
+}
+
+func TestFactsAndSchemaPaths(t *testing.T) {
+	scoped := permissio.New(config.NewConfigBuilder("permis_key_test").
+		WithApiUrl("https://api.test").
+		WithProjectID("proj").
+		WithEnvironmentID("env").
+		Build())
+	if got := scoped.FactsPath("/users"); got != "https://api.test/v1/facts/proj/env/users" {
+		t.Errorf("FactsPath() = %q", got)
+	}
+	if got := scoped.SchemaPath("/roles"); got != "https://api.test/v1/schema/proj/env/roles" {
+		t.Errorf("SchemaPath() = %q", got)
+	}
+
+	unscoped := permissio.New(config.NewConfigBuilder("permis_key_test").WithApiUrl("https://api.test").Build())
+	if got := unscoped.FactsPath("/users"); got != "https://api.test/v1/users" {
+		t.Errorf("unscoped FactsPath() = %q", got)
+	}
+	if got := unscoped.SchemaPath("/roles"); got != "https://api.test/v1/roles" {
+		t.Errorf("unscoped SchemaPath() = %q", got)
+	}
+}
+
+func TestWatchUserEmitsOnChange(t *testing.T) {
+	api := newFakeAPI(t)
+	api.assignments = []models.RoleAssignmentRead{{ID: "a1", User: "alice", Role: "viewer"}}
+	client := newTestClient(t, api)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := client.WatchUser(ctx, "alice", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WatchUser() error: %v", err)
+	}
+
+	receive := func() []models.RoleAssignmentRead {
+		t.Helper()
+		select {
+		case update := <-updates:
+			return update
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for update")
+			return nil
+		}
+	}
+
+	if initial := receive(); len(initial) != 1 {
+		t.Fatalf("expected initial set of 1 assignment, got %d", len(initial))
+	}
+
+	api.mu.Lock()
+	api.assignments = append(api.assignments, models.RoleAssignmentRead{ID: "a2", User: "alice", Role: "editor"})
+	api.mu.Unlock()
+
+	if changed := receive(); len(changed) != 2 {
+		t.Fatalf("expected changed set of 2 assignments, got %d", len(changed))
+	}
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("expected no update after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel was not closed after cancel")
+	}
+}
+
+func TestWatchUserFollowsAllPages(t *testing.T) {
+	api := newFakeAPI(t)
+	api.assignments = []models.RoleAssignmentRead{
+		{ID: "a1", User: "alice", Role: "viewer"},
+		{ID: "a2", User: "alice", Role: "editor"},
+		{ID: "a3", User: "alice", Role: "admin"},
+	}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithDefaultPageSize(2) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := client.WatchUser(ctx, "alice", time.Hour)
+	if err != nil {
+		t.Fatalf("WatchUser() error: %v", err)
+	}
+
+	select {
+	case initial := <-updates:
+		if len(initial) != 3 {
+			t.Fatalf("expected initial set of 3 assignments across two pages, got %d", len(initial))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for update")
+	}
+}
+
+func TestCheckReportsRequiredPermission(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"post:create"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "editor"}}
+	client := newTestClient(t, api)
+	user := enforcement.User{Key: "alice"}
+
+	tests := []struct {
+		resourceType string
+		allowed      bool
+	}{
+		{"post", true},
+		{"Post", false},
+	}
+	for _, tt := range tests {
+		response, err := client.CheckWithDetails(context.Background(), user, "create", enforcement.Resource{Type: tt.resourceType})
+		if err != nil {
+			t.Fatalf("CheckWithDetails() error: %v", err)
+		}
+		want := tt.resourceType + ":create"
+		if response.Allowed != tt.allowed || response.Debug == nil || response.Debug.RequiredPermission != want {
+			t.Errorf("CheckWithDetails(%s) = %+v, want allowed=%v with required permission %q", tt.resourceType, response, tt.allowed, want)
+		}
+	}
+}
+
+func TestCheckPermission(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "editor"}}
+	client := newTestClient(t, api)
+	ctx := context.Background()
+	user := enforcement.User{Key: "alice"}
+
+	tests := []struct {
+		permission string
+		allowed    bool
+		wantErr    bool
+	}{
+		{"document:read", true, false},
+		{"document:delete", false, false},
+		{"document.read", false, true},
+		{"document", false, true},
+		{"document:*", false, true},
+	}
+	for _, tt := range tests {
+		allowed, err := client.CheckPermission(ctx, user, tt.permission, "")
+		if (err != nil) != tt.wantErr || allowed != tt.allowed {
+			t.Errorf("CheckPermission(%q) = %v, %v, want %v (error: %v)", tt.permission, allowed, err, tt.allowed, tt.wantErr)
+		}
+	}
+	if n := api.count("/role_assignments"); n != 2 {
+		t.Errorf("expected malformed permissions to be rejected before any request, got %d assignment lookups", n)
+	}
+}
+
+func TestCheckHonorsGlobalDenyList(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "admin", Permissions: []string{"*:*"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "admin"}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithDeniedPermissions([]string{"*:delete"})
+	})
+	ctx := context.Background()
+	user := enforcement.User{Key: "alice"}
+
+	response, err := client.CheckWithDetails(ctx, user, "delete", enforcement.Resource{Type: "document"})
+	if err != nil {
+		t.Fatalf("CheckWithDetails() error: %v", err)
+	}
+	if response.Allowed || !strings.Contains(response.Reason, "globally denied") {
+		t.Errorf("expected global deny, got %+v", response)
+	}
+	if n := api.count("/role_assignments"); n != 0 {
+		t.Errorf("expected no assignment lookups for a globally denied permission, got %d", n)
+	}
+
+	allowed, err := client.CheckWithContext(ctx, user, "read", enforcement.Resource{Type: "document"})
+	if err != nil || !allowed {
+		t.Errorf("expected non-denied permission to be allowed, got %v, %v", allowed, err)
+	}
+}
+
+func TestCheckAnyResourceType(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:*", "report:read"}}}
+	api.roles = append(api.roles, models.RoleRead{Key: "billing", Permissions: []string{"invoice:*"}})
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "editor", Tenant: "acme"},
+		{User: "alice", Role: "billing", Tenant: "acme", Resource: "invoice", ResourceInstance: "inv-1"},
+	}
+	client := newTestClient(t, api)
+
+	results, err := client.CheckAnyResourceType(context.Background(), enforcement.User{Key: "alice"}, "write",
+		[]string{"document", "report", "invoice"}, "acme")
+	if err != nil {
+		t.Fatalf("CheckAnyResourceType() error: %v", err)
+	}
+
+	want := map[string]bool{"document": true, "report": false, "invoice": false}
+	for resourceType, allowed := range want {
+		got, ok := results[resourceType]
+		if !ok || got != allowed {
+			t.Errorf("%s: got %v (present=%v), want %v", resourceType, got, ok, allowed)
+		}
+	}
+	if api.count("/role_assignments") != 1 || api.count("/roles") != 1 {
+		t.Error("expected a single assignment and roles fetch")
+	}
+}
+
+func TestFilterAuthorizedReadsEveryAssignmentsPage(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "owner", Permissions: []string{"document:*"}}}
+	for _, key := range []string{"doc-1", "doc-2", "doc-3"} {
+		api.assignments = append(api.assignments, models.RoleAssignmentRead{User: "alice", Role: "owner", Resource: "document", ResourceInstance: key})
+	}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithDefaultPageSize(2) })
+
+	resources := []enforcement.Resource{{Type: "document", Key: "doc-1"}, {Type: "document", Key: "doc-3"}}
+	authorized, err := client.FilterAuthorized(context.Background(), enforcement.User{Key: "alice"}, "read", resources)
+	if err != nil || len(authorized) != 2 {
+		t.Errorf("FilterAuthorized() = %v, %v, want both documents, including doc-3 from the second page", authorized, err)
+	}
+}
+
+func TestGetInstanceCapabilities(t *testing.T) {
+	api := newFakeAPI(t)
+	api.resources = map[string][]string{"document": {"read", "write", "delete"}}
+	api.roles = []models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "owner", Permissions: []string{"document:*"}},
+	}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "viewer", Tenant: "acme"},
+		{User: "alice", Role: "owner", Tenant: "acme", Resource: "document", ResourceInstance: "doc-1"},
+	}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithDeniedPermissions([]string{"*:delete"})
+	})
+
+	capabilities, err := client.GetInstanceCapabilities(context.Background(), enforcement.User{Key: "alice"},
+		"document", []string{"doc-1", "doc-2"}, "acme")
+	if err != nil {
+		t.Fatalf("GetInstanceCapabilities() error: %v", err)
+	}
+
+	want := map[string][]string{
+		"doc-1": {"read", "write"},
+		"doc-2": {"read"},
+	}
+	if !reflect.DeepEqual(capabilities, want) {
+		t.Errorf("capabilities = %v, want %v", capabilities, want)
+	}
+	if n := api.count("/role_assignments"); n != 1 {
+		t.Errorf("expected a single assignment lookup, got %d", n)
+	}
+
+	capabilities, err = client.GetInstanceCapabilities(context.Background(), enforcement.User{Key: "bob"},
+		"document", []string{"doc-1"}, "acme")
+	if err != nil || capabilities["doc-1"] == nil || len(capabilities["doc-1"]) != 0 {
+		t.Errorf("GetInstanceCapabilities(bob) = %v, %v, want an empty slice for doc-1", capabilities, err)
+	}
+}
+
+func TestCheckHonorsInstanceScopedAssignments(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "owner", Permissions: []string{"document:*"}}}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "owner", Tenant: "acme", Resource: "document", ResourceInstance: "doc-1"},
+	}
+	client := newTestClient(t, api)
+	ctx := context.Background()
+	user := enforcement.User{Key: "alice"}
+	document := func(key string) enforcement.Resource {
+		return enforcement.Resource{Type: "document", Key: key, Tenant: "acme"}
+	}
+
+	tests := []struct {
+		resource enforcement.Resource
+		allowed  bool
+	}{
+		{document("doc-1"), true},
+		{document("doc-2"), false},
+		{document(""), false},
+		{enforcement.Resource{Type: "folder", Key: "doc-1", Tenant: "acme"}, false},
+	}
+	for _, tt := range tests {
+		response, err := client.CheckWithDetails(ctx, user, "write", tt.resource)
+		if err != nil {
+			t.Fatalf("CheckWithDetails(%+v) error: %v", tt.resource, err)
+		}
+		if response.Allowed != tt.allowed {
+			t.Errorf("CheckWithDetails(%+v) allowed = %v, want %v (%s)", tt.resource, response.Allowed, tt.allowed, response.Reason)
+		}
+	}
+
+	// Every entry point gives the same answer
+	authorized, err := client.FilterAuthorized(ctx, user, "write", []enforcement.Resource{document("doc-1"), document("doc-2")})
+	if err != nil || len(authorized) != 1 || authorized[0].Key != "doc-1" {
+		t.Errorf("FilterAuthorized() = %v, %v, want only doc-1", authorized, err)
+	}
+}
+
+func TestCheckAndThrowIncludesResourceKeyAndTenant(t *testing.T) {
+	client := newTestClient(t, newFakeAPI(t))
+
+	err := client.CheckAndThrow(context.Background(), enforcement.User{Key: "alice"}, "read",
+		enforcement.Resource{Type: "document", Key: "doc-1", Tenant: "acme"})
+
+	var permisErr *api.PermisError
+	if !errors.As(err, &permisErr) {
+		t.Fatalf("CheckAndThrow() error = %v, want *api.PermisError", err)
+	}
+	if !strings.Contains(permisErr.Message, "document:doc-1 in tenant acme") {
+		t.Errorf("Message = %q, want resource key and tenant", permisErr.Message)
+	}
+	if permisErr.Details["resource_key"] != "doc-1" || permisErr.Details["tenant"] != "acme" {
+		t.Errorf("Details = %v, want resource_key and tenant", permisErr.Details)
+	}
+}
+
+func TestHybridCheckConfirmsDeniesWithPDP(t *testing.T) {
+	var pdpCalls int
+	pdpFails := false
+	pdp := pdpFunc(func(ctx context.Context, req models.CheckRequest) (*models.CheckResponse, error) {
+		pdpCalls++
+		if pdpFails {
+			return nil, errors.New("unavailable")
+		}
+		return &models.CheckResponse{Allowed: true, Reason: "granted by ABAC policy"}, nil
+	})
+
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "viewer"}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithPDP(pdp).WithHybridCheck(true)
+	})
+	ctx := context.Background()
+	alice := enforcement.User{Key: "alice"}
+	document := enforcement.Resource{Type: "document"}
+
+	allowed, err := client.CheckWithContext(ctx, alice, "read", document)
+	if err != nil || !allowed {
+		t.Fatalf("local allow: allowed=%v err=%v", allowed, err)
+	}
+	if pdpCalls != 0 {
+		t.Errorf("PDP called %d times for a local allow, want 0", pdpCalls)
+	}
+
+	allowed, err = client.CheckWithContext(ctx, alice, "write", document)
+	if err != nil || !allowed {
+		t.Fatalf("PDP confirmation: allowed=%v err=%v", allowed, err)
+	}
+
+	pdpFails = true
+	allowed, err = client.CheckWithContext(ctx, alice, "write", document)
+	if err != nil || allowed {
+		t.Errorf("PDP failure: allowed=%v err=%v, want local deny", allowed, err)
+	}
+}
+
+func TestCloseStopsWatchersAndRejectsCalls(t *testing.T) {
+	api := newFakeAPI(t)
+	api.assignments = []models.RoleAssignmentRead{{ID: "a1", User: "alice", Role: "viewer"}}
+	client := newTestClient(t, api)
+
+	updates, err := client.WatchUser(context.Background(), "alice", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WatchUser() error: %v", err)
+	}
+	<-updates
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Close(); err != nil {
+				t.Errorf("Close() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("expected watch channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for watch channel to close")
+	}
+
+	_, err = client.CheckWithContext(context.Background(), enforcement.User{Key: "alice"}, "read", enforcement.Resource{Type: "document"})
+	if !errors.Is(err, permissio.ErrClientClosed) {
+		t.Errorf("CheckWithContext() after Close error = %v, want ErrClientClosed", err)
+	}
+}
+
+// closeCountingTransport counts CloseIdleConnections calls.
+type closeCountingTransport struct {
+	http.RoundTripper
+	closed atomic.Int32
+}
+
+func (t *closeCountingTransport) CloseIdleConnections() { t.closed.Add(1) }
+
+func TestCloseLeavesSuppliedHTTPClientAlone(t *testing.T) {
+	transport := &closeCountingTransport{RoundTripper: http.DefaultTransport}
+	client := newTestClient(t, newFakeAPI(t), func(b *config.ConfigBuilder) {
+		b.WithHTTPClient(&http.Client{Transport: transport})
+	})
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if n := transport.closed.Load(); n != 0 {
+		t.Errorf("CloseIdleConnections called %d times on a supplied HTTP client, want 0", n)
+	}
+}
+
+func TestSyncUserReportsAssignmentFailures(t *testing.T) {
+	roles := []models.RoleAssignmentCreate{
+		{Role: "viewer", Tenant: "acme"},
+		{Role: "editor", Tenant: "acme"},
+	}
+
+	tests := []struct {
+		name       string
+		failRoles  map[string]bool
+		wantFailed []string
+	}{
+		{name: "all succeed"},
+		{name: "partial failure", failRoles: map[string]bool{"editor": true}, wantFailed: []string{"editor"}},
+		{name: "all fail", failRoles: map[string]bool{"viewer": true, "editor": true}, wantFailed: []string{"viewer", "editor"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(t)
+			api.failRoles = tt.failRoles
+			client := newTestClient(t, api)
+			ctx := context.Background()
+
+			result, err := client.SyncUser(ctx, models.UserCreate{Key: "alice"}, roles)
+			if err != nil {
+				t.Fatalf("SyncUser() error: %v", err)
+			}
+			if result.User == nil || result.User.Key != "alice" {
+				t.Errorf("User = %+v, want alice", result.User)
+			}
+
+			var failed []string
+			for _, assignmentErr := range result.AssignmentErrors {
+				failed = append(failed, assignmentErr.Assignment.Role)
+			}
+			if !sameSet(failed, tt.wantFailed) {
+				t.Errorf("failed roles = %v, want %v", failed, tt.wantFailed)
+			}
+
+			user, err := client.SyncUserStrict(ctx, models.UserCreate{Key: "alice"}, roles)
+			if (err != nil) != (len(tt.wantFailed) > 0) {
+				t.Errorf("SyncUserStrict() error = %v, want failure=%v", err, len(tt.wantFailed) > 0)
+			}
+			if user == nil {
+				t.Error("SyncUserStrict() should return the synced user")
+			}
+		})
+	}
+}
+
+func TestSyncUserReportsCreated(t *testing.T) {
+	client := newTestClient(t, newFakeAPI(t))
+	ctx := context.Background()
+
+	for i, wantCreated := range []bool{true, false} {
+		result, err := client.SyncUser(ctx, models.UserCreate{Key: "alice"}, nil)
+		if err != nil {
+			t.Fatalf("SyncUser() #%d error: %v", i+1, err)
+		}
+		if result.Created != wantCreated {
+			t.Errorf("SyncUser() #%d Created = %v, want %v", i+1, result.Created, wantCreated)
+		}
+	}
+}
+
+func TestCheckResolvesMissingParentRoles(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer", "ghost"}},
+	}
+	api.unlistedRoles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "editor"}}
+	client := newTestClient(t, api)
+	ctx := context.Background()
+	alice := enforcement.User{Key: "alice"}
+
+	response, err := client.CheckWithDetails(ctx, alice, "read", enforcement.Resource{Type: "document"})
+	if err != nil {
+		t.Fatalf("CheckWithDetails() error: %v", err)
+	}
+	if !response.Allowed {
+		t.Errorf("expected permission inherited from unlisted parent, got %q", response.Reason)
+	}
+	if api.count("/roles/viewer") == 0 {
+		t.Error("expected unlisted parent role to be fetched by key")
+	}
+	if got := response.Debug.UnresolvedExtends; len(got) != 1 || got[0] != "ghost" {
+		t.Errorf("UnresolvedExtends = %v, want [ghost]", got)
+	}
+}
+
+func TestCheckResolvesOnlyParentsOfAssignedRoles(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"ghost"}},
+		{Key: "auditor", Permissions: []string{"report:read"}, Extends: []string{"phantom"}},
+	}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "editor"}}
+	client := newTestClient(t, api)
+	alice := enforcement.User{Key: "alice"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.CheckWithContext(context.Background(), alice, "write", enforcement.Resource{Type: "document"}); err != nil {
+			t.Fatalf("CheckWithContext() error: %v", err)
+		}
+	}
+	if n := api.count("/roles/phantom"); n != 0 {
+		t.Errorf("expected the parent of an unassigned role not to be fetched, got %d fetches", n)
+	}
+	if n := api.count("/roles/ghost"); n != 1 {
+		t.Errorf("expected the missing parent to be looked up once, got %d fetches", n)
+	}
+}
+
+func TestCheckRetriesMissingParentsAfterCacheTTL(t *testing.T) {
+	now := time.Now()
+	restore := permissio.SetCacheClock(func() time.Time { return now })
+	defer restore()
+
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "editor", Permissions: []string{"document:write"}, Extends: []string{"viewer"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "editor"}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithCacheTTL(time.Minute) })
+	alice := enforcement.User{Key: "alice"}
+	read := func() bool {
+		t.Helper()
+		allowed, err := client.CheckWithContext(context.Background(), alice, "read", enforcement.Resource{Type: "document"})
+		if err != nil {
+			t.Fatalf("CheckWithContext() error: %v", err)
+		}
+		return allowed
+	}
+
+	if read() {
+		t.Error("expected no permission from a missing parent")
+	}
+	api.mu.Lock()
+	api.unlistedRoles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.mu.Unlock()
+	if read() || api.count("/roles/viewer") != 1 {
+		t.Errorf("expected the missing parent to be remembered within the TTL, got %d fetches", api.count("/roles/viewer"))
+	}
+
+	now = now.Add(time.Minute)
+	if !read() {
+		t.Error("expected the parent to be fetched again after the TTL")
+	}
+}
+
+func TestCheckRejectsInstanceWithoutTenant(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "viewer", Tenant: "acme"}}
+	alice := enforcement.User{Key: "alice"}
+	instance := enforcement.ResourceBuilder("document").WithKey("doc-1").Build()
+
+	client := newTestClient(t, api)
+	if _, err := client.CheckWithDetails(context.Background(), alice, "read", instance); !errors.Is(err, enforcement.ErrInstanceWithoutTenant) {
+		t.Errorf("CheckWithDetails() error = %v, want ErrInstanceWithoutTenant", err)
+	}
+
+	client = newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithDefaultTenant("acme") })
+	response, err := client.CheckWithDetails(context.Background(), alice, "read", instance)
+	if err != nil || !response.Allowed {
+		t.Errorf("CheckWithDetails() with a default tenant = %+v, %v, want allowed", response, err)
+	}
+}
+
+func TestCheckReadsEveryRolesPage(t *testing.T) {
+	api := newFakeAPI(t)
+	for i := 0; i < 150; i++ {
+		api.roles = append(api.roles, models.RoleRead{Key: fmt.Sprintf("role-%03d", i)})
+	}
+	api.roles = append(api.roles,
+		models.RoleRead{Key: "viewer", Permissions: []string{"document:read"}},
+		models.RoleRead{Key: "editor", Permissions: []string{"document:update"}, Extends: []string{"viewer"}},
+	)
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "editor"}}
+	client := newTestClient(t, api)
+	user := enforcement.User{Key: "alice"}
+
+	for _, action := range []enforcement.Action{"update", "read"} {
+		allowed, err := client.CheckWithContext(context.Background(), user, action, enforcement.Resource{Type: "document"})
+		if err != nil || !allowed {
+			t.Errorf("CheckWithContext(%s) = %v, %v, want allowed by a role on the second page", action, allowed, err)
+		}
+	}
+	if n := api.count("/roles/viewer"); n != 0 {
+		t.Errorf("expected the parent role to come from the roles list, got %d individual fetches", n)
+	}
+}
+
+func TestCheckCache(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "viewer", Tenant: "acme"}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithCacheTTL(time.Minute) })
+	ctx := context.Background()
+	alice := enforcement.User{Key: "alice"}
+	document := enforcement.Resource{Type: "document", Tenant: "acme"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if allowed, err := client.CheckWithContext(ctx, alice, "read", document); err != nil || !allowed {
+				t.Errorf("Check() = %v, %v, want allowed", allowed, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Concurrent first checks may all miss; later ones are served from the cache
+	assignmentCalls, roleCalls := api.count("/role_assignments"), api.count("/roles")
+	if allowed, _ := client.CheckWithContext(ctx, alice, "read", document); !allowed {
+		t.Error("expected cached check to be allowed")
+	}
+	if api.count("/role_assignments") != assignmentCalls || api.count("/roles") != roleCalls {
+		t.Fatal("expected a cached check to make no requests")
+	}
+
+	// A different tenant is a different cache entry
+	if allowed, _ := client.CheckWithContext(ctx, alice, "read", enforcement.Resource{Type: "document", Tenant: "other"}); allowed {
+		t.Error("expected deny in another tenant")
+	}
+	if got := api.count("/role_assignments"); got != assignmentCalls+1 {
+		t.Errorf("assignment requests = %d, want %d", got, assignmentCalls+1)
+	}
+
+	// Revoked access shows once the cache is invalidated
+	api.mu.Lock()
+	api.assignments = nil
+	api.mu.Unlock()
+	if allowed, _ := client.CheckWithContext(ctx, alice, "read", document); !allowed {
+		t.Error("expected the cached assignment to still allow")
+	}
+	client.InvalidateCache()
+	if allowed, _ := client.CheckWithContext(ctx, alice, "read", document); allowed {
+		t.Error("expected deny after InvalidateCache")
+	}
+	if got := api.count("/roles"); got != roleCalls {
+		t.Errorf("role requests = %d, want %d: a user without assignments needs no roles", got, roleCalls)
+	}
+}
+
+func TestCheckReadsEveryAssignmentsPage(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "viewer", Permissions: []string{"document:read"}},
+		{Key: "editor", Permissions: []string{"document:update"}},
+	}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "viewer"},
+		{User: "alice", Role: "editor"},
+	}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithDefaultPageSize(1) })
+
+	allowed, err := client.CheckWithContext(context.Background(), enforcement.User{Key: "alice"}, "update", enforcement.Resource{Type: "document"})
+	if err != nil || !allowed {
+		t.Errorf("CheckWithContext() = %v, %v, want allowed by the assignment on the second page", allowed, err)
+	}
+}
+
+func TestCheckCacheEvictsExpiredAssignments(t *testing.T) {
+	now := time.Now()
+	restore := permissio.SetCacheClock(func() time.Time { return now })
+	defer restore()
+
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithCacheTTL(time.Minute) })
+	ctx := context.Background()
+	document := enforcement.Resource{Type: "document", Tenant: "acme"}
+
+	for _, user := range []string{"alice", "bob"} {
+		if _, err := client.CheckWithContext(ctx, enforcement.User{Key: user}, "read", document); err != nil {
+			t.Fatalf("Check(%s) error: %v", user, err)
+		}
+	}
+	if n := client.CachedAssignments(); n != 2 {
+		t.Fatalf("cached assignment entries = %d, want 2", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := client.CheckWithContext(ctx, enforcement.User{Key: "carol"}, "read", document); err != nil {
+		t.Fatalf("Check(carol) error: %v", err)
+	}
+	if n := client.CachedAssignments(); n != 1 {
+		t.Errorf("cached assignment entries = %d, want only carol's once the others expired", n)
+	}
+}
+
+func TestWarmCache(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "viewer", Tenant: "acme"}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithCacheTTL(time.Minute) })
+	ctx := context.Background()
+
+	if err := client.WarmCache(ctx, "acme", "alice"); err != nil {
+		t.Fatalf("WarmCache() error: %v", err)
+	}
+	roleCalls, assignmentCalls := api.count("/roles"), api.count("/role_assignments")
+	if roleCalls != 1 || assignmentCalls != 1 {
+		t.Fatalf("WarmCache() made %d roles and %d assignment requests, want 1 each", roleCalls, assignmentCalls)
+	}
+
+	allowed, err := client.CheckWithContext(ctx, enforcement.User{Key: "alice"}, "read", enforcement.Resource{Type: "document", Tenant: "acme"})
+	if err != nil || !allowed {
+		t.Fatalf("Check() = %v, %v, want allowed", allowed, err)
+	}
+	if api.count("/roles") != roleCalls || api.count("/role_assignments") != assignmentCalls {
+		t.Error("expected the first check after warming to make no requests")
+	}
+
+	uncached := newTestClient(t, api)
+	if err := uncached.WarmCache(ctx, "acme", "alice"); err != nil || api.count("/roles") != roleCalls {
+		t.Errorf("WarmCache() without a cache = %v, want a no-op", err)
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	now := time.Now()
+	restore := permissio.SetCacheClock(func() time.Time { return now })
+	defer restore()
+
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "viewer"}, {User: "bob", Role: "viewer"}}
+	var mu sync.Mutex
+	events := map[config.CacheEvent]int{}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithCacheTTL(time.Minute).WithCacheObserver(func(event config.CacheEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events[event]++
+		})
+	})
+	ctx := context.Background()
+	check := func(user string) {
+		t.Helper()
+		if allowed, err := client.CheckWithContext(ctx, enforcement.User{Key: user}, "read", enforcement.Resource{Type: "document"}); err != nil || !allowed {
+			t.Fatalf("Check(%s) = %v, %v, want allowed", user, allowed, err)
+		}
+	}
+
+	check("alice")
+	check("alice")
+	now = now.Add(2 * time.Minute)
+	check("bob")
+
+	// alice's expired assignments and the expired roles are evicted
+	want := permissio.CacheStats{Hits: 2, Misses: 4, Evictions: 2, Size: 2}
+	if got := client.CacheStats(); got != want {
+		t.Errorf("CacheStats() = %+v, want %+v", got, want)
+	}
+	wantEvents := map[config.CacheEvent]int{
+		{Kind: config.CacheHit, Cache: "roles"}:            1,
+		{Kind: config.CacheHit, Cache: "assignments"}:      1,
+		{Kind: config.CacheMiss, Cache: "roles"}:           2,
+		{Kind: config.CacheMiss, Cache: "assignments"}:     2,
+		{Kind: config.CacheEviction, Cache: "roles"}:       1,
+		{Kind: config.CacheEviction, Cache: "assignments"}: 1,
+	}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("observed events = %v, want %v", events, wantEvents)
+	}
+
+	client.InvalidateCache()
+	if got := client.CacheStats(); got != (permissio.CacheStats{}) {
+		t.Errorf("CacheStats() after InvalidateCache = %+v, want zero", got)
+	}
+}
+
+func TestCheckCacheInvalidatedOnScopeChange(t *testing.T) {
+	var mu sync.Mutex
+	environmentID := "env-a"
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/v1/api-key/scope":
+			writeJSON(t, w, models.APIKeyScope{ProjectID: "proj", EnvironmentID: environmentID})
+		case r.URL.Path == "/v1/schema/proj/"+environmentID+"/roles":
+			writeJSON(t, w, models.RoleList{Data: []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}})
+		case r.URL.Path == "/v1/facts/proj/env-a/role_assignments":
+			writeJSON(t, w, models.RoleAssignmentList{{User: "alice", Role: "viewer"}})
+		case r.URL.Path == "/v1/facts/proj/"+environmentID+"/role_assignments":
+			writeJSON(t, w, models.RoleAssignmentList{})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	client := newTestClient(t, handler, func(b *config.ConfigBuilder) {
+		b.WithProjectID("").WithEnvironmentID("").WithCacheTTL(time.Hour)
+	})
+	ctx := context.Background()
+	alice := enforcement.User{Key: "alice"}
+	document := enforcement.Resource{Type: "document"}
+
+	if allowed, err := client.CheckWithContext(ctx, alice, "read", document); err != nil || !allowed {
+		t.Fatalf("Check() in env-a = %v, %v, want allowed", allowed, err)
+	}
+
+	mu.Lock()
+	environmentID = "env-b"
+	mu.Unlock()
+	client.RefreshScope()
+
+	if allowed, err := client.CheckWithContext(ctx, alice, "read", document); err != nil || allowed {
+		t.Errorf("Check() after the switch to env-b = %v, %v, want denied from env-b's assignments", allowed, err)
+	}
+}
+
+func TestCheckReasonNamesEmptyRoles(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "viewer"},
+		{Key: "commenter", Extends: []string{"viewer"}},
+		{Key: "billing", Permissions: []string{"invoice:read"}},
+	}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "commenter"},
+		{User: "alice", Role: "billing"},
+	}
+	client := newTestClient(t, api)
+
+	response, err := client.CheckWithDetails(context.Background(), enforcement.User{Key: "alice"}, "read", enforcement.Resource{Type: "document"})
+	if err != nil {
+		t.Fatalf("CheckWithDetails() error: %v", err)
+	}
+	if response.Allowed {
+		t.Fatal("expected deny")
+	}
+	if !strings.Contains(response.Reason, "user's role 'commenter' has no permissions") || strings.Contains(response.Reason, "'billing'") {
+		t.Errorf("Reason = %q, want it to name only the empty role", response.Reason)
+	}
+	if !reflect.DeepEqual(response.Debug.EmptyRoles, []string{"commenter"}) {
+		t.Errorf("EmptyRoles = %v, want [commenter]", response.Debug.EmptyRoles)
+	}
+}
+
+func TestBulkCheckWithBuiltRequests(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "viewer", Tenant: "acme"}}
+	client := newTestClient(t, api)
+
+	alice := enforcement.UserBuilder("alice").WithAttribute("department", "eng").Build()
+	document := enforcement.ResourceBuilder("document").WithKey("doc-1").WithTenant("acme").Build()
+	invalid := enforcement.CheckBuilder(alice, "read", document).Build()
+	invalid.User = 42
+
+	result, err := client.BulkCheck(context.Background(), []models.CheckRequest{
+		enforcement.CheckBuilder(alice, "read", document).Build(),
+		enforcement.CheckBuilder(alice, "write", document).Build(),
+		invalid,
+	})
+	if err != nil {
+		t.Fatalf("BulkCheck() error: %v", err)
+	}
+	if !result.Results[0].Response.Allowed {
+		t.Errorf("read: expected allow, got %q", result.Results[0].Response.Reason)
+	}
+	if result.Results[1].Response.Allowed {
+		t.Error("write: expected deny")
+	}
+	if result.Results[2].Response.Allowed || !strings.Contains(result.Results[2].Response.Reason, "unsupported") {
+		t.Errorf("invalid request: got %+v, want deny with reason", result.Results[2].Response)
+	}
+}
+
+func TestBulkCheckRunsConcurrently(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	for _, user := range []string{"u0", "u2", "u4", "u6", "u8"} {
+		api.assignments = append(api.assignments, models.RoleAssignmentRead{User: user, Role: "viewer"})
+	}
+
+	// Every assignment lookup waits until four are in flight at once
+	var inFlight atomic.Int32
+	ready := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/role_assignments") {
+			if inFlight.Add(1) == 4 {
+				close(ready)
+			}
+			select {
+			case <-ready:
+			case <-time.After(2 * time.Second):
+				t.Error("role assignment lookups did not run concurrently")
+			}
+		}
+		api.ServeHTTP(w, r)
+	})
+	client := newTestClient(t, handler, func(b *config.ConfigBuilder) { b.WithBulkConcurrency(4) })
+
+	var checks []models.CheckRequest
+	for i := 0; i < 10; i++ {
+		user := enforcement.User{Key: fmt.Sprintf("u%d", i)}
+		checks = append(checks, enforcement.CheckBuilder(user, "read", enforcement.Resource{Type: "document"}).Build())
+	}
+
+	response, err := client.BulkCheck(context.Background(), checks)
+	if err != nil {
+		t.Fatalf("BulkCheck() error: %v", err)
+	}
+	for i, result := range response.Results {
+		if result.Index != i || result.Response.Allowed != (i%2 == 0) {
+			t.Errorf("result %d = index %d, allowed %v, want index %d, allowed %v", i, result.Index, result.Response.Allowed, i, i%2 == 0)
+		}
+	}
+	if got := api.count("/roles"); got != 1 {
+		t.Errorf("roles fetched %d times, want once per batch", got)
+	}
+
+	// A cancelled batch starts no checks
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	response, err = client.BulkCheck(ctx, checks)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("BulkCheck() error = %v, want context.Canceled", err)
+	}
+	if len(response.Results) != len(checks) || response.Results[0].Response.Allowed {
+		t.Errorf("BulkCheck() results = %+v, want every check denied", response.Results)
+	}
+
+	// A closed client fails before starting any check
+	client.Close()
+	if response, err := client.BulkCheck(context.Background(), checks); !errors.Is(err, permissio.ErrClientClosed) || response != nil {
+		t.Errorf("BulkCheck() on a closed client = %v, %v, want ErrClientClosed", response, err)
+	}
+}
+
+func TestBulkCheckStream(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "viewer"}}
+	client := newTestClient(t, api)
+
+	alice := enforcement.User{Key: "alice"}
+	document := enforcement.Resource{Type: "document"}
+	var checks []models.CheckRequest
+	for i := 0; i < 25; i++ {
+		action := enforcement.Action("read")
+		if i%2 == 1 {
+			action = "write"
+		}
+		checks = append(checks, enforcement.CheckBuilder(alice, action, document).Build())
+	}
+
+	results, err := client.BulkCheckStream(context.Background(), checks)
+	if err != nil {
+		t.Fatalf("BulkCheckStream() error: %v", err)
+	}
+	seen := make(map[int]bool)
+	for result := range results {
+		if seen[result.Index] {
+			t.Errorf("result %d emitted twice", result.Index)
+		}
+		seen[result.Index] = true
+		if want := result.Index%2 == 0; result.Response.Allowed != want {
+			t.Errorf("result %d Allowed = %v, want %v", result.Index, result.Response.Allowed, want)
+		}
+	}
+	if len(seen) != len(checks) {
+		t.Errorf("got %d results, want %d", len(seen), len(checks))
+	}
+
+	// Cancelling closes the channel without the rest being read
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err = client.BulkCheckStream(ctx, checks)
+	if err != nil {
+		t.Fatalf("BulkCheckStream() error: %v", err)
+	}
+	<-results
+	cancel()
+	select {
+	case <-drained(results):
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancellation")
+	}
+}
+
+// drained returns a channel closed once results is closed.
+func drained(results <-chan models.BulkCheckResult) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range results {
+		}
+	}()
+	return done
+}
+
+func TestCopySchemaOrdersParentsFirst(t *testing.T) {
+	var synced []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/schema/proj/env/resources":
+			writeJSON(t, w, map[string]interface{}{"data": []map[string]interface{}{
+				{"key": "document", "actions": []string{"read", "write"}},
+			}, "page": 1, "totalPages": 1})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/schema/proj/env/roles":
+			writeJSON(t, w, models.RoleList{Data: []models.RoleRead{
+				{Key: "admin", Extends: []string{"editor"}},
+				{Key: "editor", Extends: []string{"viewer"}, Permissions: []string{"document:write"}},
+				{Key: "viewer", Permissions: []string{"document:read"}},
+			}, PaginatedResponse: models.PaginatedResponse{Page: 1, TotalPages: 1}})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/schema/proj/prod/"):
+			var body struct {
+				Key string `json:"key"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode sync body: %v", err)
+			}
+			synced = append(synced, strings.TrimPrefix(r.URL.Path, "/v1/schema/proj/prod/")+":"+body.Key)
+			if body.Key != "viewer" { // only viewer already exists in prod
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+			}
+			writeJSON(t, w, map[string]string{"key": body.Key})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	client := newTestClient(t, handler)
+
+	result, err := client.CopySchema(context.Background(), "prod")
+	if err != nil {
+		t.Fatalf("CopySchema() error: %v", err)
+	}
+
+	want := []string{"resources:document", "roles:viewer", "roles:editor", "roles:admin"}
+	if strings.Join(synced, ",") != strings.Join(want, ",") {
+		t.Errorf("sync order = %v, want %v", synced, want)
+	}
+	if !sameSet(result.CreatedRoles, []string{"editor", "admin"}) || !sameSet(result.UpdatedRoles, []string{"viewer"}) {
+		t.Errorf("created roles = %v, updated roles = %v", result.CreatedRoles, result.UpdatedRoles)
+	}
+	if !sameSet(result.CreatedResources, []string{"document"}) {
+		t.Errorf("created resources = %v", result.CreatedResources)
+	}
+}
+
+func TestWithoutAutoScopeNeverFetchesScope(t *testing.T) {
+	api := newFakeAPI(t)
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithProjectID("").WithEnvironmentID("").WithoutAutoScope()
+	})
+
+	if err := client.Init(context.Background()); err == nil {
+		t.Error("expected Init to fail without an explicit scope")
+	}
+	if got := api.count("/v1/api-key/scope"); got != 0 {
+		t.Errorf("scope endpoint called %d times, want 0", got)
+	}
+}
+
+func TestScopeInfo(t *testing.T) {
+	configured := newTestClient(t, newFakeAPI(t))
+	if project, env, fetchedAt, fromConfig := configured.ScopeInfo(); project != "proj" || env != "env" || !fetchedAt.IsZero() || !fromConfig {
+		t.Errorf("ScopeInfo() = %q, %q, %v, %v, want the configured scope", project, env, fetchedAt, fromConfig)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, models.APIKeyScope{ProjectID: "proj-api", EnvironmentID: "env-api"})
+	})
+	detected := newTestClient(t, handler, func(b *config.ConfigBuilder) {
+		b.WithProjectID("").WithEnvironmentID("")
+	})
+	if project, _, fetchedAt, fromConfig := detected.ScopeInfo(); project != "" || !fetchedAt.IsZero() || fromConfig {
+		t.Errorf("ScopeInfo() before Init = %q, %v, %v, want nothing", project, fetchedAt, fromConfig)
+	}
+
+	// A fetched scope is never reported as configured, even mid-update
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if project, _, _, fromConfig := detected.ScopeInfo(); project != "" && fromConfig {
+				t.Error("ScopeInfo() reported a fetched scope as configured")
+				return
+			}
+		}
+	}()
+
+	before := time.Now()
+	if err := detected.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	<-done
+	project, env, fetchedAt, fromConfig := detected.ScopeInfo()
+	if project != "proj-api" || env != "env-api" || fetchedAt.Before(before) || fromConfig {
+		t.Errorf("ScopeInfo() after Init = %q, %q, %v, %v, want the fetched scope", project, env, fetchedAt, fromConfig)
+	}
+}
+
+func TestScopeRefreshPicksUpChangedScope(t *testing.T) {
+	var mu sync.Mutex
+	environmentID := "env-a"
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/api-key/scope" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		writeJSON(t, w, models.APIKeyScope{ProjectID: "proj", EnvironmentID: environmentID})
+	})
+	client := newTestClient(t, handler, func(b *config.ConfigBuilder) {
+		b.WithProjectID("").WithEnvironmentID("").WithScopeRefreshInterval(10 * time.Millisecond)
+	})
+	defer client.Close()
+
+	if _, env, err := client.GetScope(context.Background()); err != nil || env != "env-a" {
+		t.Fatalf("GetScope() = %q, %v, want env-a", env, err)
+	}
+
+	mu.Lock()
+	environmentID = "env-b"
+	mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, env, err := client.GetScope(context.Background())
+		if err != nil {
+			t.Fatalf("GetScope() error: %v", err)
+		}
+		if env == "env-b" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("scope not refreshed, environment = %q", env)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestUserKeyTransformRoundTrip(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{{Key: "viewer", Permissions: []string{"document:read"}}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithUserKeyTransform(
+			func(key string) string { return "auth0|" + key },
+			func(key string) string { return strings.TrimPrefix(key, "auth0|") },
+		)
+	})
+	ctx := context.Background()
+
+	result, err := client.SyncUser(ctx, models.UserCreate{Key: "alice"}, []models.RoleAssignmentCreate{
+		{Role: "viewer", Tenant: "acme"},
+	})
+	if err != nil {
+		t.Fatalf("SyncUser() error: %v", err)
+	}
+	if result.User.Key != "alice" {
+		t.Errorf("synced user key = %q, want alice", result.User.Key)
+	}
+	if !api.users["auth0|alice"] || len(api.assignments) != 1 || api.assignments[0].User != "auth0|alice" {
+		t.Fatalf("API saw users %v and assignments %+v, want auth0|alice", api.users, api.assignments)
+	}
+
+	allowed, err := client.CheckWithContext(ctx, enforcement.User{Key: "alice"}, enforcement.Action("read"),
+		enforcement.Resource{Type: "document", Tenant: "acme"})
+	if err != nil || !allowed {
+		t.Errorf("Check() = %v, %v, want allowed", allowed, err)
+	}
+
+	assignments, err := client.Api.RoleAssignments.ListByUser(ctx, "alice", nil)
+	if err != nil {
+		t.Fatalf("ListByUser() error: %v", err)
+	}
+	if len(assignments) != 1 || assignments[0].User != "alice" {
+		t.Errorf("ListByUser() = %+v, want user alice", assignments)
+	}
+}
+
+func TestHasAnyRole(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "viewer"},
+		{Key: "editor", Extends: []string{"viewer"}},
+		{Key: "owner"},
+	}
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "editor", Tenant: "acme"},
+		{User: "alice", Role: "owner", Tenant: "other"},
+	}
+	client := newTestClient(t, api)
+	ctx := context.Background()
+
+	if ok, err := client.HasAnyRole(ctx, "alice", []string{"admin", "editor"}, "acme"); err != nil || !ok {
+		t.Errorf("HasAnyRole(admin, editor) = %v, %v, want true", ok, err)
+	}
+	if ok, err := client.HasAnyRole(ctx, "alice", []string{"owner"}, "acme"); err != nil || ok {
+		t.Errorf("HasAnyRole(owner) in acme = %v, %v, want false", ok, err)
+	}
+	if ok, err := client.HasAnyRole(ctx, "alice", []string{"viewer"}, "acme"); err != nil || ok {
+		t.Errorf("HasAnyRole(viewer) without inheritance = %v, %v, want false", ok, err)
+	}
+
+	result, err := client.HasAnyRoleWithDetails(ctx, "alice", []string{"admin", "viewer"}, "acme", &permissio.HasAnyRoleOptions{Inherited: true})
+	if err != nil {
+		t.Fatalf("HasAnyRoleWithDetails() error: %v", err)
+	}
+	want := permissio.HasAnyRoleResult{HasRole: true, MatchedRole: "viewer", AssignedRole: "editor"}
+	if *result != want {
+		t.Errorf("HasAnyRoleWithDetails() = %+v, want %+v", *result, want)
+	}
+}
+
+func TestHasAnyRoleMatchesCheckAssignments(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "viewer"},
+		{Key: "editor", Extends: []string{"viewer"}},
+		{Key: "admin", Extends: []string{"editor"}},
+		{Key: "owner"},
+	}
+	expired := time.Now().Add(-time.Hour)
+	api.assignments = []models.RoleAssignmentRead{
+		{User: "alice", Role: "admin", Tenant: "acme", ExpiresAt: &expired},
+		{User: "alice", Role: "owner", Tenant: "acme", Resource: "document", ResourceInstance: "doc-1"},
+		{User: "bob", Role: "admin", Tenant: "acme"},
+	}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithMaxInheritanceDepth(1) })
+	ctx := context.Background()
+
+	if ok, err := client.HasAnyRole(ctx, "alice", []string{"admin"}, "acme"); err != nil || ok {
+		t.Errorf("HasAnyRole(admin) with an expired assignment = %v, %v, want false", ok, err)
+	}
+	if ok, err := client.HasAnyRole(ctx, "alice", []string{"owner"}, "acme"); err != nil || ok {
+		t.Errorf("HasAnyRole(owner) with an instance-scoped assignment = %v, %v, want false", ok, err)
+	}
+	options := &permissio.HasAnyRoleOptions{Resource: "document", ResourceInstance: "doc-1"}
+	if result, err := client.HasAnyRoleWithDetails(ctx, "alice", []string{"owner"}, "acme", options); err != nil || !result.HasRole {
+		t.Errorf("HasAnyRoleWithDetails(owner) on doc-1 = %+v, %v, want true", result, err)
+	}
+
+	inherited := &permissio.HasAnyRoleOptions{Inherited: true}
+	if result, err := client.HasAnyRoleWithDetails(ctx, "bob", []string{"editor"}, "acme", inherited); err != nil || !result.HasRole {
+		t.Errorf("HasAnyRoleWithDetails(editor) = %+v, %v, want true", result, err)
+	}
+	if result, err := client.HasAnyRoleWithDetails(ctx, "bob", []string{"viewer"}, "acme", inherited); err != nil || result.HasRole {
+		t.Errorf("HasAnyRoleWithDetails(viewer) beyond the inheritance depth = %+v, %v, want false", result, err)
+	}
+}
+
+func TestPreviewURL(t *testing.T) {
+	client := newTestClient(t, newFakeAPI(t))
+	apiURL := client.GetConfig().ApiURL
+
+	tests := []struct {
+		kind string
+		want string
+	}{
+		{permissio.URLKindFacts, apiURL + "/v1/facts/proj/env/users"},
+		{permissio.URLKindSchema, apiURL + "/v1/schema/proj/env/users"},
+		{permissio.URLKindBase, apiURL + "/users"},
+		{"unknown", ""},
+	}
+	for _, tt := range tests {
+		if got := client.PreviewURL(tt.kind, "/users"); got != tt.want {
+			t.Errorf("PreviewURL(%q) = %q, want %q", tt.kind, got, tt.want)
+		}
+	}
+
+	// Without a scope, the unscoped fallback is shown
+	client.GetConfig().UpdateScope("", "")
+	if got, want := client.PreviewURL(permissio.URLKindFacts, "/users"), apiURL+"/v1/users"; got != want {
+		t.Errorf("PreviewURL() without scope = %q, want %q", got, want)
+	}
+}
+
+func TestListAccessibleScopes(t *testing.T) {
+	scopes := []models.APIKeyScope{
+		{ProjectID: "proj", EnvironmentID: "dev"},
+		{ProjectID: "proj", EnvironmentID: "prod"},
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/api-key/scopes" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-Team") != "platform" {
+			t.Errorf("X-Team header = %q, want custom header", r.Header.Get("X-Team"))
+		}
+		if r.Header.Get("Authorization") != "Bearer permis_key_refreshed" {
+			http.Error(w, `{"message":"expired"}`, http.StatusUnauthorized)
+			return
+		}
+		writeJSON(t, w, map[string]interface{}{"scopes": scopes})
+	})
+	// Strict scope without a scope must not block listing scopes
+	client := newTestClient(t, handler, func(b *config.ConfigBuilder) {
+		b.WithProjectID("").WithEnvironmentID("").WithStrictScope(true).
+			WithCustomHeaders(map[string]string{"X-Team": "platform"}).
+			WithTokenRefresher(func(ctx context.Context) (string, error) { return "permis_key_refreshed", nil })
+	})
+
+	got, err := client.ListAccessibleScopes(context.Background())
+	if err != nil {
+		t.Fatalf("ListAccessibleScopes() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, scopes) {
+		t.Errorf("ListAccessibleScopes() = %+v, want %+v", got, scopes)
+	}
+}
+
+func TestListAccessibleScopesFallsBackToKeyScope(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/api-key/scope" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(t, w, models.APIKeyScope{ProjectID: "proj", EnvironmentID: "env"})
+	})
+	client := newTestClient(t, handler)
+
+	got, err := client.ListAccessibleScopes(context.Background())
+	if err != nil {
+		t.Fatalf("ListAccessibleScopes() error: %v", err)
+	}
+	want := []models.APIKeyScope{{ProjectID: "proj", EnvironmentID: "env"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListAccessibleScopes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEnvironmentSlugResolvedOnce(t *testing.T) {
+	var lookups atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/my-app/environments/default" {
+			http.NotFound(w, r)
+			return
+		}
+		lookups.Add(1)
+		writeJSON(t, w, map[string]string{"id": "env-1", "project_id": "proj-1"})
+	})
+	client := newTestClient(t, handler, func(b *config.ConfigBuilder) {
+		b.WithProjectID("").WithEnvironmentID("").WithEnvironmentSlug("my-app", "default")
+	})
+
+	for i := 0; i < 2; i++ {
+		projectID, environmentID, err := client.GetScope(context.Background())
+		if err != nil {
+			t.Fatalf("GetScope() error: %v", err)
+		}
+		if projectID != "proj-1" || environmentID != "env-1" {
+			t.Errorf("GetScope() = %q, %q, want proj-1, env-1", projectID, environmentID)
+		}
+	}
+	if got := lookups.Load(); got != 1 {
+		t.Errorf("environment looked up %d times, want 1", got)
+	}
+}
+
+func TestEnvironmentSlugLookupRetriedUnderStrictScope(t *testing.T) {
+	var lookups atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lookups.Add(1) == 1 {
+			http.Error(w, `{"message":"unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(t, w, map[string]string{"id": "env-1", "project_id": "proj-1"})
+	})
+	client := newTestClient(t, handler, func(b *config.ConfigBuilder) {
+		b.WithProjectID("").WithEnvironmentID("").WithEnvironmentSlug("my-app", "default").
+			WithStrictScope(true).WithRetryAttempts(1)
+	})
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	if got := lookups.Load(); got != 2 {
+		t.Errorf("environment looked up %d times, want a retry after the 503", got)
+	}
+}
+
+func TestEnvironmentSlugUnsupported(t *testing.T) {
+	client := newTestClient(t, http.NotFoundHandler(), func(b *config.ConfigBuilder) {
+		b.WithProjectID("").WithEnvironmentID("").WithEnvironmentSlug("my-app", "default")
+	})
+
+	if err := client.Init(context.Background()); err == nil {
+		t.Error("expected Init to fail when slugs can't be resolved")
+	}
+}
+
+func TestCheckTimeoutBoundsChecks(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	client := newTestClient(t, slow, func(b *config.ConfigBuilder) {
+		b.WithCheckTimeout(20 * time.Millisecond).WithThrowOnError(true)
+	})
+
+	start := time.Now()
+	_, err := client.Check(enforcement.User{Key: "alice"}, "read", enforcement.Resource{Type: "document"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Check() error = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Check() took %v, want it bounded by the check timeout", elapsed)
+	}
+
+	// Admin operations are not bound by the check timeout
+	api := newFakeAPI(t)
+	client = newTestClient(t, api, func(b *config.ConfigBuilder) { b.WithCheckTimeout(time.Nanosecond) })
+	if _, err := client.Api.Roles.List(context.Background(), nil); err != nil {
+		t.Errorf("Roles.List() error: %v", err)
+	}
+}
+
+func TestCheckAgainstMatchesClientChecks(t *testing.T) {
+	granted := []string{"document:read", "report:*", "*:*", "*:read", "invoice.read"}
+	checks := []struct {
+		resourceType string
+		action       enforcement.Action
+	}{
+		{"document", "read"},
+		{"document", "write"},
+		{"report", "delete"},
+		{"invoice", "read"},
+		{"user", "read"},
+	}
+
+	for _, perm := range granted {
+		api := newFakeAPI(t)
+		api.roles = []models.RoleRead{{Key: "role", Permissions: []string{perm}}}
+		api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "role"}}
+		client := newTestClient(t, api)
+
+		for _, check := range checks {
+			resource := enforcement.Resource{Type: check.resourceType}
+			response, err := client.CheckWithDetails(context.Background(), enforcement.User{Key: "alice"}, check.action, resource)
+			if err != nil {
+				t.Fatalf("CheckWithDetails() error: %v", err)
+			}
+			if got := enforcement.CheckAgainst([]string{perm}, check.action, resource); got != response.Allowed {
+				t.Errorf("%s on %s:%s: CheckAgainst = %v, client check = %v", perm, check.resourceType, check.action, got, response.Allowed)
+			}
+		}
+	}
+}
+
+func TestPDPDebugInfoPassesThroughCheckResponse(t *testing.T) {
+	pdp := pdpFunc(func(ctx context.Context, req models.CheckRequest) (*models.CheckResponse, error) {
+		if req.Action == "delete" {
+			// No debug info at all
+			return &models.CheckResponse{Allowed: false}, nil
+		}
+		return &models.CheckResponse{
+			Allowed: true,
+			Reason:  "allowed by rbac policy",
+			Debug: &models.CheckDebugInfo{
+				PolicyRules: []string{"data.permissio.rbac.allow"},
+				DecisionID:  "decision-1",
+			},
+		}, nil
+	})
+	client := newTestClient(t, http.NotFoundHandler(), func(b *config.ConfigBuilder) {
+		b.WithPDP(pdp)
+	})
+	user := enforcement.User{Key: "alice"}
+	resource := enforcement.Resource{Type: "document"}
+
+	explained, err := client.CheckWithDetails(context.Background(), user, "read", resource)
+	if err != nil {
+		t.Fatalf("CheckWithDetails() error: %v", err)
+	}
+	if !explained.Allowed || explained.Reason != "allowed by rbac policy" {
+		t.Errorf("response = %+v", explained)
+	}
+	if !reflect.DeepEqual(explained.Debug.PolicyRules, []string{"data.permissio.rbac.allow"}) || explained.Debug.DecisionID != "decision-1" {
+		t.Errorf("debug = %+v", explained.Debug)
+	}
+
+	plain, err := client.CheckWithDetails(context.Background(), user, "delete", resource)
+	if err != nil {
+		t.Fatalf("CheckWithDetails() error: %v", err)
+	}
+	if plain.Allowed || plain.Debug.PolicyRules != nil || plain.Debug.RequiredPermission != "document:delete" {
+		t.Errorf("response without explanation = %+v, debug = %+v", plain, plain.Debug)
+	}
+}
+
+func TestMaxInheritanceDepthTruncatesInheritance(t *testing.T) {
+	api := newFakeAPI(t)
+	api.roles = []models.RoleRead{
+		{Key: "admin", Extends: []string{"editor"}},
+		{Key: "editor", Extends: []string{"viewer"}},
+		{Key: "viewer", Permissions: []string{"document:read"}},
+	}
+	api.assignments = []models.RoleAssignmentRead{{User: "alice", Role: "admin"}}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithMaxInheritanceDepth(1)
+	})
+
+	response, err := client.CheckWithDetails(context.Background(), enforcement.User{Key: "alice"}, "read", enforcement.Resource{Type: "document"})
+	if err != nil {
+		t.Fatalf("CheckWithDetails() error: %v", err)
+	}
+	if response.Allowed {
+		t.Error("expected permission two levels up to be cut off at depth 1")
+	}
+	if !reflect.DeepEqual(response.Debug.TruncatedRoles, []string{"admin"}) {
+		t.Errorf("TruncatedRoles = %v", response.Debug.TruncatedRoles)
+	}
+}
+
+func TestTenantValidationReportsUnknownTenant(t *testing.T) {
+	api := newFakeAPI(t)
+	api.tenants = []string{"acme"}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithTenantValidation(true)
+	})
+	user := enforcement.User{Key: "alice"}
+
+	typo, err := client.CheckWithDetails(context.Background(), user, "read", enforcement.Resource{Type: "document", Tenant: "acme-crop"})
+	if err != nil {
+		t.Fatalf("CheckWithDetails() error: %v", err)
+	}
+	if typo.Allowed || typo.Reason != "Tenant acme-crop not found" {
+		t.Errorf("unknown tenant: response = %+v", typo)
+	}
+
+	for i := 0; i < 2; i++ {
+		known, err := client.CheckWithDetails(context.Background(), user, "read", enforcement.Resource{Type: "document", Tenant: "acme"})
+		if err != nil {
+			t.Fatalf("CheckWithDetails() error: %v", err)
+		}
+		if known.Reason != "User alice has no role assignments" {
+			t.Errorf("existing tenant: reason = %q", known.Reason)
+		}
+	}
+	if got := api.count("/tenants/acme"); got != 1 {
+		t.Errorf("tenant looked up %d times, want 1 (cached)", got)
+	}
+}
+
+func TestTenantValidationCacheExpires(t *testing.T) {
+	now := time.Now()
+	defer permissio.SetCacheClock(func() time.Time { return now })()
+
+	api := newFakeAPI(t)
+	api.tenants = []string{"acme", "globex"}
+	client := newTestClient(t, api, func(b *config.ConfigBuilder) {
+		b.WithTenantValidation(true)
+	})
+	check := func(tenant string) {
+		t.Helper()
+		if _, err := client.CheckWithDetails(context.Background(), enforcement.User{Key: "alice"}, "read",
+			enforcement.Resource{Type: "document", Tenant: tenant}); err != nil {
+			t.Fatalf("CheckWithDetails() error: %v", err)
+		}
+	}
+
+	check("acme")
+	now = now.Add(11 * time.Minute)
+	check("acme")
+	if got := api.count("/tenants/acme"); got != 2 {
+		t.Errorf("tenant looked up %d times, want 2 after the cache expired", got)
+	}
+
+	// Expired tenants are dropped instead of accumulating
+	now = now.Add(11 * time.Minute)
+	check("globex")
+	if got := client.KnownTenants(); got != 1 {
+		t.Errorf("known tenants = %d, want only globex", got)
+	}
+}
+
+func TestCheckHasDefaultDeadline(t *testing.T) {
+	defer permissio.SetDefaultCheckTimeout(50 * time.Millisecond)()
+
+	hung := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	client := newTestClient(t, hung, func(b *config.ConfigBuilder) {
+		b.WithHTTPClient(&http.Client{}).WithThrowOnError(true)
+	})
+
+	start := time.Now()
+	_, err := client.Check(enforcement.User{Key: "alice"}, "read", enforcement.Resource{Type: "document"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Check() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Check() took %v, want it bounded by the default deadline", elapsed)
+	}
+}
@@ -0,0 +1,41 @@
+package permissio
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientClosed is returned by client methods after Close has been called.
+var ErrClientClosed = errors.New("permissio: client is closed")
+
+// Close stops the client's background goroutines (such as WatchUser pollers)
+// and closes the idle connections of the HTTP client built by the config. An
+// HTTP client supplied with WithHTTPClient is left untouched, since other
+// code may share it. After Close, client methods return ErrClientClosed.
+// Close is idempotent and safe to call concurrently.
+func (c *Client) Close() error {
+	c.closeCancel()
+	if c.config.OwnsHTTPClient() {
+		c.config.HTTPClient.CloseIdleConnections()
+	}
+	return nil
+}
+
+// checkOpen returns ErrClientClosed once Close has been called.
+func (c *Client) checkOpen() error {
+	if c.closeCtx.Err() != nil {
+		return ErrClientClosed
+	}
+	return nil
+}
+
+// withClientLifetime returns a context that is also cancelled when the client
+// is closed. The returned cancel func must be called to release resources.
+func (c *Client) withClientLifetime(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.closeCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,38 @@
+package permissio
+
+import "time"
+
+// SetDefaultCheckTimeout overrides the deadline applied by Check without a
+// configured check timeout, returning a function that restores it.
+func SetDefaultCheckTimeout(timeout time.Duration) (restore func()) {
+	previous := defaultCheckTimeout
+	defaultCheckTimeout = timeout
+	return func() { defaultCheckTimeout = previous }
+}
+
+// SetCacheClock overrides the clock of the check cache, returning a function
+// that restores it.
+func SetCacheClock(now func() time.Time) (restore func()) {
+	previous := cacheNow
+	cacheNow = now
+	return func() { cacheNow = previous }
+}
+
+// CachedAssignments returns the number of role assignments cache entries.
+func (c *Client) CachedAssignments() int {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	return len(c.cache.assignments)
+}
+
+// KnownTenants returns the number of tenants cached by tenant validation.
+func (c *Client) KnownTenants() int {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	return len(c.cache.tenants)
+}
+
+// RefreshScope re-fetches the API key scope, as the periodic refresh does.
+func (c *Client) RefreshScope() {
+	c.refreshScope()
+}
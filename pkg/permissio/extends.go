@@ -0,0 +1,109 @@
+package permissio
+
+import (
+	"context"
+	"errors"
+	"maps"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/models"
+	"go.uber.org/zap"
+)
+
+// resolveMissingParents returns rolesMap along with the parent roles, reachable
+// through Extends from the assigned roles, that are missing from it (deleted,
+// or on a page that wasn't fetched), fetched by key. rolesMap may be shared
+// and is left unmodified: fetched parents are added to a copy. Parents that
+// can't be fetched are skipped.
+func (c *Client) resolveMissingParents(ctx context.Context, rolesMap map[string]*models.RoleRead, assignments models.RoleAssignmentList) map[string]*models.RoleRead {
+	resolved, copied := rolesMap, false
+	seen := make(map[string]struct{})
+	var queue []*models.RoleRead
+	for _, assignment := range assignments {
+		if _, ok := seen[assignment.Role]; ok {
+			continue
+		}
+		seen[assignment.Role] = struct{}{}
+		if role, ok := rolesMap[assignment.Role]; ok {
+			queue = append(queue, role)
+		}
+	}
+
+	for len(queue) > 0 {
+		role := queue[0]
+		queue = queue[1:]
+		for _, parentKey := range role.Extends {
+			if _, ok := seen[parentKey]; ok {
+				continue
+			}
+			seen[parentKey] = struct{}{}
+
+			parent, ok := resolved[parentKey]
+			if !ok {
+				if parent = c.fetchParent(ctx, parentKey); parent == nil {
+					continue
+				}
+				if !copied {
+					resolved, copied = maps.Clone(rolesMap), true
+				}
+				resolved[parentKey] = parent
+			}
+			queue = append(queue, parent)
+		}
+	}
+	return resolved
+}
+
+// fetchParent fetches a parent role missing from the roles list by key, or
+// returns nil if it can't be fetched. Parents that don't exist are remembered
+// for CacheTTL, or for the client's lifetime without a cache, so that a role
+// extending a deleted parent doesn't cost a request on every check.
+func (c *Client) fetchParent(ctx context.Context, roleKey string) *models.RoleRead {
+	if role, ok := c.cachedParent(roleKey); ok {
+		return role
+	}
+
+	role, err := c.Api.Roles.Get(ctx, roleKey)
+	if err != nil {
+		if c.debugEnabled(ctx) {
+			c.config.Logger.Debug("Failed to fetch extended parent role",
+				zap.String("role", roleKey),
+				zap.Error(err))
+		}
+		var apiErr *api.PermisError
+		if errors.As(err, &apiErr) && apiErr.IsNotFound() {
+			c.cacheParent(roleKey, nil)
+		}
+		return nil
+	}
+	c.cacheParent(roleKey, role)
+	return role
+}
+
+// warnUnresolvedParent logs a warning (once per role and parent) when a role
+// extends a parent that doesn't exist, so its inherited permissions are missing.
+func (c *Client) warnUnresolvedParent(roleKey, parentKey string) {
+	if c.config.Logger == nil {
+		return
+	}
+	if _, warned := c.warnedParents.LoadOrStore(roleKey+"\x00"+parentKey, struct{}{}); warned {
+		return
+	}
+	c.config.Logger.Warn("Role extends a parent role that could not be found; its permissions are not inherited",
+		zap.String("role", roleKey),
+		zap.String("parent", parentKey))
+}
+
+// warnDepthExceeded logs a warning (once per role) when a role's inheritance
+// is deeper than the configured maximum, so some inherited permissions are missing.
+func (c *Client) warnDepthExceeded(roleKey string) {
+	if c.config.Logger == nil {
+		return
+	}
+	if _, warned := c.warnedDepth.LoadOrStore(roleKey, struct{}{}); warned {
+		return
+	}
+	c.config.Logger.Warn("Role inheritance exceeds the maximum depth; deeper permissions are not inherited",
+		zap.String("role", roleKey),
+		zap.Int("maxDepth", c.config.MaxInheritanceDepth))
+}
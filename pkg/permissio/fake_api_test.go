@@ -0,0 +1,177 @@
+package permissio_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// fakeAPI is an in-memory stand-in for the Permissio.io API used by client tests.
+type fakeAPI struct {
+	t *testing.T
+
+	mu          sync.Mutex
+	roles       []models.RoleRead
+	assignments []models.RoleAssignmentRead
+	resources   map[string][]string
+	requests    map[string]int
+
+	// users holds the keys of users created with PUT /users/{key}.
+	users map[string]bool
+
+	// tenants holds the keys of tenants served by GET /tenants/{key}.
+	tenants []string
+
+	// failRoles makes role assignment creation fail for these role keys.
+	failRoles map[string]bool
+
+	// unlistedRoles can be fetched by key but are missing from the roles list.
+	unlistedRoles []models.RoleRead
+
+	// instances holds the resource instances created with POST
+	// /resources/{type}/instances. Like the real API, an instance created
+	// without a tenant is put in "default".
+	instances []models.ResourceInstanceRead
+}
+
+// newFakeAPI creates an empty fakeAPI.
+func newFakeAPI(t *testing.T) *fakeAPI {
+	return &fakeAPI{t: t, resources: map[string][]string{}, requests: map[string]int{}, users: map[string]bool{}}
+}
+
+// count returns how many requests were made to the given path suffix.
+func (f *fakeAPI) count(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+// rolesPage returns the page of roles requested by the page and perPage query
+// parameters.
+func (f *fakeAPI) rolesPage(query url.Values) models.RoleList {
+	page, perPage, start, end := pageBounds(query, len(f.roles))
+	return models.RoleList{
+		Data: f.roles[start:end],
+		PaginatedResponse: models.PaginatedResponse{
+			Page:       page,
+			PerPage:    perPage,
+			TotalPages: max((len(f.roles)+perPage-1)/perPage, 1),
+			Total:      len(f.roles),
+		},
+	}
+}
+
+// pageBounds returns the page and page size requested by the page and perPage
+// query parameters, and the bounds of that page among total items. Without
+// perPage, all items are on one page.
+func pageBounds(query url.Values, total int) (page, perPage, start, end int) {
+	page, _ = strconv.Atoi(query.Get("page"))
+	page = max(page, 1)
+	perPage, _ = strconv.Atoi(query.Get("perPage"))
+	if perPage <= 0 {
+		perPage = max(total, 1)
+	}
+	start = min((page-1)*perPage, total)
+	end = min(start+perPage, total)
+	return page, perPage, start, end
+}
+
+// ServeHTTP implements http.Handler.
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/facts/proj/env")
+	path = strings.TrimPrefix(path, "/v1/schema/proj/env")
+	f.requests[path]++
+
+	switch {
+	case r.Method == http.MethodGet && path == "/role_assignments":
+		q := r.URL.Query()
+		result := models.RoleAssignmentList{}
+		for _, a := range f.assignments {
+			if (q.Get("user") == "" || q.Get("user") == a.User) &&
+				(q.Get("tenant") == "" || q.Get("tenant") == a.Tenant) &&
+				(q.Get("role") == "" || q.Get("role") == a.Role) {
+				result = append(result, a)
+			}
+		}
+		_, _, start, end := pageBounds(q, len(result))
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(result)))
+		writeJSON(f.t, w, result[start:end])
+	case r.Method == http.MethodGet && path == "/roles":
+		writeJSON(f.t, w, f.rolesPage(r.URL.Query()))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/roles/"):
+		key := strings.TrimPrefix(path, "/roles/")
+		for _, role := range append(f.roles, f.unlistedRoles...) {
+			if role.Key == key {
+				writeJSON(f.t, w, role)
+				return
+			}
+		}
+		http.Error(w, `{"message":"role not found","code":"NOT_FOUND"}`, http.StatusNotFound)
+	case r.Method == http.MethodGet && path == "/resources":
+		data := make([]map[string]interface{}, 0, len(f.resources))
+		for key, actions := range f.resources {
+			data = append(data, map[string]interface{}{"key": key, "actions": actions})
+		}
+		writeJSON(f.t, w, map[string]interface{}{"data": data, "page": 1, "totalPages": 1, "total": len(data)})
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/users/"):
+		var user models.UserCreate
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+			f.t.Fatalf("failed to decode user: %v", err)
+		}
+		if !f.users[user.Key] {
+			f.users[user.Key] = true
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+		}
+		writeJSON(f.t, w, models.UserRead{Key: user.Key, Email: user.Email})
+	case r.Method == http.MethodPost && path == "/role_assignments":
+		var assignment models.RoleAssignmentCreate
+		if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil {
+			f.t.Fatalf("failed to decode role assignment: %v", err)
+		}
+		if f.failRoles[assignment.Role] {
+			http.Error(w, `{"message":"role not found","code":"NOT_FOUND"}`, http.StatusNotFound)
+			return
+		}
+		created := models.RoleAssignmentRead{
+			User:             assignment.User,
+			Role:             assignment.Role,
+			Tenant:           assignment.Tenant,
+			Resource:         assignment.Resource,
+			ResourceInstance: assignment.ResourceInstance,
+		}
+		f.assignments = append(f.assignments, created)
+		writeJSON(f.t, w, created)
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/resources/") && strings.HasSuffix(path, "/instances"):
+		var instance models.ResourceInstanceCreate
+		if err := json.NewDecoder(r.Body).Decode(&instance); err != nil {
+			f.t.Fatalf("failed to decode resource instance: %v", err)
+		}
+		if instance.Tenant == "" {
+			instance.Tenant = "default"
+		}
+		created := models.ResourceInstanceRead{Key: instance.Key, ResourceType: instance.ResourceType, Tenant: instance.Tenant}
+		f.instances = append(f.instances, created)
+		writeJSON(f.t, w, created)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/tenants/"):
+		key := strings.TrimPrefix(path, "/tenants/")
+		for _, tenant := range f.tenants {
+			if tenant == key {
+				writeJSON(f.t, w, models.TenantRead{Key: key})
+				return
+			}
+		}
+		http.Error(w, `{"message":"tenant not found","code":"NOT_FOUND"}`, http.StatusNotFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
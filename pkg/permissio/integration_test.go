@@ -431,8 +431,11 @@ func TestIntegration(t *testing.T) {
 		if err != nil {
 			t.Fatalf("SyncUser() failed: %v", err)
 		}
-		if synced.Key != syncUserKey {
-			t.Errorf("expected key %q, got %q", syncUserKey, synced.Key)
+		if err := synced.Err(); err != nil {
+			t.Fatalf("SyncUser() role assignment failed: %v", err)
+		}
+		if synced.User.Key != syncUserKey {
+			t.Errorf("expected key %q, got %q", syncUserKey, synced.User.Key)
 		}
 
 		// Verify role was assigned
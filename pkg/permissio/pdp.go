@@ -0,0 +1,79 @@
+package permissio
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/permissio/permissio-go/pkg/enforcement"
+	"github.com/permissio/permissio-go/pkg/models"
+	"go.uber.org/zap"
+)
+
+// checkWithPDP evaluates a permission check on the configured PDP.
+func (c *Client) checkWithPDP(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (*models.CheckResponse, error) {
+	response, err := c.queryPDP(ctx, user, action, resource)
+	if err != nil {
+		if c.config.ThrowOnError {
+			return nil, err
+		}
+		return &models.CheckResponse{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Error querying PDP: %v", err),
+		}, nil
+	}
+	return response, nil
+}
+
+// queryPDP asks the configured PDP to decide a permission check.
+// The check Context attached to ctx (if any) is passed along with the request.
+func (c *Client) queryPDP(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (*models.CheckResponse, error) {
+	user.Key = c.config.APIUserKey(user.Key)
+	request := models.CheckRequest{
+		User:     user,
+		Action:   string(action),
+		Resource: resource,
+		Tenant:   resource.Tenant,
+	}
+	if checkCtx, ok := enforcement.CheckContextFromContext(ctx); ok {
+		request.Context = checkCtx.Data()
+	}
+
+	if c.debugEnabled(ctx) {
+		c.config.Logger.Debug("PDP permission check",
+			zap.String("user", user.Key),
+			zap.String("action", string(action)),
+			zap.String("resource", resource.Type))
+	}
+
+	response, err := c.config.PDP.Check(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, fmt.Errorf("PDP returned no response for %s:%s", resource.Type, action)
+	}
+	return response, nil
+}
+
+// checkHybrid evaluates a permission check locally and asks the PDP for a
+// second opinion only when the local result is a deny, so grants the local
+// evaluator can't see (ABAC, relationships) are still honored. A PDP failure
+// keeps the local deny.
+func (c *Client) checkHybrid(ctx context.Context, user enforcement.User, action enforcement.Action, resource enforcement.Resource) (*models.CheckResponse, error) {
+	local, err := c.checkLocal(ctx, user, action, resource)
+	if err != nil || local.Allowed {
+		return local, err
+	}
+
+	response, err := c.queryPDP(ctx, user, action, resource)
+	if err != nil {
+		if c.debugEnabled(ctx) {
+			c.config.Logger.Debug("PDP confirmation failed, keeping local deny",
+				zap.String("user", user.Key),
+				zap.Error(err))
+		}
+		return local, nil
+	}
+
+	return response, nil
+}
@@ -0,0 +1,61 @@
+package permissio
+
+import (
+	"context"
+	"sort"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// resourceCatalog returns the resource types and their actions, fetching them
+// on first use and caching the result until InvalidateCache.
+func (c *Client) resourceCatalog(ctx context.Context) (map[string][]string, error) {
+	c.catalogMu.Lock()
+	defer c.catalogMu.Unlock()
+
+	if c.catalog != nil {
+		return c.catalog, nil
+	}
+
+	resources, err := c.Api.Resources.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := make(map[string][]string, len(resources))
+	for _, resource := range resources {
+		catalog[resource.Key] = resource.Actions
+	}
+
+	c.catalog = catalog
+	return catalog, nil
+}
+
+// expandPermissions expands wildcard permissions into the concrete
+// "resourceType:action" permissions of the resource catalog they grant, using
+// the matching of permission checks, so that every expanded permission is one
+// a check allows. Concrete permissions are kept as-is. The result is sorted
+// and de-duplicated.
+func expandPermissions(permissions []string, catalog map[string][]string) []string {
+	expanded := make(map[string]struct{})
+
+	for _, perm := range permissions {
+		if parsed, err := models.ParsePermission(perm); err != nil || !parsed.IsWildcard() {
+			expanded[perm] = struct{}{}
+		}
+	}
+	for resourceType, actions := range catalog {
+		for _, action := range actions {
+			if grantsPermission(permissions, resourceType, action) {
+				expanded[resourceType+":"+action] = struct{}{}
+			}
+		}
+	}
+
+	result := make([]string, 0, len(expanded))
+	for perm := range expanded {
+		result = append(result, perm)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,33 @@
+package permissio
+
+import (
+	"context"
+)
+
+// FactsPath returns the full URL of a facts endpoint (users, tenants,
+// role assignments, resource instances) for the given path, e.g. "/users".
+// Without a scope it falls back to the unscoped "/v1<path>" (or the configured
+// API version); call Init first when relying on auto-fetched scope.
+func (c *Client) FactsPath(path string) string {
+	return c.base.BuildFactsURL(path)
+}
+
+// SchemaPath returns the full URL of a schema endpoint (roles, resources)
+// for the given path, e.g. "/roles".
+// Without a scope it falls back to the unscoped "/v1<path>" (or the configured
+// API version); call Init first when relying on auto-fetched scope.
+func (c *Client) SchemaPath(path string) string {
+	return c.base.BuildSchemaURL(path)
+}
+
+// Do performs a request against an endpoint the SDK doesn't wrap yet.
+// The url is used as-is: build it with FactsPath or SchemaPath to pick the
+// facts or schema routing. body is JSON-encoded and the response is decoded
+// into result when both are non-nil. Requests go through the usual headers,
+// retries and error handling.
+func (c *Client) Do(ctx context.Context, method, url string, body interface{}, result interface{}) error {
+	if err := c.ensureScope(ctx); err != nil {
+		return err
+	}
+	return c.base.Request(ctx, method, url, body, result)
+}
@@ -0,0 +1,115 @@
+package permissio
+
+import (
+	"context"
+	"sort"
+
+	"github.com/permissio/permissio-go/pkg/enforcement"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// HasAnyRoleOptions contains optional parameters for HasAnyRoleWithDetails.
+type HasAnyRoleOptions struct {
+	// Resource restricts the assignments considered to those on a resource
+	// type. ResourceInstance also counts the assignments scoped to that
+	// instance; without it, instance-scoped assignments are ignored.
+	Resource         string
+	ResourceInstance string
+
+	// Inherited also counts a listed role as held when an assigned role
+	// extends it, directly or through other roles, within the configured
+	// maximum inheritance depth.
+	Inherited bool
+}
+
+// HasAnyRoleResult reports the outcome of HasAnyRoleWithDetails.
+type HasAnyRoleResult struct {
+	HasRole bool
+
+	// MatchedRole is the first listed role the user holds.
+	MatchedRole string
+
+	// AssignedRole is the assigned role that grants MatchedRole: MatchedRole
+	// itself, or a role extending it when matched through inheritance.
+	AssignedRole string
+}
+
+// HasAnyRole returns true if the user is assigned any of roles in the tenant.
+// It suits coarse, role-based gates such as "admin or owner".
+func (c *Client) HasAnyRole(ctx context.Context, userKey string, roles []string, tenant string) (bool, error) {
+	result, err := c.HasAnyRoleWithDetails(ctx, userKey, roles, tenant, nil)
+	if err != nil {
+		return false, err
+	}
+	return result.HasRole, nil
+}
+
+// HasAnyRoleWithDetails is HasAnyRole reporting which role matched. Roles are
+// tried in order; a role both assigned and inherited is reported as assigned.
+// Like permission checks, it only counts assignments active at the evaluation
+// time (see enforcement.WithEvalTime).
+func (c *Client) HasAnyRoleWithDetails(ctx context.Context, userKey string, roles []string, tenant string, options *HasAnyRoleOptions) (*HasAnyRoleResult, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &HasAnyRoleOptions{}
+	}
+
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+
+	listed, err := c.userAssignments(ctx, userKey, c.config.TenantOrDefault(tenant))
+	if err != nil {
+		return nil, err
+	}
+
+	target := enforcement.Resource{Type: options.Resource, Key: options.ResourceInstance}
+	var assignments models.RoleAssignmentList
+	for _, assignment := range applicableAssignments(activeAssignments(listed, evalTime(ctx)), target) {
+		if options.Resource == "" || assignment.Resource == options.Resource {
+			assignments = append(assignments, assignment)
+		}
+	}
+	if len(assignments) == 0 {
+		return &HasAnyRoleResult{}, nil
+	}
+
+	var rolesMap map[string]*models.RoleRead
+	if options.Inherited {
+		if rolesMap, err = c.fetchRolesMap(ctx, assignments); err != nil {
+			return nil, err
+		}
+	}
+	evaluator := c.newEvaluator(rolesMap, assignments)
+	assigned := evaluator.AssignedRoles()
+	sort.Strings(assigned)
+
+	// held maps each role the user holds to the assigned role granting it.
+	held := make(map[string]string, len(assigned))
+	for _, roleKey := range assigned {
+		held[roleKey] = roleKey
+	}
+	if options.Inherited {
+		for _, roleKey := range assigned {
+			for _, ancestor := range evaluator.InheritedRoles(roleKey) {
+				if _, ok := held[ancestor]; !ok {
+					held[ancestor] = roleKey
+				}
+			}
+		}
+	}
+
+	for _, roleKey := range roles {
+		if assignedRole, ok := held[roleKey]; ok {
+			return &HasAnyRoleResult{
+				HasRole:      true,
+				MatchedRole:  roleKey,
+				AssignedRole: assignedRole,
+			}, nil
+		}
+	}
+	return &HasAnyRoleResult{}, nil
+}
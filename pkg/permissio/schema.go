@@ -0,0 +1,125 @@
+package permissio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// CopySchemaResult reports the outcome of CopySchema.
+type CopySchemaResult struct {
+	// CreatedResources and UpdatedResources are resource keys in the target environment.
+	CreatedResources []string
+	UpdatedResources []string
+
+	// CreatedRoles and UpdatedRoles are role keys in the target environment.
+	CreatedRoles []string
+	UpdatedRoles []string
+}
+
+// CopySchema copies all resources and roles from the client's environment into
+// targetEnvID in the same project, upserting each and reporting whether the
+// target already had it. Resources are copied first, then roles ordered so
+// parents exist before the roles that extend them. It stops at the first
+// failure, reporting what was copied so far.
+func (c *Client) CopySchema(ctx context.Context, targetEnvID string) (*CopySchemaResult, error) {
+	if targetEnvID == "" {
+		return nil, errors.New("target environment ID is required")
+	}
+
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+	projectID, environmentID := c.config.Scope()
+	if targetEnvID == environmentID {
+		return nil, errors.New("target environment must differ from the source environment")
+	}
+
+	resources, err := c.Api.Resources.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := c.Api.Roles.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	targetCfg := c.config.Clone()
+	targetCfg.UpdateScope(projectID, targetEnvID)
+	targetResources := api.NewResourcesAPI(targetCfg)
+	targetRoles := api.NewRolesAPI(targetCfg)
+
+	result := &CopySchemaResult{}
+	for _, resource := range resources {
+		_, created, err := targetResources.Upsert(ctx, &models.ResourceCreate{
+			Key:         resource.Key,
+			Name:        resource.Name,
+			Description: resource.Description,
+			Actions:     resource.Actions,
+			Attributes:  resource.Attributes,
+		})
+		if err != nil {
+			return result, fmt.Errorf("copy resource %s: %w", resource.Key, err)
+		}
+		if created {
+			result.CreatedResources = append(result.CreatedResources, resource.Key)
+		} else {
+			result.UpdatedResources = append(result.UpdatedResources, resource.Key)
+		}
+	}
+
+	for _, role := range parentsFirst(roles) {
+		_, created, err := targetRoles.Upsert(ctx, &models.RoleCreate{
+			Key:         role.Key,
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+			Extends:     role.Extends,
+			Attributes:  role.Attributes,
+		})
+		if err != nil {
+			return result, fmt.Errorf("copy role %s: %w", role.Key, err)
+		}
+		if created {
+			result.CreatedRoles = append(result.CreatedRoles, role.Key)
+		} else {
+			result.UpdatedRoles = append(result.UpdatedRoles, role.Key)
+		}
+	}
+
+	return result, nil
+}
+
+// parentsFirst orders roles so every role comes after the roles it extends.
+// Inheritance cycles are broken at the first role visited.
+func parentsFirst(roles []models.RoleRead) []models.RoleRead {
+	byKey := make(map[string]models.RoleRead, len(roles))
+	for _, role := range roles {
+		byKey[role.Key] = role
+	}
+
+	ordered := make([]models.RoleRead, 0, len(roles))
+	state := make(map[string]int) // 0 = unvisited, 1 = visiting, 2 = done
+	var visit func(key string)
+	visit = func(key string) {
+		role, ok := byKey[key]
+		if !ok || state[key] != 0 {
+			return
+		}
+		state[key] = 1
+		for _, parentKey := range role.Extends {
+			visit(parentKey)
+		}
+		state[key] = 2
+		ordered = append(ordered, role)
+	}
+
+	for _, role := range roles {
+		visit(role.Key)
+	}
+	return ordered
+}
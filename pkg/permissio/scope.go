@@ -0,0 +1,191 @@
+package permissio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/models"
+	"go.uber.org/zap"
+)
+
+// ListAccessibleScopes returns the project/environment pairs the API key can
+// reach, for tooling that works across scopes with a single key, such as an
+// organization-level key. A client for any of them is created by building a
+// config with WithProjectID and WithEnvironmentID.
+//
+// Where the API does not expose the scopes listing, the key is assumed to be
+// single-scope and the result holds the scope from /v1/api-key/scope.
+func (c *Client) ListAccessibleScopes(ctx context.Context) ([]models.APIKeyScope, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/api-key/scopes", c.config.ApiURL, c.config.Version())
+
+	var result struct {
+		Scopes []models.APIKeyScope `json:"scopes"`
+	}
+	err := c.base.GetUnscoped(ctx, endpoint, &result)
+	var apiErr *api.PermisError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			scope, err := c.fetchScope(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return []models.APIKeyScope{*scope}, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API key scopes: %w", err)
+	}
+	return result.Scopes, nil
+}
+
+// ScopeInfo reports the client's current scope and where it comes from, for
+// diagnostics such as an admin health endpoint. fetchedAt is when the scope
+// was last fetched from the API (by auto-detection, a scope refresh or an
+// environment slug lookup); it is zero if the scope is configured, in which
+// case fromConfig is true. All values are empty before the scope is known.
+// ScopeInfo never makes a request and is safe for concurrent use.
+func (c *Client) ScopeInfo() (projectID, environmentID string, fetchedAt time.Time, fromConfig bool) {
+	c.scopeInfoMu.RLock()
+	projectID, environmentID = c.config.Scope()
+	fetchedAt = c.scopeFetchedAt
+	c.scopeInfoMu.RUnlock()
+	fromConfig = fetchedAt.IsZero() && projectID != "" && environmentID != ""
+	return projectID, environmentID, fetchedAt, fromConfig
+}
+
+// setFetchedScope sets the scope just fetched from the API, recording the
+// fetch time under the same lock so ScopeInfo never pairs the IDs of one
+// fetch with the time of another.
+func (c *Client) setFetchedScope(projectID, environmentID string) {
+	c.scopeInfoMu.Lock()
+	defer c.scopeInfoMu.Unlock()
+	c.config.UpdateScope(projectID, environmentID)
+	c.scopeFetchedAt = time.Now()
+}
+
+// URL kinds accepted by PreviewURL.
+const (
+	// URLKindFacts is the kind of users, tenants, role assignments and
+	// resource instances URLs.
+	URLKindFacts = "facts"
+
+	// URLKindSchema is the kind of roles and resources URLs.
+	URLKindSchema = "schema"
+
+	// URLKindBase is the kind of unscoped URLs, such as the API key scope.
+	URLKindBase = "base"
+)
+
+// PreviewURL returns the URL a GET request for path would be sent to,
+// without making a request, to check which scope the client resolved. kind is
+// URLKindFacts, URLKindSchema or URLKindBase; other kinds return "". For
+// example, PreviewURL(URLKindFacts, "/users") is
+// "https://api.permissio.io/v1/facts/{project}/{environment}/users" once the
+// scope is known. With a read replica (config.WithReadURL), the URL is on the
+// replica, while writes still go to ApiURL. Without a scope, facts and schema
+// URLs fall back to "{ApiURL}/v1{path}", which most endpoints reject with 404;
+// the scope is not fetched by PreviewURL, so call Init first to preview the
+// URLs of an auto-scoped client.
+func (c *Client) PreviewURL(kind, path string) string {
+	switch kind {
+	case URLKindFacts, URLKindSchema:
+		return c.base.PreviewURL(kind, path)
+	case URLKindBase:
+		return c.base.PreviewURL("", path)
+	default:
+		return ""
+	}
+}
+
+// refreshScopePeriodically re-fetches the API key scope every interval, plus
+// up to 10% random jitter so that many processes don't refresh in lockstep,
+// until the client is closed.
+func (c *Client) refreshScopePeriodically(interval time.Duration) {
+	for {
+		jitter := time.Duration(rand.Int64N(int64(interval)/10 + 1))
+		timer := time.NewTimer(interval + jitter)
+		select {
+		case <-c.closeCtx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		c.refreshScope()
+	}
+}
+
+// refreshScope re-fetches the API key scope and updates the config if the
+// project or environment changed, dropping the data cached for the previous
+// scope. Failures keep the current scope.
+func (c *Client) refreshScope() {
+	scope, err := c.fetchScope(c.closeCtx)
+	if err != nil {
+		if c.config.Debug && c.config.Logger != nil {
+			c.config.Logger.Debug("Scope refresh failed, keeping current scope", zap.Error(err))
+		}
+		return
+	}
+
+	c.scopeMu.Lock()
+	defer c.scopeMu.Unlock()
+
+	projectID, environmentID := c.config.Scope()
+	c.setFetchedScope(scope.ProjectID, scope.EnvironmentID)
+	if scope.ProjectID == projectID && scope.EnvironmentID == environmentID {
+		return
+	}
+
+	// Cached roles and assignments belong to the previous environment
+	c.InvalidateCache()
+
+	if c.config.Logger != nil {
+		c.config.Logger.Info("API key scope changed",
+			zap.String("previousProjectId", projectID),
+			zap.String("previousEnvironmentId", environmentID),
+			zap.String("projectId", scope.ProjectID),
+			zap.String("environmentId", scope.EnvironmentID))
+	}
+}
+
+// resolveEnvironmentSlug looks up the IDs of the configured project and
+// environment slugs and sets them as the scope.
+func (c *Client) resolveEnvironmentSlug(ctx context.Context) error {
+	endpoint := fmt.Sprintf("%s/%s/projects/%s/environments/%s", c.config.ApiURL, c.config.Version(),
+		url.PathEscape(c.config.ProjectSlug), url.PathEscape(c.config.EnvironmentSlug))
+
+	var environment struct {
+		ID        string `json:"id"`
+		ProjectID string `json:"project_id"`
+	}
+	if err := c.base.GetUnscoped(ctx, endpoint, &environment); err != nil {
+		return fmt.Errorf("failed to resolve environment %s/%s: %w. "+
+			"If the API doesn't support slugs, configure projectId and environmentId instead",
+			c.config.ProjectSlug, c.config.EnvironmentSlug, err)
+	}
+	if environment.ID == "" || environment.ProjectID == "" {
+		return fmt.Errorf("failed to resolve environment %s/%s: response has no IDs", c.config.ProjectSlug, c.config.EnvironmentSlug)
+	}
+
+	c.setFetchedScope(environment.ProjectID, environment.ID)
+
+	if c.debugEnabled(ctx) {
+		c.config.Logger.Debug("Resolved environment slug",
+			zap.String("projectSlug", c.config.ProjectSlug),
+			zap.String("environmentSlug", c.config.EnvironmentSlug),
+			zap.String("projectId", environment.ProjectID),
+			zap.String("environmentId", environment.ID))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package permissio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+// SyncResult is the outcome of SyncUser.
+type SyncResult struct {
+	// User is the synced user.
+	User *models.UserRead
+
+	// Created is true if the user was newly created rather than updated.
+	Created bool
+
+	// AssignmentErrors holds one entry per role assignment that failed.
+	AssignmentErrors []AssignmentError
+}
+
+// AssignmentError records a role assignment that failed during SyncUser.
+type AssignmentError struct {
+	Assignment models.RoleAssignmentCreate
+	Err        error
+}
+
+// Error implements the error interface.
+func (e AssignmentError) Error() string {
+	if e.Assignment.Tenant != "" {
+		return fmt.Sprintf("assign role %s in tenant %s: %v", e.Assignment.Role, e.Assignment.Tenant, e.Err)
+	}
+	return fmt.Sprintf("assign role %s: %v", e.Assignment.Role, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e AssignmentError) Unwrap() error {
+	return e.Err
+}
+
+// Err returns the assignment errors joined into one error, or nil if every
+// assignment succeeded.
+func (r *SyncResult) Err() error {
+	errs := make([]error, 0, len(r.AssignmentErrors))
+	for _, assignmentErr := range r.AssignmentErrors {
+		errs = append(errs, assignmentErr)
+	}
+	return errors.Join(errs...)
+}
+
+// SyncUserStrict is like SyncUser but returns an error if any role assignment
+// fails. The synced user is still returned alongside the error.
+func (c *Client) SyncUserStrict(ctx context.Context, user models.UserCreate, roles []models.RoleAssignmentCreate) (*models.UserRead, error) {
+	result, err := c.SyncUser(ctx, user, roles)
+	if err != nil {
+		return nil, err
+	}
+	return result.User, result.Err()
+}
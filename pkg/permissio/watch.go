@@ -0,0 +1,86 @@
+package permissio
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/permissio/permissio-go/pkg/models"
+	"go.uber.org/zap"
+)
+
+// WatchUser polls the user's role assignments every interval, following all
+// pages, and emits the full assignment set on the returned channel whenever it changes (compared by
+// assignment ID). The current set is emitted first. Transient errors are
+// retried on the next tick. The channel is closed when ctx is cancelled or
+// the client is closed.
+func (c *Client) WatchUser(ctx context.Context, userKey string, interval time.Duration) (<-chan []models.RoleAssignmentRead, error) {
+	if interval <= 0 {
+		return nil, errors.New("watch interval must be positive")
+	}
+
+	// Ensure scope is initialized
+	if err := c.ensureScope(ctx); err != nil {
+		return nil, err
+	}
+
+	updates := make(chan []models.RoleAssignmentRead)
+	ctx, cancel := c.withClientLifetime(ctx)
+
+	go func() {
+		defer close(updates)
+		defer cancel()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last map[string]struct{}
+		for {
+			assignments, err := c.Api.RoleAssignments.ListAll(ctx, &models.RoleAssignmentListParams{User: userKey})
+			if err != nil {
+				if c.debugEnabled(ctx) {
+					c.config.Logger.Debug("Watch poll failed, retrying next tick",
+						zap.String("user", userKey),
+						zap.Error(err))
+				}
+			} else if ids := assignmentIDs(assignments); last == nil || !sameIDs(last, ids) {
+				last = ids
+				select {
+				case updates <- assignments:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updates, nil
+}
+
+// assignmentIDs returns the set of assignment IDs.
+func assignmentIDs(assignments models.RoleAssignmentList) map[string]struct{} {
+	ids := make(map[string]struct{}, len(assignments))
+	for _, assignment := range assignments {
+		ids[assignment.ID] = struct{}{}
+	}
+	return ids
+}
+
+// sameIDs returns true if both ID sets are equal.
+func sameIDs(a, b map[string]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id := range a {
+		if _, ok := b[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
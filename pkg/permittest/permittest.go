@@ -0,0 +1,26 @@
+// Package permittest provides mocks of the Permissio.io API clients for
+// testing code that manages users, tenants, roles, resources and role
+// assignments, without an HTTP server.
+//
+// Assign the mocks to the fields of permissio.Client.Api:
+//
+//	client := permissio.New(cfg)
+//	client.Api.Users = &permittest.UsersClient{
+//		GetFunc: func(ctx context.Context, userKey string) (*models.UserRead, error) {
+//			return &models.UserRead{Key: userKey}, nil
+//		},
+//	}
+package permittest
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotMocked is returned by mock methods whose function field is not set.
+var ErrNotMocked = errors.New("permittest: method not mocked")
+
+// notMocked returns an ErrNotMocked error for the named method.
+func notMocked(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotMocked, method)
+}
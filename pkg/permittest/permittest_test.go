@@ -0,0 +1,55 @@
+package permittest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/permissio/permissio-go/pkg/config"
+	"github.com/permissio/permissio-go/pkg/models"
+	"github.com/permissio/permissio-go/pkg/permissio"
+	"github.com/permissio/permissio-go/pkg/permittest"
+)
+
+func TestMockedApiServesClientCalls(t *testing.T) {
+	client := permissio.New(config.NewConfigBuilder("permis_key_test").
+		WithApiUrl("http://127.0.0.1:0").
+		WithProjectID("proj").
+		WithEnvironmentID("env").
+		Build())
+
+	var assigned []string
+	client.Api.Users = &permittest.UsersClient{
+		UpsertFunc: func(ctx context.Context, user models.UserCreate) (*models.UserRead, bool, error) {
+			return &models.UserRead{Key: user.Key}, true, nil
+		},
+	}
+	client.Api.RoleAssignments = &permittest.RoleAssignmentsClient{
+		AssignFunc: func(ctx context.Context, assignment *models.RoleAssignmentCreate) (*models.RoleAssignmentRead, error) {
+			assigned = append(assigned, assignment.User+":"+assignment.Role)
+			return &models.RoleAssignmentRead{User: assignment.User, Role: assignment.Role}, nil
+		},
+	}
+
+	result, err := client.SyncUser(context.Background(), models.UserCreate{Key: "alice"}, []models.RoleAssignmentCreate{
+		{Role: "editor", Tenant: "acme"},
+	})
+	if err != nil {
+		t.Fatalf("SyncUser() error: %v", err)
+	}
+	if !result.Created || result.User.Key != "alice" {
+		t.Errorf("result = %+v", result)
+	}
+	if len(assigned) != 1 || assigned[0] != "alice:editor" {
+		t.Errorf("assigned = %v", assigned)
+	}
+}
+
+func TestUnmockedMethodReturnsErrNotMocked(t *testing.T) {
+	roles := &permittest.RolesClient{}
+
+	role, err := roles.Get(context.Background(), "editor")
+	if role != nil || !errors.Is(err, permittest.ErrNotMocked) {
+		t.Errorf("Get() = %v, %v, want ErrNotMocked", role, err)
+	}
+}
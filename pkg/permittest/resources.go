@@ -0,0 +1,196 @@
+package permittest
+
+import (
+	"context"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+var _ api.ResourcesClient = (*ResourcesClient)(nil)
+
+// ResourcesClient is a mock api.ResourcesClient. Each method calls the function
+// field of the same name with a Func suffix; methods whose function is not set
+// return zero values and an error wrapping ErrNotMocked.
+type ResourcesClient struct {
+	ListFunc                      func(ctx context.Context, params *models.ResourceListParams) (*models.ResourceList, error)
+	ListAllFunc                   func(ctx context.Context, params *models.ResourceListParams) ([]models.ResourceRead, error)
+	GetFunc                       func(ctx context.Context, resourceKey string) (*models.ResourceRead, error)
+	CreateFunc                    func(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, error)
+	UpdateFunc                    func(ctx context.Context, resourceKey string, data *models.ResourceUpdate) (*models.ResourceRead, error)
+	CreateRawFunc                 func(ctx context.Context, body interface{}) (*models.ResourceRead, error)
+	UpdateRawFunc                 func(ctx context.Context, resourceKey string, body interface{}) (*models.ResourceRead, error)
+	DeleteFunc                    func(ctx context.Context, resourceKey string) error
+	SyncFunc                      func(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, error)
+	UpsertFunc                    func(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, bool, error)
+	GetActionsFunc                func(ctx context.Context, resourceKey string) ([]string, error)
+	AddActionFunc                 func(ctx context.Context, resourceKey string, action string) error
+	GetActionsDetailedFunc        func(ctx context.Context, resourceKey string) ([]models.ResourceAction, error)
+	AddActionDetailedFunc         func(ctx context.Context, resourceKey string, action models.ResourceAction) error
+	RemoveActionFunc              func(ctx context.Context, resourceKey string, action string) error
+	CreateInstanceFunc            func(ctx context.Context, resourceKey string, instance *models.ResourceInstanceCreate) (*models.ResourceInstanceRead, error)
+	CreateInstanceWithOptionsFunc func(ctx context.Context, resourceKey string, instance *models.ResourceInstanceCreate, options *api.CreateInstanceOptions) (*models.ResourceInstanceRead, error)
+	GetInstanceFunc               func(ctx context.Context, resourceKey string, instanceKey string) (*models.ResourceInstanceRead, error)
+	InstanceExistsFunc            func(ctx context.Context, resourceKey string, instanceKey string) (bool, error)
+	DeleteInstanceFunc            func(ctx context.Context, resourceKey string, instanceKey string) error
+}
+
+// List calls ListFunc.
+func (m *ResourcesClient) List(ctx context.Context, params *models.ResourceListParams) (*models.ResourceList, error) {
+	if m.ListFunc == nil {
+		return nil, notMocked("ResourcesClient.List")
+	}
+	return m.ListFunc(ctx, params)
+}
+
+// ListAll calls ListAllFunc.
+func (m *ResourcesClient) ListAll(ctx context.Context, params *models.ResourceListParams) ([]models.ResourceRead, error) {
+	if m.ListAllFunc == nil {
+		return nil, notMocked("ResourcesClient.ListAll")
+	}
+	return m.ListAllFunc(ctx, params)
+}
+
+// Get calls GetFunc.
+func (m *ResourcesClient) Get(ctx context.Context, resourceKey string) (*models.ResourceRead, error) {
+	if m.GetFunc == nil {
+		return nil, notMocked("ResourcesClient.Get")
+	}
+	return m.GetFunc(ctx, resourceKey)
+}
+
+// Create calls CreateFunc.
+func (m *ResourcesClient) Create(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, error) {
+	if m.CreateFunc == nil {
+		return nil, notMocked("ResourcesClient.Create")
+	}
+	return m.CreateFunc(ctx, resource)
+}
+
+// Update calls UpdateFunc.
+func (m *ResourcesClient) Update(ctx context.Context, resourceKey string, data *models.ResourceUpdate) (*models.ResourceRead, error) {
+	if m.UpdateFunc == nil {
+		return nil, notMocked("ResourcesClient.Update")
+	}
+	return m.UpdateFunc(ctx, resourceKey, data)
+}
+
+// CreateRaw calls CreateRawFunc.
+func (m *ResourcesClient) CreateRaw(ctx context.Context, body interface{}) (*models.ResourceRead, error) {
+	if m.CreateRawFunc == nil {
+		return nil, notMocked("ResourcesClient.CreateRaw")
+	}
+	return m.CreateRawFunc(ctx, body)
+}
+
+// UpdateRaw calls UpdateRawFunc.
+func (m *ResourcesClient) UpdateRaw(ctx context.Context, resourceKey string, body interface{}) (*models.ResourceRead, error) {
+	if m.UpdateRawFunc == nil {
+		return nil, notMocked("ResourcesClient.UpdateRaw")
+	}
+	return m.UpdateRawFunc(ctx, resourceKey, body)
+}
+
+// Delete calls DeleteFunc.
+func (m *ResourcesClient) Delete(ctx context.Context, resourceKey string) error {
+	if m.DeleteFunc == nil {
+		return notMocked("ResourcesClient.Delete")
+	}
+	return m.DeleteFunc(ctx, resourceKey)
+}
+
+// Sync calls SyncFunc.
+func (m *ResourcesClient) Sync(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, error) {
+	if m.SyncFunc == nil {
+		return nil, notMocked("ResourcesClient.Sync")
+	}
+	return m.SyncFunc(ctx, resource)
+}
+
+// Upsert calls UpsertFunc.
+func (m *ResourcesClient) Upsert(ctx context.Context, resource *models.ResourceCreate) (*models.ResourceRead, bool, error) {
+	if m.UpsertFunc == nil {
+		return nil, false, notMocked("ResourcesClient.Upsert")
+	}
+	return m.UpsertFunc(ctx, resource)
+}
+
+// GetActions calls GetActionsFunc.
+func (m *ResourcesClient) GetActions(ctx context.Context, resourceKey string) ([]string, error) {
+	if m.GetActionsFunc == nil {
+		return nil, notMocked("ResourcesClient.GetActions")
+	}
+	return m.GetActionsFunc(ctx, resourceKey)
+}
+
+// AddAction calls AddActionFunc.
+func (m *ResourcesClient) AddAction(ctx context.Context, resourceKey string, action string) error {
+	if m.AddActionFunc == nil {
+		return notMocked("ResourcesClient.AddAction")
+	}
+	return m.AddActionFunc(ctx, resourceKey, action)
+}
+
+// GetActionsDetailed calls GetActionsDetailedFunc.
+func (m *ResourcesClient) GetActionsDetailed(ctx context.Context, resourceKey string) ([]models.ResourceAction, error) {
+	if m.GetActionsDetailedFunc == nil {
+		return nil, notMocked("ResourcesClient.GetActionsDetailed")
+	}
+	return m.GetActionsDetailedFunc(ctx, resourceKey)
+}
+
+// AddActionDetailed calls AddActionDetailedFunc.
+func (m *ResourcesClient) AddActionDetailed(ctx context.Context, resourceKey string, action models.ResourceAction) error {
+	if m.AddActionDetailedFunc == nil {
+		return notMocked("ResourcesClient.AddActionDetailed")
+	}
+	return m.AddActionDetailedFunc(ctx, resourceKey, action)
+}
+
+// RemoveAction calls RemoveActionFunc.
+func (m *ResourcesClient) RemoveAction(ctx context.Context, resourceKey string, action string) error {
+	if m.RemoveActionFunc == nil {
+		return notMocked("ResourcesClient.RemoveAction")
+	}
+	return m.RemoveActionFunc(ctx, resourceKey, action)
+}
+
+// CreateInstance calls CreateInstanceFunc.
+func (m *ResourcesClient) CreateInstance(ctx context.Context, resourceKey string, instance *models.ResourceInstanceCreate) (*models.ResourceInstanceRead, error) {
+	if m.CreateInstanceFunc == nil {
+		return nil, notMocked("ResourcesClient.CreateInstance")
+	}
+	return m.CreateInstanceFunc(ctx, resourceKey, instance)
+}
+
+// CreateInstanceWithOptions calls CreateInstanceWithOptionsFunc.
+func (m *ResourcesClient) CreateInstanceWithOptions(ctx context.Context, resourceKey string, instance *models.ResourceInstanceCreate, options *api.CreateInstanceOptions) (*models.ResourceInstanceRead, error) {
+	if m.CreateInstanceWithOptionsFunc == nil {
+		return nil, notMocked("ResourcesClient.CreateInstanceWithOptions")
+	}
+	return m.CreateInstanceWithOptionsFunc(ctx, resourceKey, instance, options)
+}
+
+// GetInstance calls GetInstanceFunc.
+func (m *ResourcesClient) GetInstance(ctx context.Context, resourceKey string, instanceKey string) (*models.ResourceInstanceRead, error) {
+	if m.GetInstanceFunc == nil {
+		return nil, notMocked("ResourcesClient.GetInstance")
+	}
+	return m.GetInstanceFunc(ctx, resourceKey, instanceKey)
+}
+
+// InstanceExists calls InstanceExistsFunc.
+func (m *ResourcesClient) InstanceExists(ctx context.Context, resourceKey string, instanceKey string) (bool, error) {
+	if m.InstanceExistsFunc == nil {
+		return false, notMocked("ResourcesClient.InstanceExists")
+	}
+	return m.InstanceExistsFunc(ctx, resourceKey, instanceKey)
+}
+
+// DeleteInstance calls DeleteInstanceFunc.
+func (m *ResourcesClient) DeleteInstance(ctx context.Context, resourceKey string, instanceKey string) error {
+	if m.DeleteInstanceFunc == nil {
+		return notMocked("ResourcesClient.DeleteInstance")
+	}
+	return m.DeleteInstanceFunc(ctx, resourceKey, instanceKey)
+}
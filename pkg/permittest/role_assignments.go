@@ -0,0 +1,197 @@
+package permittest
+
+import (
+	"context"
+	"io"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+var _ api.RoleAssignmentsClient = (*RoleAssignmentsClient)(nil)
+
+// RoleAssignmentsClient is a mock api.RoleAssignmentsClient. Each method calls the function
+// field of the same name with a Func suffix; methods whose function is not set
+// return zero values and an error wrapping ErrNotMocked.
+type RoleAssignmentsClient struct {
+	ListFunc                 func(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	ListWithPaginationFunc   func(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, models.PaginatedResponse, error)
+	ListAllFunc              func(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	CountFunc                func(ctx context.Context, params *models.RoleAssignmentListParams) (int, error)
+	ListByUserFunc           func(ctx context.Context, userKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	ListByTenantFunc         func(ctx context.Context, tenantKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	ListByTenantDetailedFunc func(ctx context.Context, tenantKey string, params *models.RoleAssignmentListParams) ([]models.RoleAssignmentWithRole, error)
+	ListByResourceFunc       func(ctx context.Context, resourceType string, instanceKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error)
+	AssignFunc               func(ctx context.Context, assignment *models.RoleAssignmentCreate) (*models.RoleAssignmentRead, error)
+	UnassignFunc             func(ctx context.Context, user string, role string, tenant string) error
+	UnassignWithResourceFunc func(ctx context.Context, user string, role string, tenant string, resource string, resourceInstance string) error
+	PurgeExpiredFunc         func(ctx context.Context, params *models.RoleAssignmentListParams) (int, error)
+	BulkAssignFunc           func(ctx context.Context, assignments []models.RoleAssignmentCreate) (*models.BulkRoleAssignmentResponse, error)
+	BulkUnassignFunc         func(ctx context.Context, assignments []models.RoleAssignmentCreate) (*models.BulkRoleAssignmentResponse, error)
+	BulkAssignStreamFunc     func(ctx context.Context, r io.Reader, options *api.BulkAssignStreamOptions) (*models.BulkRoleAssignmentResponse, error)
+	HasRoleFunc              func(ctx context.Context, userKey string, roleKey string, options *api.HasRoleOptions) (bool, error)
+	GetUserRolesFunc         func(ctx context.Context, userKey string, options *api.GetUserRolesOptions) ([]string, error)
+	GetRoleUsersFunc         func(ctx context.Context, roleKey string, options *api.GetRoleUsersOptions) ([]string, error)
+	ListDetailedFunc         func(ctx context.Context, params *models.RoleAssignmentListParams) (*models.RoleAssignmentList, error)
+	GetByIDFunc              func(ctx context.Context, id string) (*models.RoleAssignmentRead, error)
+}
+
+// List calls ListFunc.
+func (m *RoleAssignmentsClient) List(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error) {
+	if m.ListFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.List")
+	}
+	return m.ListFunc(ctx, params)
+}
+
+// ListWithPagination calls ListWithPaginationFunc.
+func (m *RoleAssignmentsClient) ListWithPagination(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, models.PaginatedResponse, error) {
+	if m.ListWithPaginationFunc == nil {
+		return nil, models.PaginatedResponse{}, notMocked("RoleAssignmentsClient.ListWithPagination")
+	}
+	return m.ListWithPaginationFunc(ctx, params)
+}
+
+// ListAll calls ListAllFunc.
+func (m *RoleAssignmentsClient) ListAll(ctx context.Context, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error) {
+	if m.ListAllFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.ListAll")
+	}
+	return m.ListAllFunc(ctx, params)
+}
+
+// Count calls CountFunc.
+func (m *RoleAssignmentsClient) Count(ctx context.Context, params *models.RoleAssignmentListParams) (int, error) {
+	if m.CountFunc == nil {
+		return 0, notMocked("RoleAssignmentsClient.Count")
+	}
+	return m.CountFunc(ctx, params)
+}
+
+// ListByUser calls ListByUserFunc.
+func (m *RoleAssignmentsClient) ListByUser(ctx context.Context, userKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error) {
+	if m.ListByUserFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.ListByUser")
+	}
+	return m.ListByUserFunc(ctx, userKey, params)
+}
+
+// ListByTenant calls ListByTenantFunc.
+func (m *RoleAssignmentsClient) ListByTenant(ctx context.Context, tenantKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error) {
+	if m.ListByTenantFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.ListByTenant")
+	}
+	return m.ListByTenantFunc(ctx, tenantKey, params)
+}
+
+// ListByTenantDetailed calls ListByTenantDetailedFunc.
+func (m *RoleAssignmentsClient) ListByTenantDetailed(ctx context.Context, tenantKey string, params *models.RoleAssignmentListParams) ([]models.RoleAssignmentWithRole, error) {
+	if m.ListByTenantDetailedFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.ListByTenantDetailed")
+	}
+	return m.ListByTenantDetailedFunc(ctx, tenantKey, params)
+}
+
+// ListByResource calls ListByResourceFunc.
+func (m *RoleAssignmentsClient) ListByResource(ctx context.Context, resourceType string, instanceKey string, params *models.RoleAssignmentListParams) (models.RoleAssignmentList, error) {
+	if m.ListByResourceFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.ListByResource")
+	}
+	return m.ListByResourceFunc(ctx, resourceType, instanceKey, params)
+}
+
+// Assign calls AssignFunc.
+func (m *RoleAssignmentsClient) Assign(ctx context.Context, assignment *models.RoleAssignmentCreate) (*models.RoleAssignmentRead, error) {
+	if m.AssignFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.Assign")
+	}
+	return m.AssignFunc(ctx, assignment)
+}
+
+// Unassign calls UnassignFunc.
+func (m *RoleAssignmentsClient) Unassign(ctx context.Context, user string, role string, tenant string) error {
+	if m.UnassignFunc == nil {
+		return notMocked("RoleAssignmentsClient.Unassign")
+	}
+	return m.UnassignFunc(ctx, user, role, tenant)
+}
+
+// UnassignWithResource calls UnassignWithResourceFunc.
+func (m *RoleAssignmentsClient) UnassignWithResource(ctx context.Context, user string, role string, tenant string, resource string, resourceInstance string) error {
+	if m.UnassignWithResourceFunc == nil {
+		return notMocked("RoleAssignmentsClient.UnassignWithResource")
+	}
+	return m.UnassignWithResourceFunc(ctx, user, role, tenant, resource, resourceInstance)
+}
+
+// PurgeExpired calls PurgeExpiredFunc.
+func (m *RoleAssignmentsClient) PurgeExpired(ctx context.Context, params *models.RoleAssignmentListParams) (int, error) {
+	if m.PurgeExpiredFunc == nil {
+		return 0, notMocked("RoleAssignmentsClient.PurgeExpired")
+	}
+	return m.PurgeExpiredFunc(ctx, params)
+}
+
+// BulkAssign calls BulkAssignFunc.
+func (m *RoleAssignmentsClient) BulkAssign(ctx context.Context, assignments []models.RoleAssignmentCreate) (*models.BulkRoleAssignmentResponse, error) {
+	if m.BulkAssignFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.BulkAssign")
+	}
+	return m.BulkAssignFunc(ctx, assignments)
+}
+
+// BulkUnassign calls BulkUnassignFunc.
+func (m *RoleAssignmentsClient) BulkUnassign(ctx context.Context, assignments []models.RoleAssignmentCreate) (*models.BulkRoleAssignmentResponse, error) {
+	if m.BulkUnassignFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.BulkUnassign")
+	}
+	return m.BulkUnassignFunc(ctx, assignments)
+}
+
+// BulkAssignStream calls BulkAssignStreamFunc.
+func (m *RoleAssignmentsClient) BulkAssignStream(ctx context.Context, r io.Reader, options *api.BulkAssignStreamOptions) (*models.BulkRoleAssignmentResponse, error) {
+	if m.BulkAssignStreamFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.BulkAssignStream")
+	}
+	return m.BulkAssignStreamFunc(ctx, r, options)
+}
+
+// HasRole calls HasRoleFunc.
+func (m *RoleAssignmentsClient) HasRole(ctx context.Context, userKey string, roleKey string, options *api.HasRoleOptions) (bool, error) {
+	if m.HasRoleFunc == nil {
+		return false, notMocked("RoleAssignmentsClient.HasRole")
+	}
+	return m.HasRoleFunc(ctx, userKey, roleKey, options)
+}
+
+// GetUserRoles calls GetUserRolesFunc.
+func (m *RoleAssignmentsClient) GetUserRoles(ctx context.Context, userKey string, options *api.GetUserRolesOptions) ([]string, error) {
+	if m.GetUserRolesFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.GetUserRoles")
+	}
+	return m.GetUserRolesFunc(ctx, userKey, options)
+}
+
+// GetRoleUsers calls GetRoleUsersFunc.
+func (m *RoleAssignmentsClient) GetRoleUsers(ctx context.Context, roleKey string, options *api.GetRoleUsersOptions) ([]string, error) {
+	if m.GetRoleUsersFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.GetRoleUsers")
+	}
+	return m.GetRoleUsersFunc(ctx, roleKey, options)
+}
+
+// ListDetailed calls ListDetailedFunc.
+func (m *RoleAssignmentsClient) ListDetailed(ctx context.Context, params *models.RoleAssignmentListParams) (*models.RoleAssignmentList, error) {
+	if m.ListDetailedFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.ListDetailed")
+	}
+	return m.ListDetailedFunc(ctx, params)
+}
+
+// GetByID calls GetByIDFunc.
+func (m *RoleAssignmentsClient) GetByID(ctx context.Context, id string) (*models.RoleAssignmentRead, error) {
+	if m.GetByIDFunc == nil {
+		return nil, notMocked("RoleAssignmentsClient.GetByID")
+	}
+	return m.GetByIDFunc(ctx, id)
+}
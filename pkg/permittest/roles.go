@@ -0,0 +1,223 @@
+package permittest
+
+import (
+	"context"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+var _ api.RolesClient = (*RolesClient)(nil)
+
+// RolesClient is a mock api.RolesClient. Each method calls the function
+// field of the same name with a Func suffix; methods whose function is not set
+// return zero values and an error wrapping ErrNotMocked.
+type RolesClient struct {
+	ListFunc                     func(ctx context.Context, params *models.RoleListParams) (*models.RoleList, error)
+	ListAllFunc                  func(ctx context.Context, params *models.RoleListParams) ([]models.RoleRead, error)
+	CountFunc                    func(ctx context.Context, params *models.RoleListParams) (int, error)
+	GetFunc                      func(ctx context.Context, roleKey string) (*models.RoleRead, error)
+	CreateFunc                   func(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, error)
+	UpdateFunc                   func(ctx context.Context, roleKey string, data *models.RoleUpdate) (*models.RoleRead, error)
+	CreateRawFunc                func(ctx context.Context, body interface{}) (*models.RoleRead, error)
+	UpdateRawFunc                func(ctx context.Context, roleKey string, body interface{}) (*models.RoleRead, error)
+	DeleteFunc                   func(ctx context.Context, roleKey string) error
+	SyncFunc                     func(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, error)
+	UpsertFunc                   func(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, bool, error)
+	GetPermissionsFunc           func(ctx context.Context, roleKey string) ([]string, error)
+	AddPermissionFunc            func(ctx context.Context, roleKey string, permission string) error
+	RemovePermissionFunc         func(ctx context.Context, roleKey string, permission string) error
+	GetExtendsFunc               func(ctx context.Context, roleKey string) ([]string, error)
+	GetExtendedByFunc            func(ctx context.Context, roleKey string, options *api.GetExtendedByOptions) ([]string, error)
+	GetExtendedByChainsFunc      func(ctx context.Context, roleKey string) (map[string][]string, error)
+	AddExtendsFunc               func(ctx context.Context, roleKey string, parentRoleKey string) error
+	RemoveExtendsFunc            func(ctx context.Context, roleKey string, parentRoleKey string) error
+	PermissionSourceFunc         func(ctx context.Context, roleKey string, permission string) (*models.PermissionSource, error)
+	EffectivePermissionCountFunc func(ctx context.Context) (map[string]int, error)
+	HierarchyFunc                func(ctx context.Context) (*models.RoleGraph, error)
+	WildcardGrantsFunc           func(ctx context.Context) (map[string]models.RoleWildcards, error)
+}
+
+// List calls ListFunc.
+func (m *RolesClient) List(ctx context.Context, params *models.RoleListParams) (*models.RoleList, error) {
+	if m.ListFunc == nil {
+		return nil, notMocked("RolesClient.List")
+	}
+	return m.ListFunc(ctx, params)
+}
+
+// ListAll calls ListAllFunc.
+func (m *RolesClient) ListAll(ctx context.Context, params *models.RoleListParams) ([]models.RoleRead, error) {
+	if m.ListAllFunc == nil {
+		return nil, notMocked("RolesClient.ListAll")
+	}
+	return m.ListAllFunc(ctx, params)
+}
+
+// Count calls CountFunc.
+func (m *RolesClient) Count(ctx context.Context, params *models.RoleListParams) (int, error) {
+	if m.CountFunc == nil {
+		return 0, notMocked("RolesClient.Count")
+	}
+	return m.CountFunc(ctx, params)
+}
+
+// Get calls GetFunc.
+func (m *RolesClient) Get(ctx context.Context, roleKey string) (*models.RoleRead, error) {
+	if m.GetFunc == nil {
+		return nil, notMocked("RolesClient.Get")
+	}
+	return m.GetFunc(ctx, roleKey)
+}
+
+// Create calls CreateFunc.
+func (m *RolesClient) Create(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, error) {
+	if m.CreateFunc == nil {
+		return nil, notMocked("RolesClient.Create")
+	}
+	return m.CreateFunc(ctx, role)
+}
+
+// Update calls UpdateFunc.
+func (m *RolesClient) Update(ctx context.Context, roleKey string, data *models.RoleUpdate) (*models.RoleRead, error) {
+	if m.UpdateFunc == nil {
+		return nil, notMocked("RolesClient.Update")
+	}
+	return m.UpdateFunc(ctx, roleKey, data)
+}
+
+// CreateRaw calls CreateRawFunc.
+func (m *RolesClient) CreateRaw(ctx context.Context, body interface{}) (*models.RoleRead, error) {
+	if m.CreateRawFunc == nil {
+		return nil, notMocked("RolesClient.CreateRaw")
+	}
+	return m.CreateRawFunc(ctx, body)
+}
+
+// UpdateRaw calls UpdateRawFunc.
+func (m *RolesClient) UpdateRaw(ctx context.Context, roleKey string, body interface{}) (*models.RoleRead, error) {
+	if m.UpdateRawFunc == nil {
+		return nil, notMocked("RolesClient.UpdateRaw")
+	}
+	return m.UpdateRawFunc(ctx, roleKey, body)
+}
+
+// Delete calls DeleteFunc.
+func (m *RolesClient) Delete(ctx context.Context, roleKey string) error {
+	if m.DeleteFunc == nil {
+		return notMocked("RolesClient.Delete")
+	}
+	return m.DeleteFunc(ctx, roleKey)
+}
+
+// Sync calls SyncFunc.
+func (m *RolesClient) Sync(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, error) {
+	if m.SyncFunc == nil {
+		return nil, notMocked("RolesClient.Sync")
+	}
+	return m.SyncFunc(ctx, role)
+}
+
+// Upsert calls UpsertFunc.
+func (m *RolesClient) Upsert(ctx context.Context, role *models.RoleCreate) (*models.RoleRead, bool, error) {
+	if m.UpsertFunc == nil {
+		return nil, false, notMocked("RolesClient.Upsert")
+	}
+	return m.UpsertFunc(ctx, role)
+}
+
+// GetPermissions calls GetPermissionsFunc.
+func (m *RolesClient) GetPermissions(ctx context.Context, roleKey string) ([]string, error) {
+	if m.GetPermissionsFunc == nil {
+		return nil, notMocked("RolesClient.GetPermissions")
+	}
+	return m.GetPermissionsFunc(ctx, roleKey)
+}
+
+// AddPermission calls AddPermissionFunc.
+func (m *RolesClient) AddPermission(ctx context.Context, roleKey string, permission string) error {
+	if m.AddPermissionFunc == nil {
+		return notMocked("RolesClient.AddPermission")
+	}
+	return m.AddPermissionFunc(ctx, roleKey, permission)
+}
+
+// RemovePermission calls RemovePermissionFunc.
+func (m *RolesClient) RemovePermission(ctx context.Context, roleKey string, permission string) error {
+	if m.RemovePermissionFunc == nil {
+		return notMocked("RolesClient.RemovePermission")
+	}
+	return m.RemovePermissionFunc(ctx, roleKey, permission)
+}
+
+// GetExtends calls GetExtendsFunc.
+func (m *RolesClient) GetExtends(ctx context.Context, roleKey string) ([]string, error) {
+	if m.GetExtendsFunc == nil {
+		return nil, notMocked("RolesClient.GetExtends")
+	}
+	return m.GetExtendsFunc(ctx, roleKey)
+}
+
+// GetExtendedBy calls GetExtendedByFunc.
+func (m *RolesClient) GetExtendedBy(ctx context.Context, roleKey string, options *api.GetExtendedByOptions) ([]string, error) {
+	if m.GetExtendedByFunc == nil {
+		return nil, notMocked("RolesClient.GetExtendedBy")
+	}
+	return m.GetExtendedByFunc(ctx, roleKey, options)
+}
+
+// GetExtendedByChains calls GetExtendedByChainsFunc.
+func (m *RolesClient) GetExtendedByChains(ctx context.Context, roleKey string) (map[string][]string, error) {
+	if m.GetExtendedByChainsFunc == nil {
+		return nil, notMocked("RolesClient.GetExtendedByChains")
+	}
+	return m.GetExtendedByChainsFunc(ctx, roleKey)
+}
+
+// AddExtends calls AddExtendsFunc.
+func (m *RolesClient) AddExtends(ctx context.Context, roleKey string, parentRoleKey string) error {
+	if m.AddExtendsFunc == nil {
+		return notMocked("RolesClient.AddExtends")
+	}
+	return m.AddExtendsFunc(ctx, roleKey, parentRoleKey)
+}
+
+// RemoveExtends calls RemoveExtendsFunc.
+func (m *RolesClient) RemoveExtends(ctx context.Context, roleKey string, parentRoleKey string) error {
+	if m.RemoveExtendsFunc == nil {
+		return notMocked("RolesClient.RemoveExtends")
+	}
+	return m.RemoveExtendsFunc(ctx, roleKey, parentRoleKey)
+}
+
+// PermissionSource calls PermissionSourceFunc.
+func (m *RolesClient) PermissionSource(ctx context.Context, roleKey string, permission string) (*models.PermissionSource, error) {
+	if m.PermissionSourceFunc == nil {
+		return nil, notMocked("RolesClient.PermissionSource")
+	}
+	return m.PermissionSourceFunc(ctx, roleKey, permission)
+}
+
+// EffectivePermissionCount calls EffectivePermissionCountFunc.
+func (m *RolesClient) EffectivePermissionCount(ctx context.Context) (map[string]int, error) {
+	if m.EffectivePermissionCountFunc == nil {
+		return nil, notMocked("RolesClient.EffectivePermissionCount")
+	}
+	return m.EffectivePermissionCountFunc(ctx)
+}
+
+// Hierarchy calls HierarchyFunc.
+func (m *RolesClient) Hierarchy(ctx context.Context) (*models.RoleGraph, error) {
+	if m.HierarchyFunc == nil {
+		return nil, notMocked("RolesClient.Hierarchy")
+	}
+	return m.HierarchyFunc(ctx)
+}
+
+// WildcardGrants calls WildcardGrantsFunc.
+func (m *RolesClient) WildcardGrants(ctx context.Context) (map[string]models.RoleWildcards, error) {
+	if m.WildcardGrantsFunc == nil {
+		return nil, notMocked("RolesClient.WildcardGrants")
+	}
+	return m.WildcardGrantsFunc(ctx)
+}
@@ -0,0 +1,142 @@
+package permittest
+
+import (
+	"context"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+var _ api.TenantsClient = (*TenantsClient)(nil)
+
+// TenantsClient is a mock api.TenantsClient. Each method calls the function
+// field of the same name with a Func suffix; methods whose function is not set
+// return zero values and an error wrapping ErrNotMocked.
+type TenantsClient struct {
+	ListFunc            func(ctx context.Context, params *models.TenantListParams) (*models.TenantList, error)
+	ListAllFunc         func(ctx context.Context, params *models.TenantListParams) ([]models.TenantRead, error)
+	GetFunc             func(ctx context.Context, tenantKey string) (*models.TenantRead, error)
+	CreateFunc          func(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, error)
+	UpdateFunc          func(ctx context.Context, tenantKey string, data *models.TenantUpdate) (*models.TenantRead, error)
+	CreateRawFunc       func(ctx context.Context, body interface{}) (*models.TenantRead, error)
+	UpdateRawFunc       func(ctx context.Context, tenantKey string, body interface{}) (*models.TenantRead, error)
+	DeleteFunc          func(ctx context.Context, tenantKey string) error
+	SyncFunc            func(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, error)
+	UpsertFunc          func(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, bool, error)
+	AddUserFunc         func(ctx context.Context, tenantKey string, userKey string) error
+	AddUserIfAbsentFunc func(ctx context.Context, tenantKey string, userKey string) (bool, error)
+	RemoveUserFunc      func(ctx context.Context, tenantKey string, userKey string) error
+	GetUsersFunc        func(ctx context.Context, tenantKey string) ([]string, error)
+}
+
+// List calls ListFunc.
+func (m *TenantsClient) List(ctx context.Context, params *models.TenantListParams) (*models.TenantList, error) {
+	if m.ListFunc == nil {
+		return nil, notMocked("TenantsClient.List")
+	}
+	return m.ListFunc(ctx, params)
+}
+
+// ListAll calls ListAllFunc.
+func (m *TenantsClient) ListAll(ctx context.Context, params *models.TenantListParams) ([]models.TenantRead, error) {
+	if m.ListAllFunc == nil {
+		return nil, notMocked("TenantsClient.ListAll")
+	}
+	return m.ListAllFunc(ctx, params)
+}
+
+// Get calls GetFunc.
+func (m *TenantsClient) Get(ctx context.Context, tenantKey string) (*models.TenantRead, error) {
+	if m.GetFunc == nil {
+		return nil, notMocked("TenantsClient.Get")
+	}
+	return m.GetFunc(ctx, tenantKey)
+}
+
+// Create calls CreateFunc.
+func (m *TenantsClient) Create(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, error) {
+	if m.CreateFunc == nil {
+		return nil, notMocked("TenantsClient.Create")
+	}
+	return m.CreateFunc(ctx, tenant)
+}
+
+// Update calls UpdateFunc.
+func (m *TenantsClient) Update(ctx context.Context, tenantKey string, data *models.TenantUpdate) (*models.TenantRead, error) {
+	if m.UpdateFunc == nil {
+		return nil, notMocked("TenantsClient.Update")
+	}
+	return m.UpdateFunc(ctx, tenantKey, data)
+}
+
+// CreateRaw calls CreateRawFunc.
+func (m *TenantsClient) CreateRaw(ctx context.Context, body interface{}) (*models.TenantRead, error) {
+	if m.CreateRawFunc == nil {
+		return nil, notMocked("TenantsClient.CreateRaw")
+	}
+	return m.CreateRawFunc(ctx, body)
+}
+
+// UpdateRaw calls UpdateRawFunc.
+func (m *TenantsClient) UpdateRaw(ctx context.Context, tenantKey string, body interface{}) (*models.TenantRead, error) {
+	if m.UpdateRawFunc == nil {
+		return nil, notMocked("TenantsClient.UpdateRaw")
+	}
+	return m.UpdateRawFunc(ctx, tenantKey, body)
+}
+
+// Delete calls DeleteFunc.
+func (m *TenantsClient) Delete(ctx context.Context, tenantKey string) error {
+	if m.DeleteFunc == nil {
+		return notMocked("TenantsClient.Delete")
+	}
+	return m.DeleteFunc(ctx, tenantKey)
+}
+
+// Sync calls SyncFunc.
+func (m *TenantsClient) Sync(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, error) {
+	if m.SyncFunc == nil {
+		return nil, notMocked("TenantsClient.Sync")
+	}
+	return m.SyncFunc(ctx, tenant)
+}
+
+// Upsert calls UpsertFunc.
+func (m *TenantsClient) Upsert(ctx context.Context, tenant *models.TenantCreate) (*models.TenantRead, bool, error) {
+	if m.UpsertFunc == nil {
+		return nil, false, notMocked("TenantsClient.Upsert")
+	}
+	return m.UpsertFunc(ctx, tenant)
+}
+
+// AddUser calls AddUserFunc.
+func (m *TenantsClient) AddUser(ctx context.Context, tenantKey string, userKey string) error {
+	if m.AddUserFunc == nil {
+		return notMocked("TenantsClient.AddUser")
+	}
+	return m.AddUserFunc(ctx, tenantKey, userKey)
+}
+
+// AddUserIfAbsent calls AddUserIfAbsentFunc.
+func (m *TenantsClient) AddUserIfAbsent(ctx context.Context, tenantKey string, userKey string) (bool, error) {
+	if m.AddUserIfAbsentFunc == nil {
+		return false, notMocked("TenantsClient.AddUserIfAbsent")
+	}
+	return m.AddUserIfAbsentFunc(ctx, tenantKey, userKey)
+}
+
+// RemoveUser calls RemoveUserFunc.
+func (m *TenantsClient) RemoveUser(ctx context.Context, tenantKey string, userKey string) error {
+	if m.RemoveUserFunc == nil {
+		return notMocked("TenantsClient.RemoveUser")
+	}
+	return m.RemoveUserFunc(ctx, tenantKey, userKey)
+}
+
+// GetUsers calls GetUsersFunc.
+func (m *TenantsClient) GetUsers(ctx context.Context, tenantKey string) ([]string, error) {
+	if m.GetUsersFunc == nil {
+		return nil, notMocked("TenantsClient.GetUsers")
+	}
+	return m.GetUsersFunc(ctx, tenantKey)
+}
@@ -0,0 +1,187 @@
+package permittest
+
+import (
+	"context"
+
+	"github.com/permissio/permissio-go/pkg/api"
+	"github.com/permissio/permissio-go/pkg/models"
+)
+
+var _ api.UsersClient = (*UsersClient)(nil)
+
+// UsersClient is a mock api.UsersClient. Each method calls the function
+// field of the same name with a Func suffix; methods whose function is not set
+// return zero values and an error wrapping ErrNotMocked.
+type UsersClient struct {
+	ListFunc              func(ctx context.Context, params *models.UserListParams) (*models.UserList, error)
+	ListAllFunc           func(ctx context.Context, params *models.UserListParams) ([]models.UserRead, error)
+	CountFunc             func(ctx context.Context, params *models.UserListParams) (int, error)
+	GetFunc               func(ctx context.Context, userKey string) (*models.UserRead, error)
+	CreateFunc            func(ctx context.Context, user *models.UserCreate) (*models.UserRead, error)
+	UpdateFunc            func(ctx context.Context, userKey string, data *models.UserUpdate) (*models.UserRead, error)
+	CreateRawFunc         func(ctx context.Context, body interface{}) (*models.UserRead, error)
+	UpdateRawFunc         func(ctx context.Context, userKey string, body interface{}) (*models.UserRead, error)
+	DeleteFunc            func(ctx context.Context, userKey string) error
+	BulkDeleteFunc        func(ctx context.Context, keys []string) (*models.BulkUserResponse, error)
+	SyncUserFunc          func(ctx context.Context, user models.UserCreate) (*models.UserRead, error)
+	UpsertFunc            func(ctx context.Context, user models.UserCreate) (*models.UserRead, bool, error)
+	AssignRoleFunc        func(ctx context.Context, userKey string, role string, tenant string) (*models.RoleAssignmentRead, error)
+	UnassignRoleFunc      func(ctx context.Context, userKey string, role string, tenant string) error
+	GetRolesFunc          func(ctx context.Context, userKey string, tenant string) ([]string, error)
+	AddTenantFunc         func(ctx context.Context, userKey string, tenantKey string) error
+	AddTenantIfAbsentFunc func(ctx context.Context, userKey string, tenantKey string) (bool, error)
+	RemoveTenantFunc      func(ctx context.Context, userKey string, tenantKey string) error
+	GetTenantsFunc        func(ctx context.Context, userKey string) ([]string, error)
+}
+
+// List calls ListFunc.
+func (m *UsersClient) List(ctx context.Context, params *models.UserListParams) (*models.UserList, error) {
+	if m.ListFunc == nil {
+		return nil, notMocked("UsersClient.List")
+	}
+	return m.ListFunc(ctx, params)
+}
+
+// ListAll calls ListAllFunc.
+func (m *UsersClient) ListAll(ctx context.Context, params *models.UserListParams) ([]models.UserRead, error) {
+	if m.ListAllFunc == nil {
+		return nil, notMocked("UsersClient.ListAll")
+	}
+	return m.ListAllFunc(ctx, params)
+}
+
+// Count calls CountFunc.
+func (m *UsersClient) Count(ctx context.Context, params *models.UserListParams) (int, error) {
+	if m.CountFunc == nil {
+		return 0, notMocked("UsersClient.Count")
+	}
+	return m.CountFunc(ctx, params)
+}
+
+// Get calls GetFunc.
+func (m *UsersClient) Get(ctx context.Context, userKey string) (*models.UserRead, error) {
+	if m.GetFunc == nil {
+		return nil, notMocked("UsersClient.Get")
+	}
+	return m.GetFunc(ctx, userKey)
+}
+
+// Create calls CreateFunc.
+func (m *UsersClient) Create(ctx context.Context, user *models.UserCreate) (*models.UserRead, error) {
+	if m.CreateFunc == nil {
+		return nil, notMocked("UsersClient.Create")
+	}
+	return m.CreateFunc(ctx, user)
+}
+
+// Update calls UpdateFunc.
+func (m *UsersClient) Update(ctx context.Context, userKey string, data *models.UserUpdate) (*models.UserRead, error) {
+	if m.UpdateFunc == nil {
+		return nil, notMocked("UsersClient.Update")
+	}
+	return m.UpdateFunc(ctx, userKey, data)
+}
+
+// CreateRaw calls CreateRawFunc.
+func (m *UsersClient) CreateRaw(ctx context.Context, body interface{}) (*models.UserRead, error) {
+	if m.CreateRawFunc == nil {
+		return nil, notMocked("UsersClient.CreateRaw")
+	}
+	return m.CreateRawFunc(ctx, body)
+}
+
+// UpdateRaw calls UpdateRawFunc.
+func (m *UsersClient) UpdateRaw(ctx context.Context, userKey string, body interface{}) (*models.UserRead, error) {
+	if m.UpdateRawFunc == nil {
+		return nil, notMocked("UsersClient.UpdateRaw")
+	}
+	return m.UpdateRawFunc(ctx, userKey, body)
+}
+
+// Delete calls DeleteFunc.
+func (m *UsersClient) Delete(ctx context.Context, userKey string) error {
+	if m.DeleteFunc == nil {
+		return notMocked("UsersClient.Delete")
+	}
+	return m.DeleteFunc(ctx, userKey)
+}
+
+// BulkDelete calls BulkDeleteFunc.
+func (m *UsersClient) BulkDelete(ctx context.Context, keys []string) (*models.BulkUserResponse, error) {
+	if m.BulkDeleteFunc == nil {
+		return nil, notMocked("UsersClient.BulkDelete")
+	}
+	return m.BulkDeleteFunc(ctx, keys)
+}
+
+// SyncUser calls SyncUserFunc.
+func (m *UsersClient) SyncUser(ctx context.Context, user models.UserCreate) (*models.UserRead, error) {
+	if m.SyncUserFunc == nil {
+		return nil, notMocked("UsersClient.SyncUser")
+	}
+	return m.SyncUserFunc(ctx, user)
+}
+
+// Upsert calls UpsertFunc.
+func (m *UsersClient) Upsert(ctx context.Context, user models.UserCreate) (*models.UserRead, bool, error) {
+	if m.UpsertFunc == nil {
+		return nil, false, notMocked("UsersClient.Upsert")
+	}
+	return m.UpsertFunc(ctx, user)
+}
+
+// AssignRole calls AssignRoleFunc.
+func (m *UsersClient) AssignRole(ctx context.Context, userKey string, role string, tenant string) (*models.RoleAssignmentRead, error) {
+	if m.AssignRoleFunc == nil {
+		return nil, notMocked("UsersClient.AssignRole")
+	}
+	return m.AssignRoleFunc(ctx, userKey, role, tenant)
+}
+
+// UnassignRole calls UnassignRoleFunc.
+func (m *UsersClient) UnassignRole(ctx context.Context, userKey string, role string, tenant string) error {
+	if m.UnassignRoleFunc == nil {
+		return notMocked("UsersClient.UnassignRole")
+	}
+	return m.UnassignRoleFunc(ctx, userKey, role, tenant)
+}
+
+// GetRoles calls GetRolesFunc.
+func (m *UsersClient) GetRoles(ctx context.Context, userKey string, tenant string) ([]string, error) {
+	if m.GetRolesFunc == nil {
+		return nil, notMocked("UsersClient.GetRoles")
+	}
+	return m.GetRolesFunc(ctx, userKey, tenant)
+}
+
+// AddTenant calls AddTenantFunc.
+func (m *UsersClient) AddTenant(ctx context.Context, userKey string, tenantKey string) error {
+	if m.AddTenantFunc == nil {
+		return notMocked("UsersClient.AddTenant")
+	}
+	return m.AddTenantFunc(ctx, userKey, tenantKey)
+}
+
+// AddTenantIfAbsent calls AddTenantIfAbsentFunc.
+func (m *UsersClient) AddTenantIfAbsent(ctx context.Context, userKey string, tenantKey string) (bool, error) {
+	if m.AddTenantIfAbsentFunc == nil {
+		return false, notMocked("UsersClient.AddTenantIfAbsent")
+	}
+	return m.AddTenantIfAbsentFunc(ctx, userKey, tenantKey)
+}
+
+// RemoveTenant calls RemoveTenantFunc.
+func (m *UsersClient) RemoveTenant(ctx context.Context, userKey string, tenantKey string) error {
+	if m.RemoveTenantFunc == nil {
+		return notMocked("UsersClient.RemoveTenant")
+	}
+	return m.RemoveTenantFunc(ctx, userKey, tenantKey)
+}
+
+// GetTenants calls GetTenantsFunc.
+func (m *UsersClient) GetTenants(ctx context.Context, userKey string) ([]string, error) {
+	if m.GetTenantsFunc == nil {
+		return nil, notMocked("UsersClient.GetTenants")
+	}
+	return m.GetTenantsFunc(ctx, userKey)
+}